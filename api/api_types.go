@@ -4,6 +4,8 @@ package api
 import (
 	"bytes"
 	"fmt"
+	"time"
+
 	"github.com/ipfs/go-cid"
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -327,3 +329,48 @@ type SectorOffset struct {
 	Sector abi.SectorNumber
 	Offset abi.PaddedPieceSize
 }
+
+// WinningPoStDryRun is the result of computing a winning PoSt for an epoch
+// without mining a block
+type WinningPoStDryRun struct {
+	Epoch           abi.ChainEpoch
+	EligibleSectors uint64
+	Challenged      []abi.SectorNumber
+	ProofSize       int
+
+	CandidatesTook time.Duration
+	ProofTook      time.Duration
+}
+
+// WindowPoStSimulation is the result of computing the window PoSt of the
+// deadlines without sending any message
+type WindowPoStSimulation struct {
+	Epoch abi.ChainEpoch
+	// ChallengeWindow is the time a deadline stays open
+	ChallengeWindow time.Duration
+	Deadlines       []DeadlineSimulation
+	Took            time.Duration
+}
+
+type DeadlineSimulation struct {
+	Index      uint64
+	Challenge  abi.ChainEpoch
+	Partitions int
+	Batches    []PoStBatchSimulation
+	Took       time.Duration
+	Error      string
+}
+
+// PoStBatchSimulation describes the partitions proven by a single
+// SubmitWindowedPoSt message
+type PoStBatchSimulation struct {
+	Partitions []uint64
+	// Checked sectors are the live and recovering ones, Skipped ones couldn't
+	// be proven, Faulty ones are declared faulty on chain
+	Checked uint64
+	Skipped uint64
+	Faulty  uint64
+	Proven  uint64
+	// Took is the proof generation time, retries included
+	Took time.Duration
+}
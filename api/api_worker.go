@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/proof"
 
 	"github.com/filecoin-project/venus-sealer/constants"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
@@ -21,10 +22,15 @@ type WorkerAPI interface {
 	Paths(context.Context) ([]stores.StoragePath, error)
 	Info(context.Context) (storiface.WorkerInfo, error)
 
-	TaskNumbers(context.Context) (string, error)
+	// Capacity returns the number of running tasks and the task limits of the worker
+	Capacity(context.Context) (storiface.WorkerCapacity, error)
 
 	storiface.WorkerCalls
 
+	// GenerateWindowPoSt proves the partition at partitionIdx of a window PoSt
+	// batch with the sectors read from the storage paths of the worker
+	GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (storiface.WindowPoStResult, error)
+
 	TaskDisable(ctx context.Context, tt types.TaskType) error
 	TaskEnable(ctx context.Context, tt types.TaskType) error
 
@@ -33,6 +39,10 @@ type WorkerAPI interface {
 
 	StorageAddLocal(ctx context.Context, path string) error
 
+	// StorageFetches returns the sector fetches of the worker, running then
+	// queued by priority
+	StorageFetches(ctx context.Context) ([]stores.FetchInfo, error)
+
 	// SetEnabled marks the worker as enabled/disabled. Not that this setting
 	// may take a few seconds to propagate to task scheduler
 	SetEnabled(ctx context.Context, enabled bool) error
@@ -32,6 +32,8 @@ import (
 
 	"github.com/filecoin-project/venus-sealer/api"
 	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/journal"
+	"github.com/filecoin-project/venus-sealer/models/repo"
 	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
@@ -66,8 +68,10 @@ type StorageMinerAPI struct {
 	Stor *stores.Remote
 
 	MarketClient         market.IMarket
+	Repo                 repo.Repo
 	LogService           *service.LogService
 	NetParams            *config.NetParamsConfig
+	Journal              journal.Journal
 	SetSealingConfigFunc types2.SetSealingConfigFunc
 	GetSealingConfigFunc types2.GetSealingConfigFunc
 }
@@ -156,6 +160,18 @@ func (sm *StorageMinerAPI) ComputeWindowPoSt(ctx context.Context, dlIdx uint64,
 	return sm.WdPoSt.ComputePoSt(ctx, dlIdx, ts)
 }
 
+func (sm *StorageMinerAPI) SimulateWindowPoSt(ctx context.Context, deadlines []uint64) (*api.WindowPoStSimulation, error) {
+	return sm.WdPoSt.Simulate(ctx, deadlines)
+}
+
+func (sm *StorageMinerAPI) SectorHealthList(ctx context.Context) ([]*types2.SectorHealth, error) {
+	return sm.WdPoSt.SectorHealth(ctx)
+}
+
+func (sm *StorageMinerAPI) ProvingHistory(ctx context.Context, from abi.ChainEpoch) ([]*types2.PartitionPoSt, error) {
+	return sm.WdPoSt.ProvingHistory(ctx, from)
+}
+
 func (sm *StorageMinerAPI) ComputeDataCid(ctx context.Context, pieceSize abi.UnpaddedPieceSize, pieceData sto.Data) (abi.PieceInfo, error) {
 	return sm.StorageMgr.DataCid(ctx, pieceSize, pieceData)
 }
@@ -194,10 +210,26 @@ func (sm *StorageMinerAPI) DealSector(ctx context.Context) ([]types2.DealAssign,
 	return sm.Miner.DealSector(ctx)
 }
 
+func (sm *StorageMinerAPI) DealPlan(ctx context.Context) (*types2.DealPackingPlan, error) {
+	return sm.Miner.DealPlan(ctx)
+}
+
+func (sm *StorageMinerAPI) DealReconcile(ctx context.Context, fix bool) ([]*types2.DealMismatch, error) {
+	return sm.Miner.DealReconcile(ctx, fix)
+}
+
 func (sm *StorageMinerAPI) RedoSector(ctx context.Context, rsi storiface.SectorRedoParams) error {
 	return sm.Miner.RedoSector(ctx, rsi)
 }
 
+func (sm *StorageMinerAPI) SectorsRecover(ctx context.Context, params storiface.SectorRecoverParams) ([]storiface.SectorRedoStatus, error) {
+	return sm.Miner.RecoverSectors(ctx, params)
+}
+
+func (sm *StorageMinerAPI) SectorRedoStatus(ctx context.Context, sid abi.SectorNumber) (storiface.SectorRedoStatus, error) {
+	return sm.Miner.RedoStatus(ctx, sid)
+}
+
 func (sm *StorageMinerAPI) SectorsStatus(ctx context.Context, sid abi.SectorNumber, showOnChainInfo bool) (api.SectorInfo, error) {
 	info, err := sm.Miner.GetSectorInfo(sid)
 	if err != nil {
@@ -495,6 +527,10 @@ func (sm *StorageMinerAPI) StorageLocal(ctx context.Context) (map[stores.ID]stri
 	return sm.StorageMgr.StorageLocal(ctx)
 }
 
+func (sm *StorageMinerAPI) StorageFetches(ctx context.Context) ([]stores.FetchInfo, error) {
+	return sm.Stor.Fetches(), nil
+}
+
 func (sm *StorageMinerAPI) SectorsRefs(context.Context) (map[string][]types2.SealedRef, error) {
 	// json can't handle cids as map keys
 	out := map[string][]types2.SealedRef{}
@@ -786,8 +822,23 @@ func (sm *StorageMinerAPI) PiecesGetCIDInfo(ctx context.Context, payloadCid cid.
 }
 
 func (sm *StorageMinerAPI) CreateBackup(ctx context.Context, fpath string) error {
-	//return backup(sm.DS, fpath)
-	panic("not impl")
+	return backup(ctx, sm.Repo, fpath)
+}
+
+func (sm *StorageMinerAPI) JournalList(ctx context.Context, q journal.Query) ([]*journal.Entry, error) {
+	r, ok := sm.Journal.(journal.Reader)
+	if !ok {
+		return nil, xerrors.Errorf("journal %T can't be read", sm.Journal)
+	}
+	return r.Query(q)
+}
+
+func (sm *StorageMinerAPI) JournalFollow(ctx context.Context, q journal.Query) (<-chan *journal.Entry, error) {
+	r, ok := sm.Journal.(journal.Reader)
+	if !ok {
+		return nil, xerrors.Errorf("journal %T can't be read", sm.Journal)
+	}
+	return r.Follow(ctx, q)
 }
 
 func (sm *StorageMinerAPI) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []sto.SectorRef, expensive bool) (map[abi.SectorNumber]string, error) {
@@ -836,6 +887,10 @@ func (sm *StorageMinerAPI) ComputeProof(ctx context.Context, ssi []builtin.Exten
 	return sm.Prover.ComputeProof(ctx, ssi, rand, poStEpoch, nv)
 }
 
+func (sm *StorageMinerAPI) ComputeWinningPoSt(ctx context.Context, epoch abi.ChainEpoch) (*api.WinningPoStDryRun, error) {
+	return storage.WinningPoStDryRun(ctx, sm.Full, sm.Prover, sm.Miner.Address(), epoch)
+}
+
 func (sm *StorageMinerAPI) MessagerWaitMessage(ctx context.Context, uuid string, confidence uint64) (*types.MsgLookup, error) {
 	msg, err := sm.Messager.WaitMessage(ctx, uuid, confidence)
 	if err != nil {
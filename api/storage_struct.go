@@ -21,6 +21,7 @@ import (
 	types2 "github.com/filecoin-project/venus/venus-shared/types"
 
 	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/journal"
 	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
@@ -46,6 +47,9 @@ type StorageMiner interface {
 	Common
 
 	ComputeProof(context.Context, []builtin.ExtendedSectorInfo, abi.PoStRandomness, abi.ChainEpoch, abinetwork.Version) ([]builtin.PoStProof, error)
+	// ComputeWinningPoSt computes a winning PoSt for the epoch without mining a block,
+	// 0 uses the chain head
+	ComputeWinningPoSt(ctx context.Context, epoch abi.ChainEpoch) (*WinningPoStDryRun, error)
 
 	NetParamsConfig(ctx context.Context) (*config.NetParamsConfig, error)
 
@@ -55,6 +59,15 @@ type StorageMiner interface {
 	ActorAddressConfig(ctx context.Context) (AddressConfig, error)
 
 	ComputeWindowPoSt(ctx context.Context, dlIdx uint64, tsk types2.TipSetKey) ([]miner.SubmitWindowedPoStParams, error)
+	// SimulateWindowPoSt computes the window PoSt of the deadlines, all of them when
+	// empty, without sending any message and reports the proving time
+	SimulateWindowPoSt(ctx context.Context, deadlines []uint64) (*WindowPoStSimulation, error)
+	// SectorHealthList returns the sector health recorded by the last sweep of
+	// every deadline
+	SectorHealthList(ctx context.Context) ([]*types.SectorHealth, error)
+	// ProvingHistory returns the window PoSt outcome of every partition of the
+	// deadlines opening at or after from, latest first
+	ProvingHistory(ctx context.Context, from abi.ChainEpoch) ([]*types.PartitionPoSt, error)
 
 	ComputeDataCid(ctx context.Context, pieceSize abi.UnpaddedPieceSize, pieceData storage.Data) (abi.PieceInfo, error)
 
@@ -65,6 +78,12 @@ type StorageMiner interface {
 
 	// Redo
 	RedoSector(ctx context.Context, rsi storiface.SectorRedoParams) error
+	// SectorRedoStatus returns the progress of the last redo of the sector
+	SectorRedoStatus(ctx context.Context, sid abi.SectorNumber) (storiface.SectorRedoStatus, error)
+	// SectorsRecover rebuilds the sealed replica and cache of committed CC sectors
+	// from the on-chain sector info, the progress of each sector is returned by
+	// SectorRedoStatus
+	SectorsRecover(ctx context.Context, params storiface.SectorRecoverParams) ([]storiface.SectorRedoStatus, error)
 
 	// Test WdPoSt
 	MockWindowPoSt(ctx context.Context, sis []builtin.ExtendedSectorInfo, rand abi.PoStRandomness) error
@@ -128,6 +147,9 @@ type StorageMiner interface {
 
 	StorageLocal(ctx context.Context) (map[stores.ID]string, error)
 	StorageStat(ctx context.Context, id stores.ID) (fsutil.FsStat, error)
+	// StorageFetches returns the sector fetches of the sealer process, running
+	// then queued by priority
+	StorageFetches(ctx context.Context) ([]stores.FetchInfo, error)
 
 	// WorkerConnect tells the node to connect to workers RPC
 	WorkerConnect(context.Context, string) error
@@ -165,12 +187,17 @@ type StorageMiner interface {
 	PiecesGetPieceInfo(ctx context.Context, pieceCid cid.Cid) (*piecestore.PieceInfo, error)
 	PiecesGetCIDInfo(ctx context.Context, payloadCid cid.Cid) (*piecestore.CIDInfo, error)
 
-	// CreateBackup creates node backup onder the specified file name. The
+	// CreateBackup creates a backup of the sealer database under the specified file name. The
 	// method requires that the venus-sealer is running with the
-	// LOTUS_BACKUP_BASE_PATH environment variable set to some path, and that
+	// VENUS_SEALER_BACKUP_BASE_PATH environment variable set to some path, and that
 	// the path specified when calling CreateBackup is within the base path
 	CreateBackup(ctx context.Context, fpath string) error
 
+	// JournalList returns the journal entries selected by the query, oldest first
+	JournalList(ctx context.Context, q journal.Query) ([]*journal.Entry, error)
+	// JournalFollow streams the journal entries selected by the query as they are recorded
+	JournalFollow(ctx context.Context, q journal.Query) (<-chan *journal.Entry, error)
+
 	CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, expensive bool) (map[abi.SectorNumber]string, error)
 
 	//messager
@@ -184,6 +211,8 @@ type StorageMiner interface {
 	UpdateDealStatus(ctx context.Context, dealId abi.DealID, status string) error
 
 	DealSector(ctx context.Context) ([]types.DealAssign, error)
+	DealPlan(ctx context.Context) (*types.DealPackingPlan, error)
+	DealReconcile(ctx context.Context, fix bool) ([]*types.DealMismatch, error)
 	IsUnsealed(ctx context.Context, sector storage.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize) (bool, error)
 
 	// SectorsUnsealPiece will Unseal a Sealed sector file for the given sector.
@@ -194,14 +223,18 @@ type StorageMiner interface {
 type StorageMinerStruct struct {
 	CommonStruct
 	Internal struct {
-		ComputeProof func(context.Context, []builtin.ExtendedSectorInfo, abi.PoStRandomness, abi.ChainEpoch, abinetwork.Version) ([]builtin.PoStProof, error) `perm:"read"`
+		ComputeProof       func(context.Context, []builtin.ExtendedSectorInfo, abi.PoStRandomness, abi.ChainEpoch, abinetwork.Version) ([]builtin.PoStProof, error) `perm:"read"`
+		ComputeWinningPoSt func(ctx context.Context, epoch abi.ChainEpoch) (*WinningPoStDryRun, error)                                                              `perm:"admin"`
 
 		ActorAddress       func(context.Context) (address.Address, error)                 `perm:"read"`
 		ActorSectorSize    func(context.Context, address.Address) (abi.SectorSize, error) `perm:"read"`
 		ActorAddressConfig func(ctx context.Context) (AddressConfig, error)               `perm:"read"`
 		NetParamsConfig    func(ctx context.Context) (*config.NetParamsConfig, error)     `perm:"read"`
 
-		ComputeWindowPoSt func(ctx context.Context, dlIdx uint64, tsk types2.TipSetKey) ([]miner.SubmitWindowedPoStParams, error) `perm:"admin"`
+		ComputeWindowPoSt  func(ctx context.Context, dlIdx uint64, tsk types2.TipSetKey) ([]miner.SubmitWindowedPoStParams, error) `perm:"admin"`
+		SimulateWindowPoSt func(ctx context.Context, deadlines []uint64) (*WindowPoStSimulation, error)                            `perm:"admin"`
+		SectorHealthList   func(ctx context.Context) ([]*types.SectorHealth, error)                                                `perm:"read"`
+		ProvingHistory     func(ctx context.Context, from abi.ChainEpoch) ([]*types.PartitionPoSt, error)                          `perm:"read"`
 
 		ComputeDataCid func(ctx context.Context, pieceSize abi.UnpaddedPieceSize, pieceData storage.Data) (abi.PieceInfo, error) `perm:"admin"`
 
@@ -209,7 +242,9 @@ type StorageMinerStruct struct {
 
 		CurrentSectorID func(ctx context.Context) (abi.SectorNumber, error) `perm:"read"`
 
-		RedoSector func(ctx context.Context, rsi storiface.SectorRedoParams) error `perm:"write"`
+		RedoSector       func(ctx context.Context, rsi storiface.SectorRedoParams) error                                       `perm:"write"`
+		SectorRedoStatus func(ctx context.Context, sid abi.SectorNumber) (storiface.SectorRedoStatus, error)                   `perm:"read"`
+		SectorsRecover   func(ctx context.Context, params storiface.SectorRecoverParams) ([]storiface.SectorRedoStatus, error) `perm:"write"`
 
 		MockWindowPoSt func(ctx context.Context, sis []builtin.ExtendedSectorInfo, rand abi.PoStRandomness) error `perm:"write"`
 
@@ -264,6 +299,7 @@ type StorageMinerStruct struct {
 		StorageList          func(context.Context) (map[stores.ID][]stores.Decl, error)                                                                                   `perm:"admin"`
 		StorageLocal         func(context.Context) (map[stores.ID]string, error)                                                                                          `perm:"admin"`
 		StorageStat          func(context.Context, stores.ID) (fsutil.FsStat, error)                                                                                      `perm:"admin"`
+		StorageFetches       func(context.Context) ([]stores.FetchInfo, error)                                                                                            `perm:"read"`
 		StorageAttach        func(context.Context, stores.StorageInfo, fsutil.FsStat) error                                                                               `perm:"admin"`
 		StorageDeclareSector func(context.Context, stores.ID, abi.SectorID, storiface.SectorFileType, bool) error                                                         `perm:"admin"`
 		StorageDropSector    func(context.Context, stores.ID, abi.SectorID, storiface.SectorFileType) error                                                               `perm:"admin"`
@@ -301,6 +337,9 @@ type StorageMinerStruct struct {
 
 		CreateBackup func(ctx context.Context, fpath string) error `perm:"admin"`
 
+		JournalList   func(ctx context.Context, q journal.Query) ([]*journal.Entry, error)      `perm:"read"`
+		JournalFollow func(ctx context.Context, q journal.Query) (<-chan *journal.Entry, error) `perm:"read"`
+
 		CheckProvable func(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, expensive bool) (map[abi.SectorNumber]string, error) `perm:"admin"`
 
 		MessagerWaitMessage func(ctx context.Context, uuid string, confidence uint64) (*types2.MsgLookup, error)    `perm:"read"`
@@ -312,7 +351,9 @@ type StorageMinerStruct struct {
 		// SectorsUnsealPiece will Unseal a Sealed sector file for the given sector.
 		SectorsUnsealPiece func(ctx context.Context, sector storage.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, commd *cid.Cid) error `perm:"write"`
 
-		DealSector func(ctx context.Context) ([]types.DealAssign, error) `perm:"admin"`
+		DealSector    func(ctx context.Context) ([]types.DealAssign, error)              `perm:"admin"`
+		DealPlan      func(ctx context.Context) (*types.DealPackingPlan, error)          `perm:"read"`
+		DealReconcile func(ctx context.Context, fix bool) ([]*types.DealMismatch, error) `perm:"admin"`
 
 		GetDeals           func(ctx context.Context, pageIndex, pageSize int) ([]*mtypes.DealInfo, error) `perm:"admin"`
 		MarkDealsAsPacking func(ctx context.Context, deals []abi.DealID) error                            `perm:"admin"`
@@ -348,6 +389,18 @@ func (c *StorageMinerStruct) ComputeWindowPoSt(ctx context.Context, dlIdx uint64
 	return c.Internal.ComputeWindowPoSt(ctx, dlIdx, tsk)
 }
 
+func (c *StorageMinerStruct) SimulateWindowPoSt(ctx context.Context, deadlines []uint64) (*WindowPoStSimulation, error) {
+	return c.Internal.SimulateWindowPoSt(ctx, deadlines)
+}
+
+func (c *StorageMinerStruct) SectorHealthList(ctx context.Context) ([]*types.SectorHealth, error) {
+	return c.Internal.SectorHealthList(ctx)
+}
+
+func (c *StorageMinerStruct) ProvingHistory(ctx context.Context, from abi.ChainEpoch) ([]*types.PartitionPoSt, error) {
+	return c.Internal.ProvingHistory(ctx, from)
+}
+
 func (c *StorageMinerStruct) ComputeDataCid(ctx context.Context, pieceSize abi.UnpaddedPieceSize, pieceData storage.Data) (abi.PieceInfo, error) {
 	return c.Internal.ComputeDataCid(ctx, pieceSize, pieceData)
 }
@@ -365,6 +418,14 @@ func (c *StorageMinerStruct) RedoSector(ctx context.Context, rsi storiface.Secto
 	return c.Internal.RedoSector(ctx, rsi)
 }
 
+func (c *StorageMinerStruct) SectorRedoStatus(ctx context.Context, sid abi.SectorNumber) (storiface.SectorRedoStatus, error) {
+	return c.Internal.SectorRedoStatus(ctx, sid)
+}
+
+func (c *StorageMinerStruct) SectorsRecover(ctx context.Context, params storiface.SectorRecoverParams) ([]storiface.SectorRedoStatus, error) {
+	return c.Internal.SectorsRecover(ctx, params)
+}
+
 func (c *StorageMinerStruct) MockWindowPoSt(ctx context.Context, sis []builtin.ExtendedSectorInfo, rand abi.PoStRandomness) error {
 	return c.Internal.MockWindowPoSt(ctx, sis, rand)
 }
@@ -586,6 +647,10 @@ func (c *StorageMinerStruct) StorageStat(ctx context.Context, id stores.ID) (fsu
 	return c.Internal.StorageStat(ctx, id)
 }
 
+func (c *StorageMinerStruct) StorageFetches(ctx context.Context) ([]stores.FetchInfo, error) {
+	return c.Internal.StorageFetches(ctx)
+}
+
 func (c *StorageMinerStruct) StorageInfo(ctx context.Context, id stores.ID) (stores.StorageInfo, error) {
 	return c.Internal.StorageInfo(ctx, id)
 }
@@ -698,6 +763,14 @@ func (c *StorageMinerStruct) CreateBackup(ctx context.Context, fpath string) err
 	return c.Internal.CreateBackup(ctx, fpath)
 }
 
+func (c *StorageMinerStruct) JournalList(ctx context.Context, q journal.Query) ([]*journal.Entry, error) {
+	return c.Internal.JournalList(ctx, q)
+}
+
+func (c *StorageMinerStruct) JournalFollow(ctx context.Context, q journal.Query) (<-chan *journal.Entry, error) {
+	return c.Internal.JournalFollow(ctx, q)
+}
+
 func (c *StorageMinerStruct) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, expensive bool) (map[abi.SectorNumber]string, error) {
 	return c.Internal.CheckProvable(ctx, pp, sectors, expensive)
 }
@@ -706,6 +779,10 @@ func (c *StorageMinerStruct) ComputeProof(ctx context.Context, ssi []builtin.Ext
 	return c.Internal.ComputeProof(ctx, ssi, rand, poStEpoch, nv)
 }
 
+func (c *StorageMinerStruct) ComputeWinningPoSt(ctx context.Context, epoch abi.ChainEpoch) (*WinningPoStDryRun, error) {
+	return c.Internal.ComputeWinningPoSt(ctx, epoch)
+}
+
 func (c *StorageMinerStruct) MessagerWaitMessage(ctx context.Context, uuid string, confidence uint64) (*types2.MsgLookup, error) {
 	return c.Internal.MessagerWaitMessage(ctx, uuid, confidence)
 }
@@ -722,6 +799,14 @@ func (c *StorageMinerStruct) DealSector(ctx context.Context) ([]types.DealAssign
 	return c.Internal.DealSector(ctx)
 }
 
+func (c *StorageMinerStruct) DealPlan(ctx context.Context) (*types.DealPackingPlan, error) {
+	return c.Internal.DealPlan(ctx)
+}
+
+func (c *StorageMinerStruct) DealReconcile(ctx context.Context, fix bool) ([]*types.DealMismatch, error) {
+	return c.Internal.DealReconcile(ctx, fix)
+}
+
 func (c *StorageMinerStruct) GetDeals(ctx context.Context, pageIndex, pageSize int) ([]*mtypes.DealInfo, error) {
 	return c.Internal.GetDeals(ctx, pageIndex, pageSize)
 }
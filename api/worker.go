@@ -8,6 +8,7 @@ import (
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 
 	"github.com/filecoin-project/venus-sealer/constants"
@@ -42,15 +43,18 @@ type WorkerStruct struct {
 		MoveStorage               func(ctx context.Context, sector storage.SectorRef, types storiface.SectorFileType) (types.CallID, error)                                                                                 `perm:"admin"`
 		UnsealPiece               func(context.Context, storage.SectorRef, storiface.UnpaddedByteIndex, abi.UnpaddedPieceSize, abi.SealRandomness, cid.Cid) (types.CallID, error)                                           `perm:"admin"`
 		ReadPiece                 func(context.Context, io.Writer, storage.SectorRef, storiface.UnpaddedByteIndex, abi.UnpaddedPieceSize) (types.CallID, error)                                                             `perm:"admin"`
-		Fetch                     func(context.Context, storage.SectorRef, storiface.SectorFileType, storiface.PathType, storiface.AcquireMode) (types.CallID, error)                                                       `perm:"admin"`
+		Fetch                     func(context.Context, storage.SectorRef, storiface.SectorFileType, storiface.PathType, storiface.AcquireMode, int) (types.CallID, error)                                                  `perm:"admin"`
+
+		GenerateWindowPoSt func(ctx context.Context, minerID abi.ActorID, sectors []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (storiface.WindowPoStResult, error) `perm:"admin"`
 
 		TaskDisable func(ctx context.Context, tt types.TaskType) error `perm:"admin"`
 		TaskEnable  func(ctx context.Context, tt types.TaskType) error `perm:"admin"`
 
-		TaskNumbers func(context.Context) (string, error) `perm:"admin"`
+		Capacity func(context.Context) (storiface.WorkerCapacity, error) `perm:"admin"`
 
-		Remove          func(ctx context.Context, sector abi.SectorID) error `perm:"admin"`
-		StorageAddLocal func(ctx context.Context, path string) error         `perm:"admin"`
+		Remove          func(ctx context.Context, sector abi.SectorID) error  `perm:"admin"`
+		StorageAddLocal func(ctx context.Context, path string) error          `perm:"admin"`
+		StorageFetches  func(ctx context.Context) ([]stores.FetchInfo, error) `perm:"admin"`
 
 		SetEnabled func(ctx context.Context, enabled bool) error `perm:"admin"`
 		Enabled    func(ctx context.Context) (bool, error)       `perm:"admin"`
@@ -147,8 +151,12 @@ func (w *WorkerStruct) ReadPiece(ctx context.Context, sink io.Writer, sector sto
 	return w.Internal.ReadPiece(ctx, sink, sector, offset, size)
 }
 
-func (w *WorkerStruct) Fetch(ctx context.Context, id storage.SectorRef, fileType storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode) (types.CallID, error) {
-	return w.Internal.Fetch(ctx, id, fileType, ptype, am)
+func (w *WorkerStruct) Fetch(ctx context.Context, id storage.SectorRef, fileType storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode, priority int) (types.CallID, error) {
+	return w.Internal.Fetch(ctx, id, fileType, ptype, am, priority)
+}
+
+func (w *WorkerStruct) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (storiface.WindowPoStResult, error) {
+	return w.Internal.GenerateWindowPoSt(ctx, minerID, sectors, partitionIdx, randomness)
 }
 
 func (w *WorkerStruct) TaskDisable(ctx context.Context, tt types.TaskType) error {
@@ -159,8 +167,8 @@ func (w *WorkerStruct) TaskEnable(ctx context.Context, tt types.TaskType) error
 	return w.Internal.TaskEnable(ctx, tt)
 }
 
-func (w *WorkerStruct) TaskNumbers(ctx context.Context) (string, error) {
-	return w.Internal.TaskNumbers(ctx)
+func (w *WorkerStruct) Capacity(ctx context.Context) (storiface.WorkerCapacity, error) {
+	return w.Internal.Capacity(ctx)
 }
 
 func (w *WorkerStruct) Remove(ctx context.Context, sector abi.SectorID) error {
@@ -171,6 +179,10 @@ func (w *WorkerStruct) StorageAddLocal(ctx context.Context, path string) error {
 	return w.Internal.StorageAddLocal(ctx, path)
 }
 
+func (w *WorkerStruct) StorageFetches(ctx context.Context) ([]stores.FetchInfo, error) {
+	return w.Internal.StorageFetches(ctx)
+}
+
 func (w *WorkerStruct) SetEnabled(ctx context.Context, enabled bool) error {
 	return w.Internal.SetEnabled(ctx, enabled)
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/api"
+)
+
+var backupCmd = &cli.Command{
+	Name:  "backup",
+	Usage: "Create and restore backups of the sealer database",
+	Subcommands: []*cli.Command{
+		backupCreateCmd,
+		backupRestoreCmd,
+	},
+}
+
+var backupCreateCmd = &cli.Command{
+	Name:  "create",
+	Usage: "Create a checksummed backup of sector infos, logs, worker calls/states, deal refs and metadata",
+	Description: `The backup can be created online or offline.
+
+Online backups are written by the running sealer, which requires it to be
+started with VENUS_SEALER_BACKUP_BASE_PATH set, and the backup file to be
+inside that path.
+
+Offline backups (--offline) read the database directly, the sealer must be stopped.`,
+	ArgsUsage: "[backup file path]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "offline",
+			Usage: "create backup without the sealer running",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		fpath, err := homedir.Expand(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("expanding file path: %w", err)
+		}
+		fpath, err = filepath.Abs(fpath)
+		if err != nil {
+			return xerrors.Errorf("getting absolute file path: %w", err)
+		}
+
+		if !cctx.Bool("offline") {
+			nodeApi, closer, err := api.GetStorageMinerAPI(cctx)
+			if err != nil {
+				return err
+			}
+			defer closer()
+
+			if err := nodeApi.CreateBackup(api.ReqContext(cctx), fpath); err != nil {
+				return err
+			}
+
+			log.Infof("Success")
+			return nil
+		}
+
+		r, closer, err := openRepoOffline(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		out, err := os.OpenFile(fpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return xerrors.Errorf("opening backup file %s: %w", fpath, err)
+		}
+
+		if err := r.Backup(cctx.Context, out); err != nil {
+			if cerr := out.Close(); cerr != nil {
+				log.Errorw("error closing backup file while handling backup error", "closeErr", cerr, "backupErr", err)
+			}
+			return xerrors.Errorf("backup error: %w", err)
+		}
+
+		if err := out.Close(); err != nil {
+			return xerrors.Errorf("closing backup file: %w", err)
+		}
+
+		log.Infof("Success")
+		return nil
+	},
+}
+
+var backupRestoreCmd = &cli.Command{
+	Name:  "restore",
+	Usage: "Restore the sealer database from a backup created by `backup create`",
+	Description: `Replaces every table of the database configured in the repo with the content
+of the backup, the sealer must be stopped. To rebuild a lost host, copy the
+config.toml of the old repo (or run init with the same actor) and then restore.`,
+	ArgsUsage: "[backup file path]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "overwrite a database which already contains sectors",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return xerrors.Errorf("expected 1 argument")
+		}
+
+		fpath, err := homedir.Expand(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("expanding file path: %w", err)
+		}
+
+		r, closer, err := openRepoOffline(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if err := r.AutoMigrate(); err != nil {
+			return xerrors.Errorf("migrating database: %w", err)
+		}
+
+		sectors, err := r.SectorInfoRepo().GetAllSectorInfos()
+		if err != nil {
+			return xerrors.Errorf("listing existing sectors: %w", err)
+		}
+		if len(sectors) > 0 && !cctx.Bool("really-do-it") {
+			return xerrors.Errorf("database already contains %d sectors, pass --really-do-it to overwrite it", len(sectors))
+		}
+
+		in, err := os.Open(fpath)
+		if err != nil {
+			return xerrors.Errorf("opening backup file %s: %w", fpath, err)
+		}
+		defer in.Close() //nolint:errcheck
+
+		if err := r.Restore(cctx.Context, in); err != nil {
+			return xerrors.Errorf("restoring backup: %w", err)
+		}
+
+		log.Infof("Success")
+		return nil
+	},
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models"
+)
+
+var dbCmd = &cli.Command{
+	Name:  "db",
+	Usage: "Manage the sealer database",
+	Subcommands: []*cli.Command{
+		dbMigrateCmd,
+	},
+}
+
+var dbMigrateCmd = &cli.Command{
+	Name:  "migrate",
+	Usage: "Copy the sealer database from one db type to another",
+	Description: `Copies every table from the database of type --from to the database of type
+--to, both are configured by the [DB.*] sections of config.toml. The row count of
+each table and a checksum of every sector are verified after the copy. A
+snapshot of the source is kept in the repo, it can be loaded again with
+'backup restore'. The sealer must be stopped.
+
+Example: venus-sealer db migrate --from sqlite --to mysql --switch`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "source db type, one of sqlite, mysql, postgres",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "to",
+			Usage:    "target db type, one of sqlite, mysql, postgres",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "switch",
+			Usage: "set DB.Type in config.toml to the target db type after a successful migration",
+		},
+		&cli.BoolFlag{
+			Name:  "really-do-it",
+			Usage: "overwrite a target database which already contains sectors",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		from, to := cctx.String("from"), cctx.String("to")
+		if from == to {
+			return xerrors.Errorf("source and target db type are both %s", from)
+		}
+
+		cfg, unlock, err := lockRepoOffline(cctx)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// SetDataBase modifies the db config passed in, work on copies
+		fromCfg := cfg.DB
+		fromCfg.Type = from
+		src, err := models.SetDataBase(config.HomeDir(cfg.DataDir), &fromCfg)
+		if err != nil {
+			return xerrors.Errorf("opening source database: %w", err)
+		}
+		defer src.DbClose() //nolint:errcheck
+
+		toCfg := cfg.DB
+		toCfg.Type = to
+		dst, err := models.SetDataBase(config.HomeDir(cfg.DataDir), &toCfg)
+		if err != nil {
+			return xerrors.Errorf("opening target database: %w", err)
+		}
+		defer dst.DbClose() //nolint:errcheck
+
+		if err := dst.AutoMigrate(); err != nil {
+			return xerrors.Errorf("migrating target database: %w", err)
+		}
+		sectors, err := dst.SectorInfoRepo().GetAllSectorInfos()
+		if err != nil {
+			return xerrors.Errorf("listing sectors in target database: %w", err)
+		}
+		if len(sectors) > 0 && !cctx.Bool("really-do-it") {
+			return xerrors.Errorf("target database already contains %d sectors, pass --really-do-it to overwrite it", len(sectors))
+		}
+
+		dataDir, err := homedir.Expand(cfg.DataDir)
+		if err != nil {
+			return err
+		}
+		snapshot := filepath.Join(dataDir, fmt.Sprintf("db-migrate-%s-%s-%d.bak", from, to, time.Now().Unix()))
+		log.Infof("migrating database from %s to %s, snapshot of the source: %s", from, to, snapshot)
+
+		if err := models.Migrate(cctx.Context, src, dst, snapshot); err != nil {
+			return xerrors.Errorf("migrating database: %w", err)
+		}
+
+		if cctx.Bool("switch") {
+			cfg.DB.Type = to
+			if err := config.UpdateConfig(config.FsConfig(cctx.String("repo")), cfg); err != nil {
+				return xerrors.Errorf("updating config: %w", err)
+			}
+			log.Infof("DB.Type switched to %s", to)
+		} else if cfg.DB.Type != to {
+			log.Infof("set DB.Type to %q in config.toml to use the migrated database", to)
+		}
+
+		log.Infof("Success")
+		return nil
+	},
+}
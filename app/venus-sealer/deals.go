@@ -1,9 +1,12 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/venus-sealer/api"
 	"github.com/filecoin-project/venus-sealer/lib/tablewriter"
+	"github.com/filecoin-project/venus-sealer/types"
 	"github.com/urfave/cli/v2"
 	"math"
 	"os"
@@ -16,6 +19,8 @@ var dealsCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		dealListCmd,
 		updateDealStatusListCmd,
+		dealPlanCmd,
+		dealReconcileCmd,
 	},
 }
 
@@ -103,3 +108,152 @@ var updateDealStatusListCmd = &cli.Command{
 		return nil
 	},
 }
+
+var dealPlanCmd = &cli.Command{
+	Name:  "plan",
+	Usage: "show the deal to sector assignment the packing policy proposes, without packing any deal",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the plan as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := api.ReqContext(cctx)
+
+		plan, err := nodeApi.DealPlan(ctx)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(plan)
+		}
+
+		fmt.Printf("Head: %d, %d sectors planned, %d deals deferred\n\n", plan.Head, len(plan.Sectors), len(plan.Deferred))
+
+		tw := tablewriter.New(
+			tablewriter.Col("Sector"),
+			tablewriter.Col("Group"),
+			tablewriter.Col("DealId"),
+			tablewriter.Col("PieceCID"),
+			tablewriter.Col("PieceSize"),
+			tablewriter.Col("StartEpoch"),
+			tablewriter.Col("EndEpoch"),
+			tablewriter.Col("Utilisation"),
+		)
+		for i, sector := range plan.Sectors {
+			for _, deal := range sector.Deals {
+				tw.Write(map[string]interface{}{
+					"Sector":      fmt.Sprintf("#%d", i),
+					"Group":       sector.Group,
+					"DealId":      deal.DealID,
+					"PieceCID":    deal.PieceCID,
+					"PieceSize":   deal.Size,
+					"StartEpoch":  deal.StartEpoch,
+					"EndEpoch":    deal.EndEpoch,
+					"Utilisation": fmt.Sprintf("%.1f%%", sector.Utilisation*100),
+				})
+			}
+		}
+		if err := tw.Flush(os.Stdout); err != nil {
+			return err
+		}
+
+		if len(plan.Deferred) == 0 {
+			return nil
+		}
+
+		fmt.Println("\nDeferred:")
+		tw = tablewriter.New(
+			tablewriter.Col("DealId"),
+			tablewriter.Col("PieceSize"),
+			tablewriter.Col("StartEpoch"),
+			tablewriter.Col("Reason"),
+		)
+		for _, deal := range plan.Deferred {
+			tw.Write(map[string]interface{}{
+				"DealId":     deal.DealID,
+				"PieceSize":  deal.Size,
+				"StartEpoch": deal.StartEpoch,
+				"Reason":     deal.Reason,
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
+
+var dealReconcileCmd = &cli.Command{
+	Name:  "reconcile",
+	Usage: "compare the deals of venus-market with the deal pieces of the sectors",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "fix",
+			Usage: "update venus-market to match the sector pieces",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the mismatches as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := api.ReqContext(cctx)
+
+		mismatches, err := nodeApi.DealReconcile(ctx, cctx.Bool("fix"))
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(mismatches)
+		}
+
+		if len(mismatches) == 0 {
+			fmt.Println("venus-market and the sectors agree on every deal")
+			return nil
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("DealId"),
+			tablewriter.Col("Kind"),
+			tablewriter.Col("Sector"),
+			tablewriter.Col("Offset"),
+			tablewriter.Col("MarketStatus"),
+			tablewriter.Col("MarketSector"),
+			tablewriter.Col("MarketOffset"),
+			tablewriter.Col("Fixed"),
+			tablewriter.NewLineCol("Error"),
+		)
+		for _, mismatch := range mismatches {
+			row := map[string]interface{}{
+				"DealId":       mismatch.DealID,
+				"Kind":         mismatch.Kind,
+				"MarketStatus": mismatch.MarketStatus,
+				"MarketSector": mismatch.MarketSector,
+				"MarketOffset": mismatch.MarketOffset,
+				"Fixed":        mismatch.Fixed,
+				"Error":        mismatch.Error,
+			}
+			if mismatch.Kind != types.DealMissing {
+				row["Sector"] = mismatch.SectorNumber
+				row["Offset"] = mismatch.Offset
+			}
+			tw.Write(row)
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
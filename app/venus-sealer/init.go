@@ -127,6 +127,10 @@ var initCmd = &cli.Command{
 			Name:  "gateway-token",
 			Usage: "gateway token",
 		},
+		&cli.BoolFlag{
+			Name:  "native-winning-post",
+			Usage: "let block producers call ComputeProof on the sealer API, the gateway becomes optional",
+		},
 
 		&cli.StringFlag{
 			Name:  "market-mode",
@@ -357,6 +361,9 @@ func parseServiceFlag(cfg *config.StorageMiner, cctx *cli.Context) error {
 	if cctx.IsSet("gateway-token") {
 		cfg.RegisterProof.Token = cctx.String("gateway-token")
 	}
+	if cctx.IsSet("native-winning-post") {
+		cfg.RegisterProof.Native = cctx.Bool("native-winning-post")
+	}
 
 	if cctx.IsSet("market-mode") {
 		mode := cctx.String("market-mode")
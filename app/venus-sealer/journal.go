@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/api"
+	"github.com/filecoin-project/venus-sealer/journal"
+	"github.com/filecoin-project/venus-sealer/lib/tablewriter"
+)
+
+var journalCmd = &cli.Command{
+	Name:  "journal",
+	Usage: "Query the event journal of the sealer",
+	Description: `Prints the journal entries matching the filters, or follows new ones with --follow.
+
+   Times may be given as RFC3339 (2021-05-01T15:04:05+08:00), as 2006-01-02 15:04:05
+   in local time, or as a duration before now (30m, 2h).
+
+   eg) journal --system storage --event sealing_states --sector 100
+       journal --since 1h --json
+       journal --follow --system wdpost`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "system",
+			Usage: "only show events of this system, e.g. storage, wdpost",
+		},
+		&cli.StringFlag{
+			Name:  "event",
+			Usage: "only show events with this name, e.g. sealing_states, scheduler",
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "only show events recorded at or after this time",
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: "only show events recorded before this time",
+		},
+		&cli.Int64Flag{
+			Name:  "sector",
+			Usage: "only show events of this sector number",
+			Value: -1,
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "show the latest n events, 0 shows all of them",
+			Value: 100,
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print one json object per event",
+		},
+		&cli.BoolFlag{
+			Name:    "follow",
+			Usage:   "wait for new events and print them as they are recorded",
+			Aliases: []string{"f"},
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := api.ReqContext(cctx)
+
+		q := journal.Query{
+			System: cctx.String("system"),
+			Event:  cctx.String("event"),
+			Limit:  cctx.Int("limit"),
+		}
+		if q.Since, err = parseJournalTime(cctx.String("since")); err != nil {
+			return xerrors.Errorf("parsing --since: %w", err)
+		}
+		if q.Until, err = parseJournalTime(cctx.String("until")); err != nil {
+			return xerrors.Errorf("parsing --until: %w", err)
+		}
+		if sn := cctx.Int64("sector"); sn >= 0 {
+			sector := abi.SectorNumber(sn)
+			q.Sector = &sector
+		}
+
+		if cctx.Bool("follow") {
+			if !q.Until.IsZero() {
+				return xerrors.New("--until can't be used with --follow")
+			}
+			q.Limit = 0
+
+			entries, err := storageAPI.JournalFollow(ctx, q)
+			if err != nil {
+				return err
+			}
+			enc := json.NewEncoder(os.Stdout)
+			for entry := range entries {
+				if cctx.Bool("json") {
+					if err := enc.Encode(entry); err != nil {
+						return err
+					}
+					continue
+				}
+				fmt.Printf("%s  %s:%s  %s\n", entry.Timestamp.Local().Format(journalTimeFormat), entry.System, entry.Event, string(entry.Data))
+			}
+			return nil
+		}
+
+		entries, err := storageAPI.JournalList(ctx, q)
+		if err != nil {
+			return err
+		}
+		return printJournalEntries(cctx, entries)
+	},
+}
+
+const journalTimeFormat = "2006-01-02 15:04:05.000"
+
+func parseJournalTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02 15:04:05", s, time.Local)
+}
+
+func printJournalEntries(cctx *cli.Context, entries []*journal.Entry) error {
+	if cctx.Bool("json") {
+		enc := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("no journal entries found")
+		return nil
+	}
+
+	tw := tablewriter.New(
+		tablewriter.Col("Time"),
+		tablewriter.Col("System"),
+		tablewriter.Col("Event"),
+		tablewriter.NewLineCol("Data"),
+	)
+	for _, entry := range entries {
+		tw.Write(map[string]interface{}{
+			"Time":   entry.Timestamp.Local().Format(journalTimeFormat),
+			"System": entry.System,
+			"Event":  entry.Event,
+			"Data":   string(entry.Data),
+		})
+	}
+	return tw.Flush(os.Stdout)
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	//"encoding/json"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
@@ -24,7 +24,9 @@ import (
 	"github.com/filecoin-project/venus/venus-shared/types"
 
 	"github.com/filecoin-project/venus-sealer/api"
+	"github.com/filecoin-project/venus-sealer/constants"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	types2 "github.com/filecoin-project/venus-sealer/types"
 )
 
 var provingCmd = &cli.Command{
@@ -43,6 +45,320 @@ var provingCmd = &cli.Command{
 		provingCheckProvableCmd,
 		provingMockWdPoStTaskCmd,
 		provingComputeCmd,
+		provingWinningPoStCmd,
+		provingSimulateCmd,
+		provingHealthCmd,
+		provingHistoryCmd,
+	},
+}
+
+var provingHistoryCmd = &cli.Command{
+	Name:  "history",
+	Usage: "View the window PoSt outcome of every partition in the last proving periods",
+	Flags: []cli.Flag{
+		&cli.Uint64Flag{
+			Name:  "periods",
+			Usage: "number of proving periods to show",
+			Value: 1,
+		},
+		&cli.IntSliceFlag{
+			Name:  "deadline",
+			Usage: "only show these deadlines",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the history as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		nodeAPI, acloser, err := api.GetFullNodeAPIV2(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := api.ReqContext(cctx)
+
+		maddr, err := getActorAddress(ctx, storageAPI, cctx.String("actor"))
+		if err != nil {
+			return err
+		}
+
+		di, err := nodeAPI.StateMinerProvingDeadline(ctx, maddr, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("getting proving deadline: %w", err)
+		}
+
+		deadlines := map[uint64]struct{}{}
+		for _, dl := range cctx.IntSlice("deadline") {
+			if dl < 0 {
+				return xerrors.Errorf("invalid deadline %d", dl)
+			}
+			deadlines[uint64(dl)] = struct{}{}
+		}
+
+		from := di.CurrentEpoch - abi.ChainEpoch(cctx.Uint64("periods"))*di.WPoStProvingPeriod
+		records, err := storageAPI.ProvingHistory(ctx, from)
+		if err != nil {
+			return err
+		}
+
+		var out []*types2.PartitionPoSt
+		for _, record := range records {
+			if _, ok := deadlines[record.Deadline]; len(deadlines) > 0 && !ok {
+				continue
+			}
+			out = append(out, record)
+		}
+
+		if cctx.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(out)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Open\tDeadline\tPartition\tStatus\tSkipped\tFaults\tRecoveries\tProof Time\tMessage")
+		for _, record := range out {
+			status := string(record.Status)
+			switch record.Status {
+			case types2.PoStProven:
+				status = color.GreenString(status)
+			case types2.PoStFailed:
+				status = color.RedString("%s (%s)", status, record.Error)
+			case types2.PoStSkipped:
+				status = color.YellowString(status)
+			}
+
+			skipped, err := record.Skipped.Count()
+			if err != nil {
+				return err
+			}
+			faults, err := record.DeclaredFaults.Count()
+			if err != nil {
+				return err
+			}
+			recoveries, err := record.DeclaredRecoveries.Count()
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%d\t%d\t%d\t%s\t%s\n", record.Open, record.Deadline, record.Partition, status,
+				skipped, faults, recoveries, record.ProvingTime.Truncate(time.Millisecond), record.MessageUID)
+		}
+		return tw.Flush()
+	},
+}
+
+var provingHealthCmd = &cli.Command{
+	Name:  "health",
+	Usage: "View the sector health recorded by the sweeps ahead of each deadline",
+	Description: `Note: The sweeps run Proving.HealthSweepLeadEpochs before a deadline opens, each
+deadline shows the result of its last sweep. The sweeps are disabled unless
+Proving.HealthSweepLeadEpochs is set.`,
+	Flags: []cli.Flag{
+		&cli.IntSliceFlag{
+			Name:  "deadline",
+			Usage: "only show these deadlines",
+		},
+		&cli.BoolFlag{
+			Name:  "unhealthy",
+			Usage: "only show the unhealthy sectors",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the sector health as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := api.ReqContext(cctx)
+
+		deadlines := map[uint64]struct{}{}
+		for _, dl := range cctx.IntSlice("deadline") {
+			if dl < 0 {
+				return xerrors.Errorf("invalid deadline %d", dl)
+			}
+			deadlines[uint64(dl)] = struct{}{}
+		}
+
+		healths, err := storageAPI.SectorHealthList(ctx)
+		if err != nil {
+			return err
+		}
+
+		var out []*types2.SectorHealth
+		unhealthy := 0
+		for _, h := range healths {
+			if _, ok := deadlines[h.Deadline]; len(deadlines) > 0 && !ok {
+				continue
+			}
+			if !h.Healthy {
+				unhealthy++
+			} else if cctx.Bool("unhealthy") {
+				continue
+			}
+			out = append(out, h)
+		}
+
+		if cctx.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(out)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Deadline\tPartition\tSector\tStatus\tFault Declared\tDeadline Open\tChecked At")
+		for _, h := range out {
+			status := color.GreenString("good")
+			if !h.Healthy {
+				status = color.RedString("bad (%s)", h.Reason)
+			}
+			_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%t\t%d\t%s\n", h.Deadline, h.Partition, h.SectorNumber, status,
+				h.FaultDeclared, h.DeadlineOpen, time.Unix(h.CheckedAt, 0).Format(time.RFC3339))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		if unhealthy > 0 {
+			color.Red("\n%d unhealthy sectors", unhealthy)
+		}
+		return nil
+	},
+}
+
+var provingSimulateCmd = &cli.Command{
+	Name:  "simulate",
+	Usage: "Compute the window PoSt of every deadline and report the proving time",
+	Description: `Note: This command runs the window PoSt of the deadlines in compute-only mode, no
+message is sent to the chain. Faulty sectors are checked too, the proving time may be
+longer than the one of the actual window PoSt.`,
+	Flags: []cli.Flag{
+		&cli.IntSliceFlag{
+			Name:  "deadline",
+			Usage: "only simulate these deadlines",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the report as json",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := api.ReqContext(cctx)
+
+		var deadlines []uint64
+		for _, dl := range cctx.IntSlice("deadline") {
+			if dl < 0 {
+				return xerrors.Errorf("invalid deadline %d", dl)
+			}
+			deadlines = append(deadlines, uint64(dl))
+		}
+
+		res, err := storageAPI.SimulateWindowPoSt(ctx, deadlines)
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(res)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "Deadline\tBatch\tPartitions\tChecked\tSkipped\tFaulty\tProven\tProof Time")
+		var overWindow []uint64
+		for _, dl := range res.Deadlines {
+			if dl.Error != "" {
+				_, _ = fmt.Fprintf(tw, "%d\t-\t%d\t\t\t\t\t%s\n", dl.Index, dl.Partitions, color.RedString("error: %s", dl.Error))
+			}
+			if dl.Partitions == 0 && dl.Error == "" {
+				_, _ = fmt.Fprintf(tw, "%d\t-\t0\t\t\t\t\t\n", dl.Index)
+			}
+
+			var proving time.Duration
+			for i, b := range dl.Batches {
+				proving += b.Took
+				skipped := fmt.Sprint(b.Skipped)
+				if b.Skipped > 0 {
+					skipped = color.YellowString(skipped)
+				}
+				_, _ = fmt.Fprintf(tw, "%d\t%d\t%v\t%d\t%s\t%d\t%d\t%s\n", dl.Index, i, b.Partitions, b.Checked, skipped, b.Faulty, b.Proven, b.Took.Truncate(time.Millisecond))
+			}
+			if proving > res.ChallengeWindow {
+				overWindow = append(overWindow, dl.Index)
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nSimulated %d deadlines at epoch %d in %s, challenge window: %s\n", len(res.Deadlines), res.Epoch, res.Took.Truncate(time.Second), res.ChallengeWindow)
+		if len(overWindow) > 0 {
+			color.Red("WARNING: the proving time of deadlines %v exceeds the challenge window", overWindow)
+		}
+		return nil
+	},
+}
+
+var provingWinningPoStCmd = &cli.Command{
+	Name:  "winning-post",
+	Usage: "Compute a winning PoSt to check the readiness for block production",
+	Description: `Note: This command computes the proof like the block producer would when the miner
+wins an election, it doesn't mine a block. The challenged sectors may differ from the ones
+of a real election at the epoch.`,
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "epoch",
+			Usage: "epoch to compute the proof for, defaults to the chain head",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := api.ReqContext(cctx)
+
+		start := time.Now()
+		res, err := storageAPI.ComputeWinningPoSt(ctx, abi.ChainEpoch(cctx.Int64("epoch")))
+		if err != nil {
+			return xerrors.Errorf("winning PoSt failed after %s: %w", time.Since(start).Truncate(time.Millisecond), err)
+		}
+
+		fmt.Printf("Epoch:            %d\n", res.Epoch)
+		fmt.Printf("Eligible sectors: %d\n", res.EligibleSectors)
+		fmt.Printf("Challenged:       %v\n", res.Challenged)
+		fmt.Printf("Candidates took:  %s\n", res.CandidatesTook.Truncate(time.Millisecond))
+		fmt.Printf("Proof took:       %s\n", res.ProofTook.Truncate(time.Millisecond))
+		fmt.Printf("Proof size:       %d bytes\n", res.ProofSize)
+		fmt.Printf("Total:            %s\n", time.Since(start).Truncate(time.Millisecond))
+
+		// a block has to be produced within the epoch
+		if res.CandidatesTook+res.ProofTook > time.Duration(constants.BlockDelaySecs)*time.Second/2 {
+			color.Yellow("winning PoSt took more than half of the block time")
+		}
+		return nil
 	},
 }
 
@@ -104,6 +104,8 @@ var sealingWorkersCmd = &cli.Command{
 
 			fmt.Printf("Worker %s, host %s%s\n", stat.id, color.MagentaString(stat.Info.Hostname), disabled)
 
+			fmt.Printf("\tTasks: %s\n", stat.Capacity)
+
 			fmt.Printf("\tCPU:  [%s] %d/%d core(s) in use\n",
 				barString(float64(stat.Info.Resources.CPUs), 0, float64(stat.CpuUse)), stat.CpuUse, stat.Info.Resources.CPUs)
 
@@ -144,6 +146,13 @@ var sealingWorkersCmd = &cli.Command{
 			for _, gpu := range stat.Info.Resources.GPUs {
 				fmt.Printf("\tGPU: %s\n", color.New(gpuCol).Sprintf("%s, %sused", gpu, gpuUse))
 			}
+
+			if len(stat.Info.Resources.Overrides) > 0 {
+				fmt.Printf("\tResource overrides:\n")
+				for _, o := range stat.Info.Resources.Overrides {
+					fmt.Printf("\t  %s\n", o)
+				}
+			}
 		}
 
 		return nil
@@ -13,6 +13,7 @@ import (
 
 	"github.com/docker/go-units"
 	"github.com/fatih/color"
+	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
@@ -65,21 +66,22 @@ var sectorsCmd = &cli.Command{
 		sectorsRefreshPieceMatchingCmd,
 		sectorsCompactPartitionsCmd,
 		sectorsRedoCmd,
+		sectorsRecoverCmd,
 	},
 }
 
 var sectorsRedoCmd = &cli.Command{
 	Name:      "redo",
-	Usage:     "redo the specified sector and support sealer locally",
+	Usage:     "rebuild the sealed replica and cache of a sector on the workers",
 	ArgsUsage: "<sectorNum>",
 	Flags: []cli.Flag{
-		&cli.StringFlag{
-			Name:  "sealPath",
-			Usage: "",
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "wait for the redo to finish, printing its progress",
 		},
-		&cli.StringFlag{
-			Name:  "storePath",
-			Usage: "",
+		&cli.BoolFlag{
+			Name:  "status",
+			Usage: "only print the status of the last redo of the sector",
 		},
 	},
 	Action: func(cctx *cli.Context) error {
@@ -91,29 +93,228 @@ var sectorsRedoCmd = &cli.Command{
 		ctx := api.ReqContext(cctx)
 
 		if !cctx.Args().Present() {
-			return fmt.Errorf("must specify sector number to get status of")
+			return fmt.Errorf("must specify sector number to redo")
 		}
 
 		id, err := strconv.ParseUint(cctx.Args().First(), 10, 64)
 		if err != nil {
 			return err
 		}
+		sid := abi.SectorNumber(id)
 
-		err = nodeApi.RedoSector(ctx, storiface.SectorRedoParams{
-			SectorNumber: abi.SectorNumber(id),
-			SealPath:     cctx.String("sealPath"),
-			StorePath:    cctx.String("storePath"),
-		})
+		if cctx.Bool("status") {
+			st, err := nodeApi.SectorRedoStatus(ctx, sid)
+			if err != nil {
+				return err
+			}
+			printRedoStatus(st)
+			return nil
+		}
+
+		if err := nodeApi.RedoSector(ctx, storiface.SectorRedoParams{SectorNumber: sid}); err != nil {
+			return err
+		}
+		fmt.Println("Scheduled redo of sector:", id)
+
+		if !cctx.Bool("wait") {
+			return nil
+		}
+
+		var last storiface.RedoState
+		for {
+			st, err := nodeApi.SectorRedoStatus(ctx, sid)
+			if err != nil {
+				return err
+			}
+			if st.State != last {
+				printRedoStatus(st)
+				last = st.State
+			}
+			switch st.State {
+			case storiface.RedoDone:
+				return nil
+			case storiface.RedoFailed, storiface.RedoAborted:
+				return xerrors.Errorf("redo of sector %d %s", id, strings.ToLower(string(st.State)))
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
+		}
+	},
+}
+
+var sectorsRecoverCmd = &cli.Command{
+	Name:  "recover",
+	Usage: "rebuild the sealed replica and cache of committed CC sectors from the chain",
+	Description: `Seals the sectors again on the workers, checks the CommR against the chain and
+   finalizes them into long-term storage. The seal ticket is taken from the local
+   sector info, sectors without one need their precommit message in --precommit-msgs.
+
+   eg) sectors recover 100 105-120
+       sectors recover --precommit-msgs msgs.txt --parallel 8 --wait 1-300`,
+	ArgsUsage: "<sectorNum|from-to>...",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "precommit-msgs",
+			Usage: "file with a '<sectorNum> <precommit message cid>' line per sector without local info",
+		},
+		&cli.IntFlag{
+			Name:  "parallel",
+			Usage: "number of sectors sealed at the same time, 0 leaves it to the scheduler",
+		},
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "wait for all the sectors to be recovered",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := api.ReqContext(cctx)
+
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify the sectors to recover")
+		}
+
+		params := storiface.SectorRecoverParams{Parallel: cctx.Int("parallel")}
+		for _, arg := range cctx.Args().Slice() {
+			sectors, err := parseSectorRange(arg)
+			if err != nil {
+				return err
+			}
+			params.Sectors = append(params.Sectors, sectors...)
+		}
+		if path := cctx.String("precommit-msgs"); path != "" {
+			if params.PreCommitMessages, err = readPreCommitMsgs(path); err != nil {
+				return err
+			}
+		}
+
+		res, err := nodeApi.SectorsRecover(ctx, params)
 		if err != nil {
 			return err
 		}
 
-		fmt.Println("Redo CC sector: ", id)
+		var scheduled []abi.SectorNumber
+		failed := 0
+		for _, st := range res {
+			if st.State == storiface.RedoFailed {
+				failed++
+				printRedoStatus(st)
+				continue
+			}
+			scheduled = append(scheduled, st.SectorNumber)
+		}
+		fmt.Printf("Scheduled recovery of %d sectors, %d failed\n", len(scheduled), failed)
+
+		if !cctx.Bool("wait") {
+			return nil
+		}
+
+		last := map[abi.SectorNumber]storiface.RedoState{}
+		for len(scheduled) > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(10 * time.Second):
+			}
 
+			running := scheduled[:0]
+			for _, sn := range scheduled {
+				st, err := nodeApi.SectorRedoStatus(ctx, sn)
+				if err != nil {
+					return err
+				}
+				if st.State != last[sn] {
+					printRedoStatus(st)
+					last[sn] = st.State
+				}
+				switch st.State {
+				case storiface.RedoFailed, storiface.RedoAborted:
+					failed++
+				case storiface.RedoDone:
+				default:
+					running = append(running, sn)
+				}
+			}
+			scheduled = running
+		}
+
+		if failed > 0 {
+			return xerrors.Errorf("%d sectors couldn't be recovered", failed)
+		}
 		return nil
 	},
 }
 
+// parseSectorRange parses a sector number, or an inclusive range like 10-20
+func parseSectorRange(s string) ([]abi.SectorNumber, error) {
+	parts := strings.SplitN(s, "-", 2)
+	from, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, xerrors.Errorf("parsing sector number %q: %w", s, err)
+	}
+	to := from
+	if len(parts) == 2 {
+		if to, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+			return nil, xerrors.Errorf("parsing sector range %q: %w", s, err)
+		}
+		if to < from {
+			return nil, xerrors.Errorf("invalid sector range %q", s)
+		}
+	}
+
+	out := make([]abi.SectorNumber, 0, to-from+1)
+	for sn := from; sn <= to; sn++ {
+		out = append(out, abi.SectorNumber(sn))
+	}
+	return out, nil
+}
+
+func readPreCommitMsgs(path string) (map[abi.SectorNumber]cid.Cid, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	out := map[abi.SectorNumber]cid.Cid{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, xerrors.Errorf("%s:%d: expected '<sectorNum> <message cid>'", path, line)
+		}
+		sn, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("%s:%d: parsing sector number: %w", path, line, err)
+		}
+		c, err := cid.Decode(fields[1])
+		if err != nil {
+			return nil, xerrors.Errorf("%s:%d: parsing message cid: %w", path, line, err)
+		}
+		out[abi.SectorNumber(sn)] = c
+	}
+	return out, scanner.Err()
+}
+
+func printRedoStatus(st storiface.SectorRedoStatus) {
+	fmt.Printf("%s  sector %d: %s", time.Unix(st.UpdateTime, 0).Format("2006-01-02 15:04:05"), st.SectorNumber, st.State)
+	if st.Error != "" {
+		fmt.Printf(" (%s)", st.Error)
+	}
+	fmt.Println()
+}
+
 var sectorsPledgeCmd = &cli.Command{
 	Name:  "pledge",
 	Usage: "store random data in a sector",
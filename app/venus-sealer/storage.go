@@ -48,6 +48,7 @@ stored while moving through the sealing pipeline (references as 'seal').`,
 		storageFindCmd,
 		storageCleanupCmd,
 		storageLocksCmd,
+		storageFetchesCmd,
 	},
 }
 
@@ -862,3 +863,48 @@ var storageLocksCmd = &cli.Command{
 		return nil
 	},
 }
+
+var storageFetchesCmd = &cli.Command{
+	Name:  "fetches",
+	Usage: "show the sector fetches of the sealer, running then queued by priority",
+	Action: func(cctx *cli.Context) error {
+		storageAPI, closer, err := api.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := api.ReqContext(cctx)
+
+		fetches, err := storageAPI.StorageFetches(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("State"),
+			tablewriter.Col("Priority"),
+			tablewriter.Col("Source"),
+			tablewriter.Col("Received"),
+			tablewriter.Col("Time"),
+			tablewriter.Col("URL"),
+		)
+
+		now := time.Now()
+		for _, fetch := range fetches {
+			state, since := color.YellowString("queued"), fetch.Queued
+			if !fetch.Started.IsZero() {
+				state, since = color.GreenString("running"), fetch.Started
+			}
+
+			tw.Write(map[string]interface{}{
+				"State":    state,
+				"Priority": fetch.Priority,
+				"Source":   fetch.Source,
+				"Received": units.BytesSize(float64(fetch.Received)),
+				"Time":     now.Sub(since).Truncate(time.Second),
+				"URL":      fetch.URL,
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
@@ -3,18 +3,24 @@ package main
 import (
 	"context"
 	"fmt"
+	"path"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/hako/durafmt"
+	"github.com/mitchellh/go-homedir"
 	"github.com/urfave/cli/v2"
+	"github.com/zbiljic/go-filelock"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
 
 	"github.com/filecoin-project/venus-sealer/api"
+	"github.com/filecoin-project/venus-sealer/config"
 	"github.com/filecoin-project/venus-sealer/constants"
+	"github.com/filecoin-project/venus-sealer/models"
+	"github.com/filecoin-project/venus-sealer/models/repo"
 	types2 "github.com/filecoin-project/venus-sealer/types"
 
 	"github.com/filecoin-project/venus/venus-shared/types"
@@ -180,3 +186,55 @@ func isController(mi types.MinerInfo, addr address.Address) bool {
 
 	return false
 }
+
+// openRepoOffline locks the sealer repo and opens its database directly, it fails
+// if the sealer is running because `run` holds the same lock
+func openRepoOffline(cctx *cli.Context) (repo.Repo, func(), error) {
+	cfg, unlock, err := lockRepoOffline(cctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := models.SetDataBase(config.HomeDir(cfg.DataDir), &cfg.DB)
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+
+	return r, func() {
+		if err := r.DbClose(); err != nil {
+			log.Errorf("close database: %s", err)
+		}
+		unlock()
+	}, nil
+}
+
+// lockRepoOffline locks the sealer repo and loads its config
+func lockRepoOffline(cctx *cli.Context) (*config.StorageMiner, func(), error) {
+	repoPath := cctx.String("repo")
+	cfg, err := config.MinerFromFile(config.FsConfig(repoPath))
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.DataDir = repoPath
+
+	dataDir, err := homedir.Expand(cfg.DataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	fl, err := filelock.New(path.Join(dataDir, "repo.lock"))
+	if err != nil {
+		return nil, nil, err
+	}
+	locked, err := fl.TryLock()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !locked {
+		return nil, nil, xerrors.Errorf("repo %s is locked, is venus-sealer running?", dataDir)
+	}
+
+	return cfg, func() {
+		_ = fl.Unlock()
+	}, nil
+}
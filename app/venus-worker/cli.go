@@ -1,7 +1,10 @@
 package main
 
 import (
+	"os"
+
 	"github.com/filecoin-project/venus-sealer/api"
+	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 )
@@ -48,3 +51,12 @@ var waitQuietCmd = &cli.Command{
 		return workerApi.WaitQuiet(ctx)
 	},
 }
+
+var ffiTaskCmd = &cli.Command{
+	Name:   sectorstorage.TaskChildCommand,
+	Usage:  "Run the ffi work of a task read from stdin, started by workers with --task-cgroups",
+	Hidden: true,
+	Action: func(cctx *cli.Context) error {
+		return sectorstorage.RunTaskChild(cctx.Context, os.Stdin, os.Stdout)
+	},
+}
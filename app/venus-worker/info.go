@@ -57,9 +57,9 @@ var infoCmd = &cli.Command{
 			return xerrors.Errorf("getting task types: %w", err)
 		}
 
-		tasks, err := workerApi.TaskNumbers(ctx)
+		capacity, err := workerApi.Capacity(ctx)
 		if err != nil {
-			return xerrors.Errorf("getting tasks: %w", err)
+			return xerrors.Errorf("getting capacity: %w", err)
 		}
 
 		fmt.Printf("Hostname: %s\n", info.Hostname)
@@ -69,7 +69,7 @@ var infoCmd = &cli.Command{
 			types.SizeStr(types.NewInt(info.Resources.MemPhysical)),
 			types.SizeStr(types.NewInt(info.Resources.MemSwapUsed)),
 			types.SizeStr(types.NewInt(info.Resources.MemSwap)))
-		fmt.Printf("Tasks: %s\n", tasks)
+		fmt.Printf("Tasks: %s\n", capacity)
 
 		fmt.Printf("Task types: ")
 		for _, t := range ttList(tt) {
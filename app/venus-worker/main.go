@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/docker/go-units"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	logging "github.com/ipfs/go-log/v2"
@@ -34,6 +35,7 @@ import (
 	"github.com/filecoin-project/venus-sealer/constants"
 	"github.com/filecoin-project/venus-sealer/lib/rpcenc"
 	"github.com/filecoin-project/venus-sealer/lib/vfile"
+	"github.com/filecoin-project/venus-sealer/metrics"
 	"github.com/filecoin-project/venus-sealer/models"
 	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
@@ -55,6 +57,7 @@ func main() {
 		waitQuietCmd,
 		resourcesCmd,
 		tasksCmd,
+		ffiTaskCmd,
 	}
 
 	app := &cli.App{
@@ -118,6 +121,11 @@ var runCmd = &cli.Command{
 			Usage: "don't use swap",
 			Value: false,
 		},
+		&cli.BoolFlag{
+			Name:  "task-cgroups",
+			Usage: "run PC1, PC2, C2, replica update and prove replica update 2 in their own cgroup v2 group, limited to the memory of the task resources and pinned to as many CPUs of the worker CPU set as the task threads; the cgroup of the worker must be delegated to it with the memory, cpu and cpuset controllers",
+			Value: false,
+		},
 		&cli.BoolFlag{
 			Name:  "addpiece",
 			Usage: "enable addpiece",
@@ -158,11 +166,28 @@ var runCmd = &cli.Command{
 			Usage: "enable regen sector key",
 			Value: true,
 		},
+		&cli.BoolFlag{
+			Name:  "windowpost",
+			Usage: "run a dedicated window PoSt worker, the sealing tasks are disabled and the worker needs access to the long-term storage of the sectors",
+		},
 		&cli.IntFlag{
 			Name:  "parallel-fetch-limit",
 			Usage: "maximum fetch operations to run in parallel",
 			Value: 5,
 		},
+		&cli.IntFlag{
+			Name:  "parallel-fetch-per-source",
+			Usage: "maximum fetch operations to run in parallel from a single storage, 0 for unlimited",
+		},
+		&cli.IntFlag{
+			Name:  "parallel-fetch-dir-files",
+			Usage: "number of files of a cache directory fetched in parallel",
+			Value: 1,
+		},
+		&cli.StringFlag{
+			Name:  "fetch-bandwidth",
+			Usage: "maximum bandwidth used by the fetch operations per second, eg. 100MiB, unlimited when not set",
+		},
 		&cli.StringFlag{
 			Name:  "miner-addr",
 			Usage: "miner address to connect",
@@ -180,9 +205,37 @@ var runCmd = &cli.Command{
 		},
 		&cli.IntFlag{
 			Name:  "task-total",
-			Usage: "total number of task",
+			Usage: "maximum number of tasks running at the same time, 0 accepts no task, -1 for unlimited",
 			Value: 100,
 		},
+		&cli.IntFlag{
+			Name:  "max-addpiece",
+			Usage: "maximum number of addpiece tasks running at the same time, 0 for unlimited",
+		},
+		&cli.IntFlag{
+			Name:  "max-pc1",
+			Usage: "maximum number of precommit1 tasks running at the same time, 0 for unlimited",
+		},
+		&cli.IntFlag{
+			Name:  "max-pc2",
+			Usage: "maximum number of precommit2 tasks running at the same time, 0 for unlimited",
+		},
+		&cli.IntFlag{
+			Name:  "max-c2",
+			Usage: "maximum number of commit2 tasks running at the same time, 0 for unlimited",
+		},
+		&cli.IntFlag{
+			Name:  "max-unseal",
+			Usage: "maximum number of unseal tasks running at the same time, 0 for unlimited",
+		},
+		&cli.IntFlag{
+			Name:  "max-fetch",
+			Usage: "maximum number of fetch tasks running at the same time, 0 for unlimited",
+		},
+		&cli.IntFlag{
+			Name:  "max-replica-update",
+			Usage: "maximum number of replica update tasks running at the same time, 0 for unlimited",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		log.Info("Starting venus worker")
@@ -228,6 +281,15 @@ var runCmd = &cli.Command{
 			return err
 		}
 
+		if err := cfg.Resources.Validate(); err != nil {
+			return xerrors.Errorf("invalid resource overrides in %s: %w", cfg.ConfigPath, err)
+		}
+		if envs, err := resourceEnvVars(); err != nil {
+			return err
+		} else if len(envs) > 0 {
+			log.Warnf("resource env vars %s are deprecated, move them to the Resources section of %s (see 'venus-worker resources')", strings.Join(envs, ", "), cfg.ConfigPath)
+		}
+
 		log.Infof("config: %v", *cfg)
 
 		if !ok {
@@ -284,7 +346,7 @@ var runCmd = &cli.Command{
 		defer cancel()
 
 		// Register all metric views
-		if err := view.Register(); err != nil {
+		if err := view.Register(metrics.WorkerViews...); err != nil {
 			log.Fatalf("Cannot register the view: %v", err)
 		}
 
@@ -308,7 +370,8 @@ var runCmd = &cli.Command{
 			return err
 		}
 
-		if cctx.Bool("commit") || cctx.Bool("prove-replica-update2") {
+		windowPoSt := cctx.Bool("windowpost")
+		if !windowPoSt && (cctx.Bool("commit") || cctx.Bool("prove-replica-update2")) {
 			ps, err := assets.GetProofParams()
 			if err != nil {
 				return err
@@ -350,6 +413,9 @@ var runCmd = &cli.Command{
 		if cctx.Bool("regen-sector-key") {
 			taskTypes = append(taskTypes, types.TTRegenSectorKey)
 		}
+		if windowPoSt {
+			taskTypes = []types.TaskType{types.TTGenerateWindowPoSt}
+		}
 		if len(taskTypes) == 0 {
 			return xerrors.Errorf("no task types specified")
 		}
@@ -427,7 +493,19 @@ var runCmd = &cli.Command{
 			return err
 		}
 
-		remote := stores.NewRemote(localStore, nodeApi, cfg.Sealer.AuthHeader(), cctx.Int("parallel-fetch-limit"),
+		fetchLimits := stores.FetchLimits{
+			Parallel:  cctx.Int("parallel-fetch-limit"),
+			PerSource: cctx.Int("parallel-fetch-per-source"),
+			DirFiles:  cctx.Int("parallel-fetch-dir-files"),
+		}
+		if cctx.IsSet("fetch-bandwidth") {
+			bw, err := units.RAMInBytes(cctx.String("fetch-bandwidth"))
+			if err != nil {
+				return xerrors.Errorf("parsing fetch-bandwidth: %w", err)
+			}
+			fetchLimits.Bandwidth = bw
+		}
+		remote := stores.NewRemote(localStore, nodeApi, cfg.Sealer.AuthHeader(), fetchLimits,
 			&stores.DefaultPartialFileHandler{})
 
 		fh := &stores.FetchHandler{Local: localStore, PfHandler: &stores.DefaultPartialFileHandler{}}
@@ -445,13 +523,33 @@ var runCmd = &cli.Command{
 		wsts := service.NewWorkCallService(dbRepo, "worker")
 		//wsts := statestore.New(namespace.Wrap(ds, sealer.WorkerCallsPrefix))
 
+		var taskCgroups *sectorstorage.TaskCgroups
+		if cctx.Bool("task-cgroups") {
+			taskCgroups, err = sectorstorage.NewTaskCgroups()
+			if err != nil {
+				return xerrors.Errorf("setting up task cgroups: %w", err)
+			}
+		}
+
 		workerApi := &worker{
 			LocalWorker: sectorstorage.NewLocalWorker(sectorstorage.WorkerConfig{
 				TaskTypes: taskTypes,
 				NoSwap:    cctx.Bool("no-swap"),
 				TaskTotal: cctx.Int64("task-total"),
+				TaskLimits: map[types.TaskType]int64{
+					types.TTAddPiece:      cctx.Int64("max-addpiece"),
+					types.TTPreCommit1:    cctx.Int64("max-pc1"),
+					types.TTPreCommit2:    cctx.Int64("max-pc2"),
+					types.TTCommit2:       cctx.Int64("max-c2"),
+					types.TTUnseal:        cctx.Int64("max-unseal"),
+					types.TTFetch:         cctx.Int64("max-fetch"),
+					types.TTReplicaUpdate: cctx.Int64("max-replica-update"),
+				},
+				Resources:   cfg.Resources,
+				TaskCgroups: taskCgroups,
 			}, remote, localStore, nodeApi, nodeApi, wsts),
 			localStore: localStore,
+			remote:     remote,
 			ls:         localStorage,
 		}
 
@@ -466,6 +564,10 @@ var runCmd = &cli.Command{
 		mux.Handle("/rpc/v0", rpcServer)
 		mux.Handle("/rpc/streams/v0/push/{uuid}", readerHandler)
 		mux.PathPrefix("/remote").HandlerFunc(remoteHandler)
+		exporter, err := metrics.Exporter()
+		if err != nil {
+			return err
+		}
 		mux.PathPrefix("/").Handler(http.DefaultServeMux) // pprof
 
 		ah := &auth.Handler{
@@ -474,7 +576,7 @@ var runCmd = &cli.Command{
 		}
 
 		srv := &http.Server{
-			Handler: ah,
+			Handler: metrics.WithoutAuth(exporter, ah),
 			BaseContext: func(listener net.Listener) context.Context {
 				apiKey, _ := tag.NewKey("api")
 				ctx, _ := tag.New(context.Background(), tag.Upsert(apiKey, "lotus-worker"))
@@ -4,69 +4,184 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/lib/tablewriter"
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
 )
 
 var resourcesCmd = &cli.Command{
 	Name:  "resources",
 	Usage: "Manage resource table overrides",
+	Description: `The resource table is overridden in the Resources section of the worker config,
+by task then sector size:
+
+   [Resources.PC1.32G]
+   MaxMemory = 137438953472
+
+The resource env vars, eg. PC1_32G_MAX_MEMORY, are still read but the config
+takes precedence. Without flags the overrides in effect are printed as a config
+snippet.`,
 	Flags: []cli.Flag{
 		&cli.BoolFlag{
 			Name:  "all",
-			Usage: "print all resource envvars",
+			Usage: "print the whole effective resource table as a config snippet",
 		},
 		&cli.BoolFlag{
 			Name:  "default",
-			Usage: "print default resource envvars",
+			Usage: "print the default resource table as a config snippet",
 		},
 	},
+	Subcommands: []*cli.Command{
+		resourcesValidateCmd,
+	},
 	Action: func(cctx *cli.Context) error {
-		def := map[string]string{}
-		set := map[string]string{}
-		all := map[string]string{}
-
-		_, err := storiface.ParseResourceEnv(func(key, d string) (string, bool) {
-			if d != "" {
-				all[key] = d
-				def[key] = d
+		table := storiface.ResourceTable
+		var base map[types.TaskType]map[abi.RegisteredSealProof]storiface.Resources
+		if !cctx.Bool("default") {
+			cfg, err := loadWorkerConfig(cctx)
+			if err != nil {
+				return err
 			}
-
-			s, ok := os.LookupEnv(key)
-			if ok {
-				all[key] = s
-				set[key] = s
+			table, _, err = effectiveResources(cfg)
+			if err != nil {
+				return err
 			}
+			if !cctx.Bool("all") {
+				base = storiface.ResourceTable
+			}
+		}
 
-			return s, ok
-		})
+		overrides, err := storiface.ResourceTableOverrides(table, base)
 		if err != nil {
 			return err
 		}
+		return toml.NewEncoder(os.Stdout).Encode(struct {
+			Resources storiface.ResourceOverrides
+		}{overrides})
+	},
+}
 
-		printMap := func(m map[string]string) {
-			var arr []string
-			for k, v := range m {
-				arr = append(arr, fmt.Sprintf("%s=%s", k, v))
-			}
-			sort.Strings(arr)
-			for _, s := range arr {
-				fmt.Println(s)
-			}
+var resourcesValidateCmd = &cli.Command{
+	Name:  "validate",
+	Usage: "check the resource overrides of the worker config, and show how the effective table differs from the defaults",
+	Action: func(cctx *cli.Context) error {
+		cfg, err := loadWorkerConfig(cctx)
+		if err != nil {
+			return err
+		}
+		if err := cfg.Resources.Validate(); err != nil {
+			return xerrors.Errorf("invalid resource overrides in %s: %w", cfg.ConfigPath, err)
 		}
 
-		if cctx.Bool("default") {
-			printMap(def)
-		} else {
-			if cctx.Bool("all") {
-				printMap(all)
-			} else {
-				printMap(set)
-			}
+		table, sources, err := effectiveResources(cfg)
+		if err != nil {
+			return err
 		}
+		diff, err := storiface.DiffResourceTable(table)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: resource overrides are valid\n", cfg.ConfigPath)
+		if len(diff) == 0 {
+			fmt.Println("the effective resource table is the default one")
+			return nil
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("Task"),
+			tablewriter.Col("Size"),
+			tablewriter.Col("Field"),
+			tablewriter.Col("Default"),
+			tablewriter.Col("Effective"),
+			tablewriter.Col("Source"),
+		)
+		for _, d := range diff {
+			source, ok := sources[d.EnvName()]
+			if !ok {
+				source = "derived" // eg. the multicore SDR env vars
+			}
 
-		return nil
+			tw.Write(map[string]interface{}{
+				"Task":      d.TaskType.Short(),
+				"Size":      strings.TrimSuffix(d.SectorSize.ShortString(), "iB"),
+				"Field":     d.Field,
+				"Default":   d.Default,
+				"Effective": d.Value,
+				"Source":    source,
+			})
+		}
+		return tw.Flush(os.Stdout)
 	},
 }
+
+// loadWorkerConfig reads the config of the worker repo, the default one when
+// the repo has none yet.
+func loadWorkerConfig(cctx *cli.Context) (*config.StorageWorker, error) {
+	cfgPath := config.FsConfig(cctx.String("repo"))
+	ok, err := config.ConfigExist(cfgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.GetDefaultWorkerConfig()
+	if ok {
+		cfg, err = config.WorkerFromFile(cfgPath)
+		if err != nil {
+			return nil, xerrors.Errorf("loading worker config: %w", err)
+		}
+	}
+	cfg.ConfigPath = cfgPath
+	return cfg, nil
+}
+
+// effectiveResources returns the resource table of a worker, and where the
+// overridden values come from by resource env var name: "config" or "env".
+func effectiveResources(cfg *config.StorageWorker) (map[types.TaskType]map[abi.RegisteredSealProof]storiface.Resources, map[string]string, error) {
+	cfgEnv, err := cfg.Resources.Env()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("invalid resource overrides: %w", err)
+	}
+
+	sources := map[string]string{}
+	table, err := storiface.ParseResourceEnv(func(key, def string) (string, bool) {
+		if v, ok := cfgEnv[key]; ok {
+			sources[key] = "config"
+			return v, true
+		}
+		v, ok := os.LookupEnv(key)
+		if ok && !strings.HasPrefix(key, "FIL_PROOFS_") {
+			sources[key] = "env"
+		}
+		return v, ok
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return table, sources, nil
+}
+
+// resourceEnvVars returns the resource env vars set, which are deprecated in
+// favor of the Resources section of the config.
+func resourceEnvVars() ([]string, error) {
+	_, sources, err := effectiveResources(config.GetDefaultWorkerConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for key := range sources {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out, nil
+}
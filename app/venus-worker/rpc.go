@@ -18,6 +18,7 @@ type worker struct {
 	*sectorstorage.LocalWorker
 
 	localStore *stores.Local
+	remote     *stores.Remote
 	ls         stores.LocalStorage
 
 	disabled int64
@@ -46,6 +47,10 @@ func (w *worker) StorageAddLocal(ctx context.Context, path string) error {
 	return nil
 }
 
+func (w *worker) StorageFetches(context.Context) ([]stores.FetchInfo, error) {
+	return w.remote.Fetches(), nil
+}
+
 func (w *worker) SetEnabled(ctx context.Context, enabled bool) error {
 	disabled := int64(1)
 	if enabled {
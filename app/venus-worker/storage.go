@@ -6,7 +6,10 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/docker/go-units"
+	"github.com/filecoin-project/venus-sealer/lib/tablewriter"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
 	"github.com/google/uuid"
 	"github.com/mitchellh/go-homedir"
@@ -21,6 +24,7 @@ var storageCmd = &cli.Command{
 	Usage: "manage sector storage",
 	Subcommands: []*cli.Command{
 		storageAttachCmd,
+		storageFetchesCmd,
 	},
 }
 
@@ -112,3 +116,48 @@ var storageAttachCmd = &cli.Command{
 		return workerApi.StorageAddLocal(ctx, p)
 	},
 }
+
+var storageFetchesCmd = &cli.Command{
+	Name:  "fetches",
+	Usage: "show the sector fetches of the worker, running then queued by priority",
+	Action: func(cctx *cli.Context) error {
+		workerApi, closer, err := api.GetWorkerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := api.ReqContext(cctx)
+
+		fetches, err := workerApi.StorageFetches(ctx)
+		if err != nil {
+			return err
+		}
+
+		tw := tablewriter.New(
+			tablewriter.Col("State"),
+			tablewriter.Col("Priority"),
+			tablewriter.Col("Source"),
+			tablewriter.Col("Received"),
+			tablewriter.Col("Time"),
+			tablewriter.Col("URL"),
+		)
+
+		now := time.Now()
+		for _, fetch := range fetches {
+			state, since := "queued", fetch.Queued
+			if !fetch.Started.IsZero() {
+				state, since = "running", fetch.Started
+			}
+
+			tw.Write(map[string]interface{}{
+				"State":    state,
+				"Priority": fetch.Priority,
+				"Source":   fetch.Source,
+				"Received": units.BytesSize(float64(fetch.Received)),
+				"Time":     now.Sub(since).Truncate(time.Second),
+				"URL":      fetch.URL,
+			})
+		}
+		return tw.Flush(os.Stdout)
+	},
+}
@@ -30,6 +30,7 @@ var allowSetting = map[types.TaskType]struct{}{
 	types.TTReplicaUpdate:       {},
 	types.TTProveReplicaUpdate2: {},
 	types.TTRegenSectorKey:      {},
+	types.TTGenerateWindowPoSt:  {},
 }
 
 var settableStr = func() string {
@@ -22,6 +22,7 @@ import (
 	"github.com/filecoin-project/venus-sealer/market_client"
 	"github.com/filecoin-project/venus-sealer/models"
 	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/notify"
 	"github.com/filecoin-project/venus-sealer/proof_client"
 	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
@@ -125,6 +126,7 @@ func Online(cfg *config.StorageMiner) Option {
 		}),
 		Override(new(journal.DisabledEvents), journal.EnvDisabledEvents),
 		Override(new(journal.Journal), OpenFilesystemJournal),
+		Override(new(notify.Notifier), NotifyDispatcher),
 
 		Override(new(types.SetSealingConfigFunc), NewSetSealConfigFunc),
 		Override(new(types.GetSealingConfigFunc), NewGetSealConfigFunc),
@@ -132,7 +134,7 @@ func Online(cfg *config.StorageMiner) Option {
 		Override(new(api.Common), From(new(impl.CommonAPI))),
 		Override(new(sectorstorage.StorageAuth), StorageAuth),
 
-		Override(new(*stores.Index), stores.NewIndex),
+		Override(new(*stores.Index), StorageIndex),
 		Override(new(stores.SectorIndex), From(new(*stores.Index))),
 		Override(new(types.MinerID), MinerID),
 		Override(new(types.MinerAddress), MinerAddress),
@@ -152,7 +154,7 @@ func Online(cfg *config.StorageMiner) Option {
 		Override(new(types.GetSealingConfigFunc), NewGetSealConfigFunc),
 		Override(new(*sectorblocks.SectorBlocks), sectorblocks.NewSectorBlocks),
 		Override(new(*storage.Miner), StorageMiner(config.DefaultMainnetStorageMiner().Fees)),
-		Override(new(*storage.WindowPoStScheduler), WindowPostScheduler(cfg.Fees)),
+		Override(new(*storage.WindowPoStScheduler), WindowPostScheduler(cfg.Fees, cfg.Proving)),
 		// Override(new(*storage.AddressSelector), AddressSelector(nil)), // venus-sealer run: Call Repo before, Online after,will overwrite the original injection(MinerAddressConfig)
 		Override(new(types.NetworkName), StorageNetworkName),
 
@@ -192,6 +194,7 @@ func Repo(cfg *config.StorageMiner) Option {
 			Providers(
 				service.NewDealRefServiceService,
 				service.NewLogService,
+				service.NewDealAssignmentService,
 				service.NewMetadataService,
 				service.NewSectorInfoService,
 			//	service.NewWorkCallService,
@@ -13,6 +13,7 @@ import (
 	"github.com/filecoin-project/go-state-types/big"
 
 	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 
 	"github.com/filecoin-project/venus-market/v2/config"
 
@@ -34,6 +35,10 @@ type StorageWorker struct {
 	DataDir    string
 	Sealer     NodeConfig
 	DB         DbConfig
+
+	// Resources overrides the resource table by task then sector size, see
+	// `venus-worker resources --default`
+	Resources storiface.ResourceOverrides `ignored:"true"`
 }
 
 func (cfg StorageWorker) LocalStorage() *LocalStorage {
@@ -59,6 +64,9 @@ type StorageMiner struct {
 	PieceStorage   config.PieceStorage
 	RegisterProof  RegisterProofConfig
 	RegisterMarket RegisterMarketConfig
+	Notify         NotifyConfig
+	Proving        ProvingConfig
+	DealPacking    DealPackingConfig
 
 	ConfigPath string `toml:"-"`
 }
@@ -89,6 +97,10 @@ type MarketNodeConfig struct {
 type RegisterProofConfig struct {
 	Urls  []string
 	Token string
+
+	// Native lets block producers call ComputeProof on the sealer API directly,
+	// the gateways in Urls become optional
+	Native bool
 }
 
 type RegisterMarketConfig struct {
@@ -96,6 +108,74 @@ type RegisterMarketConfig struct {
 	Token string
 }
 
+// NotifyConfig configures the webhooks receiving sealer events. Events are kept
+// in an outbox in the database until every webhook accepted them.
+type NotifyConfig struct {
+	// Delay before retrying a failed delivery, doubled after every attempt
+	RetryInterval Duration
+	// Maximum delay between two attempts
+	MaxRetryInterval Duration
+	// Drop an event after this many failed deliveries, 0 retries forever
+	MaxAttempts int
+
+	Webhooks []WebhookConfig
+}
+
+// ProvingConfig configures the health sweeps checking the sector files of a
+// deadline before its window PoSt.
+type ProvingConfig struct {
+	// Sweep the sectors of a deadline this many epochs before it opens, 0
+	// (the default) disables the sweeps. 180 sweeps three deadlines ahead
+	HealthSweepLeadEpochs uint64
+	// Declare the sectors with missing or unreadable files faulty while the
+	// fault cutoff of their deadline isn't reached. The deadlines whose
+	// declarations were already made by the window PoSt cycle are skipped
+	DeclareFaults bool
+	// Declare the recovery of the faulty sectors found healthy again, with
+	// the same rules as DeclareFaults
+	DeclareRecoveries bool
+}
+
+// DealPackingConfig configures how the unpacked deals of venus-market are
+// assigned to sectors.
+type DealPackingConfig struct {
+	// Number of unpacked deals fetched from venus-market on every run
+	MaxDeals int
+	// Only pack the deals of the same client in a sector
+	GroupByClient bool
+	// Don't mix verified and unverified deals in a sector
+	SeparateVerified bool
+	// Maximum difference between the end epochs of the deals of a sector, so
+	// that the sector expiration is tight, 0 = no limit
+	MaxEndEpochSpread uint64
+	// Minimum part of a sector filled with deals before it's sealed, between 0
+	// and 1. The deals of a sector below it wait for more deals
+	MinUtilisation float64
+	// Deals starting within this many epochs after the sealing buffer are
+	// packed even when their sector is below MinUtilisation
+	UrgentStartEpochs uint64
+
+	// Interval between two automatic packing runs, 0 = only pack deals on
+	// `sectors deal`
+	PollInterval Duration
+	// Upper bound of the delay between two runs while piece storage is
+	// unreachable, the delay doubles on every failed run
+	PollMaxBackoff Duration
+}
+
+type WebhookConfig struct {
+	// Name identifies the webhook in the outbox, it must be unique
+	Name string
+	URL  string
+	// Token is sent as `Authorization: Bearer <Token>` when set
+	Token string
+	// Topics are glob patterns of the events sent to the webhook, such as
+	// "sector/*Failed", "sector/Proving", "wdpost/*" or "worker/disconnected".
+	// Every event is sent when empty.
+	Topics  []string
+	Timeout Duration
+}
+
 func (node *NodeConfig) APIEndpoint() (multiaddr.Multiaddr, error) {
 	strma := string(node.Url)
 	strma = strings.TrimSpace(strma)
@@ -133,9 +213,10 @@ func (node *NodeConfig) AuthHeader() http.Header {
 }
 
 type DbConfig struct {
-	Type   string
-	MySql  MySqlConfig
-	Sqlite SqliteConfig
+	Type     string
+	MySql    MySqlConfig
+	Postgres PostgresConfig
+	Sqlite   SqliteConfig
 }
 
 type SqliteConfig struct {
@@ -152,6 +233,17 @@ type MySqlConfig struct {
 	ConnMaxLifeTime time.Duration `toml:"connMaxLifeTime"`
 }
 
+type PostgresConfig struct {
+	Addr            string        `toml:"addr"`
+	User            string        `toml:"user"`
+	Pass            string        `toml:"pass"`
+	Name            string        `toml:"name"`
+	SSLMode         string        `toml:"sslMode"`
+	MaxOpenConn     int           `toml:"maxOpenConn"`
+	MaxIdleConn     int           `toml:"maxIdleConn"`
+	ConnMaxLifeTime time.Duration `toml:"connMaxLifeTime"`
+}
+
 type DealmakingConfig struct {
 	// When enabled, the miner can accept online deals
 	ConsiderOnlineStorageDeals bool
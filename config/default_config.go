@@ -26,8 +26,9 @@ func GetDefaultWorkerConfig() *StorageWorker {
 			Token: "",
 		},
 		DB: DbConfig{
-			Type:  "sqlite",
-			MySql: MySqlConfig{},
+			Type:     "sqlite",
+			MySql:    MySqlConfig{},
+			Postgres: PostgresConfig{SSLMode: "disable"},
 			Sqlite: SqliteConfig{
 				Path: "worker.db",
 			},
@@ -72,6 +73,8 @@ func DefaultMainnetStorageMiner() *StorageMiner {
 			// Default to 10 - tcp should still be able to figure this out, and
 			// it's the ratio between 10gbit / 1gbit
 			ParallelFetchLimit: 10,
+
+			SchedulingPolicy: sectorstorage.SchedulingPolicyDefault,
 		},
 		Fees: MinerFeeConfig{
 			MaxPreCommitGasFee: types.MustParseFIL("0.025"),
@@ -113,6 +116,16 @@ func DefaultMainnetStorageMiner() *StorageMiner {
 				MaxIdleConn:     0,
 				ConnMaxLifeTime: 0,
 			},
+			Postgres: PostgresConfig{
+				Addr:            "",
+				User:            "",
+				Pass:            "",
+				Name:            "",
+				SSLMode:         "disable",
+				MaxOpenConn:     0,
+				MaxIdleConn:     0,
+				ConnMaxLifeTime: 0,
+			},
 			Sqlite: SqliteConfig{
 				Path: "sealer.db",
 			},
@@ -125,6 +138,9 @@ func DefaultMainnetStorageMiner() *StorageMiner {
 			Secret: "",
 		},
 		RegisterMarket: defMarket,
+		Notify:         defNotify,
+		Proving:        defProving,
+		DealPacking:    defDealPacking,
 	}
 	var secret [32]byte
 	_, _ = rand.Read(secret[:])
@@ -155,6 +171,8 @@ func DefaultForceNetStorageMiner() *StorageMiner {
 			// Default to 10 - tcp should still be able to figure this out, and
 			// it's the ratio between 10gbit / 1gbit
 			ParallelFetchLimit: 10,
+
+			SchedulingPolicy: sectorstorage.SchedulingPolicyDefault,
 		},
 		Fees: MinerFeeConfig{
 			MaxPreCommitGasFee: types.MustParseFIL("0.025"),
@@ -197,6 +215,16 @@ func DefaultForceNetStorageMiner() *StorageMiner {
 				MaxIdleConn:     0,
 				ConnMaxLifeTime: 0,
 			},
+			Postgres: PostgresConfig{
+				Addr:            "",
+				User:            "",
+				Pass:            "",
+				Name:            "",
+				SSLMode:         "disable",
+				MaxOpenConn:     0,
+				MaxIdleConn:     0,
+				ConnMaxLifeTime: 0,
+			},
 			Sqlite: SqliteConfig{
 				Path: "sealer.db",
 			},
@@ -209,6 +237,9 @@ func DefaultForceNetStorageMiner() *StorageMiner {
 			Secret: "",
 		},
 		RegisterMarket: defMarket,
+		Notify:         defNotify,
+		Proving:        defProving,
+		DealPacking:    defDealPacking,
 	}
 	var secret [32]byte
 	_, _ = rand.Read(secret[:])
@@ -239,6 +270,8 @@ func DefaultCalibrationStorageMiner() *StorageMiner {
 			// Default to 10 - tcp should still be able to figure this out, and
 			// it's the ratio between 10gbit / 1gbit
 			ParallelFetchLimit: 10,
+
+			SchedulingPolicy: sectorstorage.SchedulingPolicyDefault,
 		},
 		Fees: MinerFeeConfig{
 			MaxPreCommitGasFee: types.MustParseFIL("0.025"),
@@ -282,6 +315,16 @@ func DefaultCalibrationStorageMiner() *StorageMiner {
 				MaxIdleConn:     0,
 				ConnMaxLifeTime: 0,
 			},
+			Postgres: PostgresConfig{
+				Addr:            "",
+				User:            "",
+				Pass:            "",
+				Name:            "",
+				SSLMode:         "disable",
+				MaxOpenConn:     0,
+				MaxIdleConn:     0,
+				ConnMaxLifeTime: 0,
+			},
 			Sqlite: SqliteConfig{
 				Path: "sealer.db",
 			},
@@ -294,6 +337,9 @@ func DefaultCalibrationStorageMiner() *StorageMiner {
 			Secret: "",
 		},
 		RegisterMarket: defMarket,
+		Notify:         defNotify,
+		Proving:        defProving,
+		DealPacking:    defDealPacking,
 
 		Dealmaking: DealmakingConfig{
 			ConsiderOnlineStorageDeals:     true,
@@ -356,6 +402,8 @@ func Default2kStorageMiner() *StorageMiner {
 			// Default to 10 - tcp should still be able to figure this out, and
 			// it's the ratio between 10gbit / 1gbit
 			ParallelFetchLimit: 10,
+
+			SchedulingPolicy: sectorstorage.SchedulingPolicyDefault,
 		},
 
 		Fees: MinerFeeConfig{
@@ -400,6 +448,16 @@ func Default2kStorageMiner() *StorageMiner {
 				MaxIdleConn:     0,
 				ConnMaxLifeTime: 0,
 			},
+			Postgres: PostgresConfig{
+				Addr:            "",
+				User:            "",
+				Pass:            "",
+				Name:            "",
+				SSLMode:         "disable",
+				MaxOpenConn:     0,
+				MaxIdleConn:     0,
+				ConnMaxLifeTime: 0,
+			},
 			Sqlite: SqliteConfig{
 				Path: "sealer.db",
 			},
@@ -412,6 +470,9 @@ func Default2kStorageMiner() *StorageMiner {
 			Secret: "",
 		},
 		RegisterMarket: defMarket,
+		Notify:         defNotify,
+		Proving:        defProving,
+		DealPacking:    defDealPacking,
 	}
 	var secret [32]byte
 	_, _ = rand.Read(secret[:])
@@ -443,6 +504,8 @@ func DefaultButterflyStorageMiner() *StorageMiner {
 			// Default to 10 - tcp should still be able to figure this out, and
 			// it's the ratio between 10gbit / 1gbit
 			ParallelFetchLimit: 10,
+
+			SchedulingPolicy: sectorstorage.SchedulingPolicyDefault,
 		},
 
 		Fees: MinerFeeConfig{
@@ -486,6 +549,16 @@ func DefaultButterflyStorageMiner() *StorageMiner {
 				MaxIdleConn:     0,
 				ConnMaxLifeTime: 0,
 			},
+			Postgres: PostgresConfig{
+				Addr:            "",
+				User:            "",
+				Pass:            "",
+				Name:            "",
+				SSLMode:         "disable",
+				MaxOpenConn:     0,
+				MaxIdleConn:     0,
+				ConnMaxLifeTime: 0,
+			},
 			Sqlite: SqliteConfig{
 				Path: "sealer.db",
 			},
@@ -498,6 +571,9 @@ func DefaultButterflyStorageMiner() *StorageMiner {
 			Secret: "",
 		},
 		RegisterMarket: defMarket,
+		Notify:         defNotify,
+		Proving:        defProving,
+		DealPacking:    defDealPacking,
 	}
 	var secret [32]byte
 	_, _ = rand.Read(secret[:])
@@ -512,6 +588,30 @@ var defMarket = RegisterMarketConfig{
 	Token: "",
 }
 
+var defNotify = NotifyConfig{
+	RetryInterval:    Duration(30 * time.Second),
+	MaxRetryInterval: Duration(time.Hour),
+	MaxAttempts:      0,
+	Webhooks:         []WebhookConfig{},
+}
+
+var defProving = ProvingConfig{
+	HealthSweepLeadEpochs: 0,
+	DeclareFaults:         false,
+	DeclareRecoveries:     false,
+}
+
+var defDealPacking = DealPackingConfig{
+	MaxDeals:          50,
+	GroupByClient:     true,
+	SeparateVerified:  true,
+	MaxEndEpochSpread: 7 * 2880, // a week
+	MinUtilisation:    0,
+	UrgentStartEpochs: 2880, // a day
+	PollInterval:      0,
+	PollMaxBackoff:    Duration(30 * time.Minute),
+}
+
 var defSealing = SealingConfig{
 	MaxWaitDealsSectors:       2, // 64G with 32G sectors
 	MaxSealingSectors:         0,
@@ -31,7 +31,7 @@ var (
 	FullAPIVersion1 = newVer(2, 2, 0)
 
 	MinerAPIVersion0  = newVer(1, 5, 0)
-	WorkerAPIVersion0 = newVer(1, 6, 0)
+	WorkerAPIVersion0 = newVer(1, 8, 0)
 
 	MinerVersion = newVer(1, 3, 0)
 )
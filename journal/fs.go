@@ -1,11 +1,13 @@
 package journal
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/filecoin-project/venus-sealer/constants"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"golang.org/x/xerrors"
 )
@@ -24,10 +26,20 @@ type fsJournal struct {
 
 	incoming chan *Event
 
+	subsLk sync.Mutex
+	subs   map[*subscriber]struct{}
+
 	closing chan struct{}
 	closed  chan struct{}
 }
 
+var _ Reader = (*fsJournal)(nil)
+
+type subscriber struct {
+	q  Query
+	ch chan *Entry
+}
+
 // OpenFSJournal constructs a rolling filesystem journal, with a default
 // per-file size limit of 1GiB.
 func OpenFSJournal(path string, disabled DisabledEvents) (Journal, error) {
@@ -41,6 +53,7 @@ func OpenFSJournal(path string, disabled DisabledEvents) (Journal, error) {
 		dir:               dir,
 		sizeLimit:         1 << 30,
 		incoming:          make(chan *Event, 32),
+		subs:              map[*subscriber]struct{}{},
 		closing:           make(chan struct{}),
 		closed:            make(chan struct{}),
 	}
@@ -94,6 +107,7 @@ func (f *fsJournal) putEvent(evt *Event) error {
 	}
 
 	f.fSize += int64(n)
+	f.publish(b)
 
 	if f.fSize >= f.sizeLimit {
 		_ = f.rollJournalFile()
@@ -107,7 +121,7 @@ func (f *fsJournal) rollJournalFile() error {
 		_ = f.fi.Close()
 	}
 
-	nfi, err := os.Create(filepath.Join(f.dir, fmt.Sprintf("venus-journal-%s.ndjson", constants.Clock.Now().Format(RFC3339nocolon))))
+	nfi, err := os.Create(filepath.Join(f.dir, journalFilePrefix+constants.Clock.Now().Format(RFC3339nocolon)+journalFileSuffix))
 	if err != nil {
 		return xerrors.Errorf("failed to open journal file: %w", err)
 	}
@@ -128,7 +142,73 @@ func (f *fsJournal) runLoop() {
 			}
 		case <-f.closing:
 			_ = f.fi.Close()
+
+			f.subsLk.Lock()
+			for sub := range f.subs {
+				close(sub.ch)
+			}
+			f.subs = nil
+			f.subsLk.Unlock()
 			return
 		}
 	}
 }
+
+func (f *fsJournal) Query(q Query) ([]*Entry, error) {
+	return ReadEntries(f.dir, q)
+}
+
+func (f *fsJournal) Follow(ctx context.Context, q Query) (<-chan *Entry, error) {
+	sub := &subscriber{q: q, ch: make(chan *Entry, 64)}
+
+	f.subsLk.Lock()
+	if f.subs == nil {
+		f.subsLk.Unlock()
+		return nil, xerrors.New("journal closed")
+	}
+	f.subs[sub] = struct{}{}
+	f.subsLk.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-f.closing:
+			return
+		}
+
+		f.subsLk.Lock()
+		if _, ok := f.subs[sub]; ok {
+			delete(f.subs, sub)
+			close(sub.ch)
+		}
+		f.subsLk.Unlock()
+	}()
+
+	return sub.ch, nil
+}
+
+// publish sends a written event to the followers
+func (f *fsJournal) publish(b []byte) {
+	f.subsLk.Lock()
+	defer f.subsLk.Unlock()
+
+	if len(f.subs) == 0 {
+		return
+	}
+
+	var e Entry
+	if err := json.Unmarshal(b, &e); err != nil {
+		log.Warnf("decoding journal event for followers: %s", err)
+		return
+	}
+	for sub := range f.subs {
+		if !sub.q.Match(&e) {
+			continue
+		}
+		select {
+		case sub.ch <- &e:
+		default:
+			log.Warnf("journal follower too slow, dropping %s:%s event", e.System, e.Event)
+		}
+	}
+}
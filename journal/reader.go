@@ -0,0 +1,168 @@
+package journal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"golang.org/x/xerrors"
+)
+
+const (
+	journalFilePrefix = "venus-journal-"
+	journalFileSuffix = ".ndjson"
+
+	// maximum size of a single journal line
+	maxEntrySize = 64 << 20
+)
+
+// Query selects journal entries, zero values match everything.
+type Query struct {
+	System string
+	Event  string
+
+	// Since and Until bound the timestamp of the entries, Since is inclusive,
+	// Until is exclusive
+	Since time.Time
+	Until time.Time
+
+	// Sector only matches the entries whose data has this SectorNumber
+	Sector *abi.SectorNumber
+
+	// Limit returns the latest Limit entries, 0 returns all matching ones
+	Limit int
+}
+
+// Entry is an event read back from the journal.
+type Entry struct {
+	System    string
+	Event     string
+	Timestamp time.Time
+	Data      json.RawMessage
+}
+
+// Match returns true if the entry is selected by the query, Limit is ignored.
+func (q *Query) Match(e *Entry) bool {
+	if q.System != "" && q.System != e.System {
+		return false
+	}
+	if q.Event != "" && q.Event != e.Event {
+		return false
+	}
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Timestamp.Before(q.Until) {
+		return false
+	}
+	if q.Sector != nil {
+		var data struct {
+			SectorNumber *abi.SectorNumber
+		}
+		if err := json.Unmarshal(e.Data, &data); err != nil || data.SectorNumber == nil || *data.SectorNumber != *q.Sector {
+			return false
+		}
+	}
+	return true
+}
+
+// Reader reads back the events written by a journal.
+type Reader interface {
+	// Query returns the matching entries, oldest first.
+	Query(q Query) ([]*Entry, error)
+	// Follow returns the matching entries recorded from now on, until ctx is done.
+	Follow(ctx context.Context, q Query) (<-chan *Entry, error)
+}
+
+// ReadEntries returns the entries of the journal files in dir selected by the
+// query, oldest first.
+func ReadEntries(dir string, q Query) ([]*Entry, error) {
+	files, err := journalFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*Entry
+	for i, file := range files {
+		// a file holds the entries up to the creation of the next one
+		if !q.Until.IsZero() && !file.start.IsZero() && !file.start.Before(q.Until) {
+			break
+		}
+		if !q.Since.IsZero() && i+1 < len(files) && !files[i+1].start.IsZero() && files[i+1].start.Before(q.Since) {
+			continue
+		}
+
+		if err := readFile(file.path, func(e *Entry) {
+			if !q.Match(e) {
+				return
+			}
+			out = append(out, e)
+			if q.Limit > 0 && len(out) > 2*q.Limit {
+				out = append(out[:0], out[len(out)-q.Limit:]...)
+			}
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+	return out, nil
+}
+
+type journalFile struct {
+	path  string
+	start time.Time
+}
+
+func journalFiles(dir string) ([]journalFile, error) {
+	names, err := filepath.Glob(filepath.Join(dir, journalFilePrefix+"*"+journalFileSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]journalFile, 0, len(names))
+	for _, name := range names {
+		ts := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), journalFilePrefix), journalFileSuffix)
+		start, err := time.Parse(RFC3339nocolon, ts)
+		if err != nil {
+			log.Warnf("unexpected journal file name %s: %s", name, err)
+		}
+		files = append(files, journalFile{path: name, start: start})
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].start.Before(files[j].start)
+	})
+	return files, nil
+}
+
+func readFile(path string, cb func(*Entry)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return xerrors.Errorf("open journal file: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxEntrySize)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// the last line may still be written
+			log.Debugf("skipping malformed journal entry in %s: %s", path, err)
+			continue
+		}
+		cb(&e)
+	}
+	if err := scanner.Err(); err != nil {
+		return xerrors.Errorf("reading journal file %s: %w", path, err)
+	}
+	return nil
+}
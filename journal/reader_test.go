@@ -0,0 +1,92 @@
+package journal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/require"
+)
+
+type sectorEvt struct {
+	SectorNumber abi.SectorNumber
+	State        string
+}
+
+func TestReadEntries(t *testing.T) {
+	dir := t.TempDir()
+	j, err := OpenFSJournal(dir, nil)
+	require.NoError(t, err)
+
+	sealing := j.RegisterEventType("storage", "sealing_states")
+	wdpost := j.RegisterEventType("wdpost", "scheduler")
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		sn := abi.SectorNumber(i % 2)
+		j.RecordEvent(sealing, func() interface{} { return sectorEvt{SectorNumber: sn, State: "Packing"} })
+	}
+	j.RecordEvent(wdpost, func() interface{} { return map[string]string{"State": "started"} })
+	defer j.Close() //nolint:errcheck
+
+	var all []*Entry
+	require.Eventually(t, func() bool {
+		all, err = ReadEntries(dir+"/journal", Query{})
+		return err == nil && len(all) == 6
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "wdpost", all[5].System)
+
+	byEvent, err := ReadEntries(dir+"/journal", Query{System: "storage", Event: "sealing_states"})
+	require.NoError(t, err)
+	require.Len(t, byEvent, 5)
+
+	sector := abi.SectorNumber(1)
+	bySector, err := ReadEntries(dir+"/journal", Query{Sector: &sector})
+	require.NoError(t, err)
+	require.Len(t, bySector, 2)
+
+	limited, err := ReadEntries(dir+"/journal", Query{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, limited, 2)
+	require.Equal(t, "wdpost", limited[1].System)
+
+	none, err := ReadEntries(dir+"/journal", Query{Until: start})
+	require.NoError(t, err)
+	require.Len(t, none, 0)
+
+	later, err := ReadEntries(dir+"/journal", Query{Since: time.Now()})
+	require.NoError(t, err)
+	require.Len(t, later, 0)
+}
+
+func TestFollow(t *testing.T) {
+	j, err := OpenFSJournal(t.TempDir(), nil)
+	require.NoError(t, err)
+
+	sealing := j.RegisterEventType("storage", "sealing_states")
+	wdpost := j.RegisterEventType("wdpost", "scheduler")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := j.(Reader).Follow(ctx, Query{System: "storage"})
+	require.NoError(t, err)
+
+	j.RecordEvent(wdpost, func() interface{} { return nil })
+	j.RecordEvent(sealing, func() interface{} { return sectorEvt{SectorNumber: 3} })
+
+	select {
+	case e := <-ch:
+		require.Equal(t, "sealing_states", e.Event)
+		require.JSONEq(t, `{"SectorNumber":3,"State":""}`, string(e.Data))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for journal entry")
+	}
+
+	cancel()
+	for range ch {
+	}
+	require.NoError(t, j.Close())
+
+	_, err = j.(Reader).Follow(context.Background(), Query{})
+	require.Error(t, err)
+}
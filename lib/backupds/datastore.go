@@ -31,22 +31,7 @@ func Wrap(child datastore.Batching) *Datastore {
 // Writes a datastore dump into the provided writer as
 // [array(*) of [key, value] tuples, checksum]
 func (d *Datastore) Backup(ctx context.Context, out io.Writer) error {
-	scratch := make([]byte, 9)
-
-	if err := cbg.WriteMajorTypeHeaderBuf(scratch, out, cbg.MajArray, 2); err != nil {
-		return xerrors.Errorf("writing tuple header: %w", err)
-	}
-
-	hasher := sha256.New()
-	hout := io.MultiWriter(hasher, out)
-
-	// write KVs
-	{
-		// write indefinite length array header
-		if _, err := hout.Write([]byte{0x9f}); err != nil {
-			return xerrors.Errorf("writing header: %w", err)
-		}
-
+	return WriteBackup(out, func(put func(key datastore.Key, value []byte) error) error {
 		d.backupLk.Lock()
 		defer d.backupLk.Unlock()
 
@@ -65,25 +50,60 @@ func (d *Datastore) Backup(ctx context.Context, out io.Writer) error {
 		}()
 
 		for result := range qr.Next() {
+			if err := put(datastore.NewKey(result.Key), result.Value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// WriteBackup writes the entries produced by iter into out using the same
+// [array(*) of [key, value] tuples, checksum] layout as Datastore.Backup, so
+// the result can be read back with ReadBackup
+func WriteBackup(out io.Writer, iter func(put func(key datastore.Key, value []byte) error) error) error {
+	scratch := make([]byte, 9)
+
+	if err := cbg.WriteMajorTypeHeaderBuf(scratch, out, cbg.MajArray, 2); err != nil {
+		return xerrors.Errorf("writing tuple header: %w", err)
+	}
+
+	hasher := sha256.New()
+	hout := io.MultiWriter(hasher, out)
+
+	// write KVs
+	{
+		// write indefinite length array header
+		if _, err := hout.Write([]byte{0x9f}); err != nil {
+			return xerrors.Errorf("writing header: %w", err)
+		}
+
+		err := iter(func(key datastore.Key, value []byte) error {
 			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajArray, 2); err != nil {
 				return xerrors.Errorf("writing tuple header: %w", err)
 			}
 
-			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len([]byte(result.Key)))); err != nil {
+			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len([]byte(key.String())))); err != nil {
 				return xerrors.Errorf("writing key header: %w", err)
 			}
 
-			if _, err := hout.Write([]byte(result.Key)[:]); err != nil {
+			if _, err := hout.Write([]byte(key.String())[:]); err != nil {
 				return xerrors.Errorf("writing key: %w", err)
 			}
 
-			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len(result.Value))); err != nil {
+			if err := cbg.WriteMajorTypeHeaderBuf(scratch, hout, cbg.MajByteString, uint64(len(value))); err != nil {
 				return xerrors.Errorf("writing value header: %w", err)
 			}
 
-			if _, err := hout.Write(result.Value[:]); err != nil {
+			if _, err := hout.Write(value[:]); err != nil {
 				return xerrors.Errorf("writing value: %w", err)
 			}
+
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 
 		// array break
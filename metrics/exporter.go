@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"net/http"
+
+	"contrib.go.opencensus.io/exporter/prometheus"
+	logging "github.com/ipfs/go-log/v2"
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("metrics")
+
+// Exporter returns a http handler serving all registered views in the prometheus
+// text format, along with the go runtime and process metrics of the default registry
+func Exporter() (http.Handler, error) {
+	// Prometheus globals are exposed as interfaces, but the prometheus
+	// OpenCensus exporter expects a concrete *Registry. The concrete type of
+	// the globals are actually *Registry, so we downcast them, staying
+	// defensive in case things change under the hood.
+	registry, ok := promclient.DefaultRegisterer.(*promclient.Registry)
+	if !ok {
+		log.Warnf("failed to export default prometheus registry; some metrics will be unavailable; unexpected type: %T", promclient.DefaultRegisterer)
+	}
+	exporter, err := prometheus.NewExporter(prometheus.Options{
+		Registry:  registry,
+		Namespace: "venus",
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("creating the prometheus stats exporter: %w", err)
+	}
+
+	return exporter, nil
+}
+
+// WithoutAuth serves the metrics of the exporter on /metrics and
+// /debug/metrics and the other requests with next. next usually checks the API
+// token, the scrapers don't need one.
+func WithoutAuth(exporter http.Handler, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metrics", "/debug/metrics":
+			exporter.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
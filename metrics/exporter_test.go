@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithoutAuth(t *testing.T) {
+	exporter := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	auth := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	h := WithoutAuth(exporter, auth)
+
+	for path, code := range map[string]int{
+		"/metrics":       http.StatusOK,
+		"/debug/metrics": http.StatusOK,
+		"/rpc/v0":        http.StatusUnauthorized,
+		"/remote/sealed": http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer invalid")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		require.Equal(t, code, rec.Code, path)
+	}
+}
@@ -0,0 +1,169 @@
+package metrics
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// Distribution
+var defaultMillisecondsDistribution = view.Distribution(0.01, 0.05, 0.1, 0.3, 0.6, 0.8, 1, 2, 3, 4, 5, 6, 8, 10, 13, 16, 20, 25, 30, 40, 50, 65, 80, 100, 130, 160, 200, 250, 300, 400, 500, 650, 800, 1000, 2000, 3000, 4000, 5000, 7500, 10000, 20000, 50000, 100000)
+var taskDurationDistribution = view.Distribution(1, 5, 10, 30, 60, 120, 300, 600, 900, 1200, 1800, 2700, 3600, 5400, 7200, 3*3600, 4*3600, 5*3600, 6*3600, 8*3600, 10*3600, 12*3600, 24*3600)
+var stateDurationDistribution = view.Distribution(1, 10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200, 3*3600, 4*3600, 6*3600, 8*3600, 12*3600, 24*3600, 48*3600, 7*24*3600)
+
+// Global Tags
+var (
+	SectorState, _ = tag.NewKey("sector_state")
+	TaskType, _    = tag.NewKey("task_type")
+	WorkerHost, _  = tag.NewKey("worker")
+	// ResourceStage is either "active" or "preparing"
+	ResourceStage, _ = tag.NewKey("stage")
+	Deadline, _      = tag.NewKey("deadline")
+	// Result is the outcome of a fetch, a task or a window post
+	Result, _    = tag.NewKey("result")
+	StorageID, _ = tag.NewKey("storage_id")
+	// StoragePath is the local filesystem path of a storage
+	StoragePath, _ = tag.NewKey("path")
+)
+
+// Measures
+var (
+	// sealing
+	SectorStates        = stats.Int64("sealer/sectors", "Number of sectors in each state", stats.UnitDimensionless)
+	SectorStateDuration = stats.Float64("sealer/sector_state_duration_seconds", "Time a sector spent in a state before leaving it", stats.UnitSeconds)
+
+	// scheduler
+	SchedQueueTasks       = stats.Int64("sealer/sched_queue_tasks", "Number of tasks waiting in the scheduler queue", stats.UnitDimensionless)
+	SchedWorkerMemUsedMin = stats.Int64("sealer/sched_worker_mem_used_min_bytes", "Minimum memory claimed by tasks on a worker", stats.UnitBytes)
+	SchedWorkerMemUsedMax = stats.Int64("sealer/sched_worker_mem_used_max_bytes", "Maximum memory claimed by tasks on a worker", stats.UnitBytes)
+	SchedWorkerCpuUse     = stats.Int64("sealer/sched_worker_cpu_use", "CPU threads claimed by tasks on a worker", stats.UnitDimensionless)
+	SchedWorkerGpuUsed    = stats.Float64("sealer/sched_worker_gpu_used", "GPUs claimed by tasks on a worker", stats.UnitDimensionless)
+
+	// remote store fetches
+	FetchBytes    = stats.Int64("sealer/fetch_bytes", "Bytes fetched from remote stores", stats.UnitBytes)
+	FetchDuration = stats.Float64("sealer/fetch_ms", "Duration of fetches from remote stores", stats.UnitMilliseconds)
+
+	// window post
+	WdPoStResults = stats.Int64("sealer/wdpost_results", "Outcome of window post per deadline", stats.UnitDimensionless)
+
+	// local storage
+	StorageCapacity  = stats.Int64("storage/capacity_bytes", "Capacity of a local storage path", stats.UnitBytes)
+	StorageAvailable = stats.Int64("storage/available_bytes", "Space available for sectors in a local storage path", stats.UnitBytes)
+
+	// worker
+	WorkerTasksRunning = stats.Int64("worker/tasks_running", "Number of tasks running on the worker", stats.UnitDimensionless)
+	WorkerTaskDuration = stats.Float64("worker/task_duration_seconds", "Duration of tasks run by the worker", stats.UnitSeconds)
+	WorkerTaskNumber   = stats.Int64("worker/task_number", "Number of tasks currently accepted by the worker", stats.UnitDimensionless)
+	WorkerTaskTotal    = stats.Int64("worker/task_total", "Maximum number of tasks accepted by the worker, -1 when unlimited", stats.UnitDimensionless)
+)
+
+var (
+	SectorStatesView = &view.View{
+		Measure:     SectorStates,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{SectorState},
+	}
+	SectorStateDurationView = &view.View{
+		Measure:     SectorStateDuration,
+		Aggregation: stateDurationDistribution,
+		TagKeys:     []tag.Key{SectorState},
+	}
+	SchedQueueTasksView = &view.View{
+		Measure:     SchedQueueTasks,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TaskType},
+	}
+	SchedWorkerMemUsedMinView = &view.View{
+		Measure:     SchedWorkerMemUsedMin,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{WorkerHost, ResourceStage},
+	}
+	SchedWorkerMemUsedMaxView = &view.View{
+		Measure:     SchedWorkerMemUsedMax,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{WorkerHost, ResourceStage},
+	}
+	SchedWorkerCpuUseView = &view.View{
+		Measure:     SchedWorkerCpuUse,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{WorkerHost, ResourceStage},
+	}
+	SchedWorkerGpuUsedView = &view.View{
+		Measure:     SchedWorkerGpuUsed,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{WorkerHost, ResourceStage},
+	}
+	FetchBytesView = &view.View{
+		Measure:     FetchBytes,
+		Aggregation: view.Sum(),
+	}
+	FetchDurationView = &view.View{
+		Measure:     FetchDuration,
+		Aggregation: defaultMillisecondsDistribution,
+		TagKeys:     []tag.Key{Result},
+	}
+	WdPoStResultsView = &view.View{
+		Measure:     WdPoStResults,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{Deadline, Result},
+	}
+)
+
+var (
+	StorageCapacityView = &view.View{
+		Measure:     StorageCapacity,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{StorageID, StoragePath},
+	}
+	StorageAvailableView = &view.View{
+		Measure:     StorageAvailable,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{StorageID, StoragePath},
+	}
+	WorkerTasksRunningView = &view.View{
+		Measure:     WorkerTasksRunning,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{TaskType},
+	}
+	WorkerTaskDurationView = &view.View{
+		Measure:     WorkerTaskDuration,
+		Aggregation: taskDurationDistribution,
+		TagKeys:     []tag.Key{TaskType, Result},
+	}
+	WorkerTaskNumberView = &view.View{
+		Measure:     WorkerTaskNumber,
+		Aggregation: view.LastValue(),
+	}
+	WorkerTaskTotalView = &view.View{
+		Measure:     WorkerTaskTotal,
+		Aggregation: view.LastValue(),
+	}
+)
+
+// SealerViews are the views exported by venus-sealer
+var SealerViews = []*view.View{
+	SectorStatesView,
+	SectorStateDurationView,
+	SchedQueueTasksView,
+	SchedWorkerMemUsedMinView,
+	SchedWorkerMemUsedMaxView,
+	SchedWorkerCpuUseView,
+	SchedWorkerGpuUsedView,
+	FetchBytesView,
+	FetchDurationView,
+	WdPoStResultsView,
+	StorageCapacityView,
+	StorageAvailableView,
+}
+
+// WorkerViews are the views exported by venus-worker
+var WorkerViews = []*view.View{
+	WorkerTasksRunningView,
+	WorkerTaskDurationView,
+	WorkerTaskNumberView,
+	WorkerTaskTotalView,
+	FetchBytesView,
+	FetchDurationView,
+	StorageCapacityView,
+	StorageAvailableView,
+}
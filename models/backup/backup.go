@@ -0,0 +1,109 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+	"gorm.io/gorm"
+
+	"github.com/filecoin-project/venus-sealer/lib/backupds"
+)
+
+var log = logging.Logger("db-backup")
+
+const batchSize = 500
+
+// Table is a gorm model which can be dumped into a backup, all models in models/* satisfy it
+type Table interface {
+	TableName() string
+}
+
+// Backup dumps every row of the given tables into out. Rows are stored as
+// /<table>/<seq> => json(model), the whole dump is read in one transaction so that
+// the snapshot is consistent while the sealer keeps running.
+func Backup(ctx context.Context, db *gorm.DB, out io.Writer, tables ...Table) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return backupds.WriteBackup(out, func(put func(key datastore.Key, value []byte) error) error {
+			for _, table := range tables {
+				count, err := dumpTable(tx, table, put)
+				if err != nil {
+					return xerrors.Errorf("dump table %s: %w", table.TableName(), err)
+				}
+				log.Infof("backup table %s, %d rows", table.TableName(), count)
+			}
+			return nil
+		})
+	})
+}
+
+func dumpTable(tx *gorm.DB, table Table, put func(key datastore.Key, value []byte) error) (int, error) {
+	rows := reflect.New(reflect.SliceOf(reflect.TypeOf(table)))
+	prefix := datastore.NewKey(table.TableName())
+
+	seq := 0
+	res := tx.Model(table).FindInBatches(rows.Interface(), batchSize, func(_ *gorm.DB, _ int) error {
+		for i := 0; i < rows.Elem().Len(); i++ {
+			val, err := json.Marshal(rows.Elem().Index(i).Interface())
+			if err != nil {
+				return xerrors.Errorf("marshal row: %w", err)
+			}
+			if err := put(prefix.ChildString(strconv.Itoa(seq)), val); err != nil {
+				return err
+			}
+			seq++
+		}
+		return nil
+	})
+	return seq, res.Error
+}
+
+// Restore replaces the content of the given tables with the rows read from a
+// backup written by Backup. The checksum of the backup is verified before the
+// transaction is committed, any error rolls the whole restore back.
+func Restore(ctx context.Context, db *gorm.DB, in io.Reader, tables ...Table) error {
+	models := make(map[string]reflect.Type, len(tables))
+	for _, table := range tables {
+		models[table.TableName()] = reflect.TypeOf(table).Elem()
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, table := range tables {
+			if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(table).Error; err != nil {
+				return xerrors.Errorf("clean table %s: %w", table.TableName(), err)
+			}
+		}
+
+		counts := make(map[string]int, len(tables))
+		err := backupds.ReadBackup(in, func(key datastore.Key, value []byte) error {
+			name := key.Parent().Name()
+			typ, ok := models[name]
+			if !ok {
+				return xerrors.Errorf("unknown table %s in backup", name)
+			}
+
+			row := reflect.New(typ).Interface()
+			if err := json.Unmarshal(value, row); err != nil {
+				return xerrors.Errorf("unmarshal row %s: %w", key, err)
+			}
+			if err := tx.Create(row).Error; err != nil {
+				return xerrors.Errorf("insert row %s: %w", key, err)
+			}
+			counts[name]++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for name, count := range counts {
+			log.Infof("restore table %s, %d rows", name, count)
+		}
+		return nil
+	})
+}
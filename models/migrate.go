@@ -0,0 +1,140 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/lib/backupds"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+)
+
+var log = logging.Logger("models")
+
+// Migrate copies every table of from into to. The source is first dumped into
+// snapshotPath with Backup, which is kept so that the migration can be replayed
+// with `backup restore`, then restored into to. Afterwards the row count of each
+// table and a checksum of every sector (sector info and its logs) are compared
+// between both databases. The sealer must not be running while migrating.
+func Migrate(ctx context.Context, from, to repo.Repo, snapshotPath string) error {
+	if err := to.AutoMigrate(); err != nil {
+		return xerrors.Errorf("migrating target database: %w", err)
+	}
+
+	snapshot, err := os.OpenFile(snapshotPath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	if err != nil {
+		return xerrors.Errorf("opening snapshot file: %w", err)
+	}
+	defer snapshot.Close() //nolint:errcheck
+
+	if err := from.Backup(ctx, snapshot); err != nil {
+		return xerrors.Errorf("dumping source database: %w", err)
+	}
+
+	if _, err := snapshot.Seek(0, 0); err != nil {
+		return err
+	}
+	rows := map[string]int64{}
+	if err := backupds.ReadBackup(snapshot, func(key datastore.Key, _ []byte) error {
+		rows[key.Parent().Name()]++
+		return nil
+	}); err != nil {
+		return xerrors.Errorf("reading snapshot: %w", err)
+	}
+
+	if _, err := snapshot.Seek(0, 0); err != nil {
+		return err
+	}
+	if err := to.Restore(ctx, snapshot); err != nil {
+		return xerrors.Errorf("restoring snapshot into target database: %w", err)
+	}
+
+	if err := verifyRowCounts(from, to, rows); err != nil {
+		return err
+	}
+	return verifySectors(from, to)
+}
+
+func verifyRowCounts(from, to repo.Repo, rows map[string]int64) error {
+	for table, expect := range rows {
+		var srcCount, dstCount int64
+		if err := from.GetDb().Table(table).Count(&srcCount).Error; err != nil {
+			return xerrors.Errorf("counting %s in source: %w", table, err)
+		}
+		if err := to.GetDb().Table(table).Count(&dstCount).Error; err != nil {
+			return xerrors.Errorf("counting %s in target: %w", table, err)
+		}
+		if srcCount != expect || dstCount != expect {
+			return xerrors.Errorf("row count of %s mismatch, snapshot: %d, source: %d, target: %d", table, expect, srcCount, dstCount)
+		}
+		log.Infof("table %s: %d rows", table, dstCount)
+	}
+	return nil
+}
+
+func verifySectors(from, to repo.Repo) error {
+	srcSums, err := sectorChecksums(from)
+	if err != nil {
+		return xerrors.Errorf("checksum source sectors: %w", err)
+	}
+	dstSums, err := sectorChecksums(to)
+	if err != nil {
+		return xerrors.Errorf("checksum target sectors: %w", err)
+	}
+
+	if len(srcSums) != len(dstSums) {
+		return xerrors.Errorf("sector count mismatch, source: %d, target: %d", len(srcSums), len(dstSums))
+	}
+	for number, sum := range srcSums {
+		if dstSums[number] != sum {
+			return xerrors.Errorf("checksum of sector %d mismatch", number)
+		}
+	}
+	log.Infof("%d sectors verified", len(srcSums))
+	return nil
+}
+
+func sectorChecksums(r repo.Repo) (map[abi.SectorNumber][32]byte, error) {
+	sectors, err := r.SectorInfoRepo().GetAllSectorInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[abi.SectorNumber][32]byte, len(sectors))
+	for _, sector := range sectors {
+		buf := new(bytes.Buffer)
+		if err := sector.MarshalCBOR(buf); err != nil {
+			return nil, xerrors.Errorf("marshal sector %d: %w", sector.SectorNumber, err)
+		}
+
+		logs, err := r.LogRepo().List(sector.SectorNumber)
+		if err != nil {
+			return nil, xerrors.Errorf("list logs of sector %d: %w", sector.SectorNumber, err)
+		}
+		sort.SliceStable(logs, func(i, j int) bool {
+			if logs[i].Timestamp != logs[j].Timestamp {
+				return logs[i].Timestamp < logs[j].Timestamp
+			}
+			if logs[i].Kind != logs[j].Kind {
+				return logs[i].Kind < logs[j].Kind
+			}
+			return logs[i].Message < logs[j].Message
+		})
+		logsJson, err := json.Marshal(logs)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(logsJson)
+
+		out[sector.SectorNumber] = sha256.Sum256(buf.Bytes())
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/models/sqlite"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func openTestSqlite(t *testing.T, name string) repo.Repo {
+	r, err := sqlite.OpenSqlite(&config.SqliteConfig{Path: filepath.Join(t.TempDir(), name)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestMigrate(t *testing.T) {
+	from := openTestSqlite(t, "from.db")
+	to := openTestSqlite(t, "to.db")
+
+	addr, _ := address.NewFromString("t01000")
+	if err := from.MetaDataRepo().SaveMinerAddress(addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := from.MetaDataRepo().SetStorageCounter(3); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if err := from.SectorInfoRepo().Save(&types.SectorInfo{SectorNumber: abi.SectorNumber(i), State: types.Proving}); err != nil {
+			t.Fatal(err)
+		}
+		if err := from.LogRepo().Append(&types.Log{SectorNumber: abi.SectorNumber(i), Kind: "event;sealing.SectorStart"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := from.DealRefRepo().Save(12, types.SealedRef{SectorID: 1, Offset: 0, Size: 127}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(context.Background(), from, to, filepath.Join(t.TempDir(), "snapshot")); err != nil {
+		t.Fatal(err)
+	}
+
+	counter, err := to.MetaDataRepo().GetStorageCounter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter != 3 {
+		t.Errorf("expect storage counter %d but got %d", 3, counter)
+	}
+	maddr, err := to.MetaDataRepo().GetMinerAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if maddr != addr {
+		t.Errorf("expect miner address %s but got %s", addr, maddr)
+	}
+
+	// a sector changed after the copy is detected
+	if err := to.SectorInfoRepo().UpdateSectorInfoBySectorId(&types.SectorInfo{SectorNumber: 2, State: types.Faulty}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifySectors(from, to); err == nil {
+		t.Errorf("expect checksum mismatch of sector 2")
+	}
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"github.com/filecoin-project/venus-sealer/config"
 	"github.com/filecoin-project/venus-sealer/models/mysql"
+	"github.com/filecoin-project/venus-sealer/models/postgres"
 	"github.com/filecoin-project/venus-sealer/models/repo"
 	"github.com/filecoin-project/venus-sealer/models/sqlite"
 	"golang.org/x/xerrors"
@@ -16,8 +17,10 @@ func SetDataBase(homeDir config.HomeDir, cfg *config.DbConfig) (repo.Repo, error
 		return sqlite.OpenSqlite(&cfg.Sqlite)
 	case "mysql":
 		return mysql.OpenMysql(&cfg.MySql)
+	case "postgres":
+		return postgres.OpenPostgres(&cfg.Postgres)
 	default:
-		return nil, xerrors.Errorf("unsupport db type,(%s, %s)", "sqlite", "mysql")
+		return nil, xerrors.Errorf("unsupport db type,(%s, %s, %s)", "sqlite", "mysql", "postgres")
 	}
 }
 
@@ -1,9 +1,12 @@
 package mysql
 
 import (
+	"context"
 	"fmt"
 	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/backup"
 	"github.com/filecoin-project/venus-sealer/models/repo"
+	"io"
 	"time"
 
 	"golang.org/x/xerrors"
@@ -16,37 +19,68 @@ type MysqlRepo struct {
 }
 
 func (d MysqlRepo) DealRefRepo() repo.DealRefRepo {
-	panic("implement me")
+	return newDealRefRepo(d.GetDb())
 }
 
 func (d MysqlRepo) LogRepo() repo.LogRepo {
-	panic("implement me")
+	return newLogRepo(d.GetDb())
 }
 
 func (d MysqlRepo) WorkerCallRepo() repo.WorkerCallRepo {
-	panic("implement me")
+	return newWorkerCallRepo(d.GetDb())
 }
 
 func (d MysqlRepo) SectorInfoRepo() repo.SectorInfoRepo {
-	panic("implement me")
+	return newSectorInfoRepo(d.GetDb())
 }
 
 func (d MysqlRepo) WorkerStateRepo() repo.WorkerStateRepo {
-	panic("implement me")
+	return newWorkerStateRepo(d.GetDb())
 }
 
 func (d MysqlRepo) MetaDataRepo() repo.MetaDataRepo {
-	panic("implement me")
+	return newMetadataRepo(d.GetDb())
+}
+
+func (d MysqlRepo) StorageIndexRepo() repo.StorageIndexRepo {
+	return newStorageIndexRepo(d.GetDb())
+}
+
+func (d MysqlRepo) NotifyOutboxRepo() repo.NotifyOutboxRepo {
+	return newNotifyOutboxRepo(d.GetDb())
+}
+
+func (d MysqlRepo) SectorHealthRepo() repo.SectorHealthRepo {
+	return newSectorHealthRepo(d.GetDb())
+}
+
+func (d MysqlRepo) ProvingHistoryRepo() repo.ProvingHistoryRepo {
+	return newProvingHistoryRepo(d.GetDb())
+}
+
+func (d MysqlRepo) DealAssignmentRepo() repo.DealAssignmentRepo {
+	return newDealAssignmentRepo(d.GetDb())
 }
 
 func (d MysqlRepo) AutoMigrate() error {
-	return nil
-	/*	err := d.GetDb().AutoMigrate(mysqlMessage{})
-		if err != nil {
-			return err
+	db := d.GetDb().Set("gorm:table_options", "CHARSET=utf8mb4")
+	for _, table := range tables {
+		if err := db.AutoMigrate(table); err != nil {
+			return xerrors.Errorf("migrate table %s: %w", table.TableName(), err)
 		}
+	}
+	return nil
+}
+
+// tables are all models stored by the repo, in the order they are backed up and restored
+var tables = []backup.Table{&dealRef{}, &metadata{}, &Log{}, &sectorInfo{}, &workerCall{}, &workerState{}, &storagePath{}, &sectorDecl{}, &notifyMessage{}, &sectorHealth{}, &partitionPoSt{}, &dealAssignment{}}
+
+func (d MysqlRepo) Backup(ctx context.Context, out io.Writer) error {
+	return backup.Backup(ctx, d.GetDb(), out, tables...)
+}
 
-		return d.GetDb().AutoMigrate(mysqlWallet{})*/
+func (d MysqlRepo) Restore(ctx context.Context, in io.Reader) error {
+	return backup.Restore(ctx, d.GetDb(), in, tables...)
 }
 
 func (d MysqlRepo) GetDb() *gorm.DB {
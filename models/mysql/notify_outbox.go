@@ -0,0 +1,102 @@
+package mysql
+
+import (
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+	"gorm.io/gorm"
+)
+
+type notifyMessage struct {
+	Id      int64  `gorm:"column:id;primary_key;autoIncrement;" json:"id"`
+	Sink    string `gorm:"column:sink;type:varchar(128);" json:"sink"`
+	Topic   string `gorm:"column:topic;type:varchar(256);" json:"topic"`
+	Payload string `gorm:"column:payload;type:text;" json:"payload"`
+
+	Attempts    int    `gorm:"column:attempts;type:int;" json:"attempts"`
+	NextAttempt int64  `gorm:"column:next_attempt;type:bigint;index:notify_next_attempt;" json:"next_attempt"`
+	LastErr     string `gorm:"column:last_err;type:text;" json:"last_err"`
+	CreatedAt   int64  `gorm:"column:created_at;type:bigint;autoCreateTime:false;" json:"created_at"`
+}
+
+func (notifyMessage *notifyMessage) TableName() string {
+	return "notify_outbox"
+}
+
+func (notifyMessage *notifyMessage) Message() *types.NotifyMessage {
+	return &types.NotifyMessage{
+		ID:          notifyMessage.Id,
+		Sink:        notifyMessage.Sink,
+		Topic:       notifyMessage.Topic,
+		Payload:     notifyMessage.Payload,
+		Attempts:    notifyMessage.Attempts,
+		NextAttempt: notifyMessage.NextAttempt,
+		LastErr:     notifyMessage.LastErr,
+		CreatedAt:   notifyMessage.CreatedAt,
+	}
+}
+
+var _ repo.NotifyOutboxRepo = (*notifyOutboxRepo)(nil)
+
+type notifyOutboxRepo struct {
+	*gorm.DB
+}
+
+func newNotifyOutboxRepo(db *gorm.DB) *notifyOutboxRepo {
+	return &notifyOutboxRepo{DB: db}
+}
+
+func (n *notifyOutboxRepo) Enqueue(msgs []*types.NotifyMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	rows := make([]*notifyMessage, len(msgs))
+	for index, msg := range msgs {
+		rows[index] = &notifyMessage{
+			Sink:        msg.Sink,
+			Topic:       msg.Topic,
+			Payload:     msg.Payload,
+			Attempts:    msg.Attempts,
+			NextAttempt: msg.NextAttempt,
+			LastErr:     msg.LastErr,
+			CreatedAt:   msg.CreatedAt,
+		}
+	}
+	if err := n.DB.Create(&rows).Error; err != nil {
+		return err
+	}
+	for index, row := range rows {
+		msgs[index].ID = row.Id
+	}
+	return nil
+}
+
+func (n *notifyOutboxRepo) ListDue(now int64, limit int) ([]*types.NotifyMessage, error) {
+	var rows []*notifyMessage
+	err := n.DB.Table("notify_outbox").Where("next_attempt <= ?", now).Order("id").Limit(limit).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*types.NotifyMessage, len(rows))
+	for index, row := range rows {
+		result[index] = row.Message()
+	}
+	return result, nil
+}
+
+func (n *notifyOutboxRepo) UpdateAttempt(id int64, attempts int, nextAttempt int64, lastErr string) error {
+	return n.DB.Table("notify_outbox").Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts":     attempts,
+		"next_attempt": nextAttempt,
+		"last_err":     lastErr,
+	}).Error
+}
+
+func (n *notifyOutboxRepo) Delete(id int64) error {
+	return n.DB.Delete(&notifyMessage{}, "id = ?", id).Error
+}
+
+func (n *notifyOutboxRepo) Count() (int64, error) {
+	var count int64
+	err := n.DB.Table("notify_outbox").Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,88 @@
+package mysql
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type sectorHealth struct {
+	SectorNumber uint64 `gorm:"column:sector_number;type:bigint unsigned;primary_key;" json:"sector_number"`
+	Deadline     uint64 `gorm:"column:deadline;type:bigint unsigned;" json:"deadline"`
+	Partition    uint64 `gorm:"column:partition_index;type:bigint unsigned;" json:"partition_index"`
+
+	Healthy       bool   `gorm:"column:healthy;type:bool;" json:"healthy"`
+	Reason        string `gorm:"column:reason;type:text;" json:"reason"`
+	FaultDeclared bool   `gorm:"column:fault_declared;type:bool;" json:"fault_declared"`
+
+	DeadlineOpen int64 `gorm:"column:deadline_open;type:bigint;" json:"deadline_open"`
+	CheckedAt    int64 `gorm:"column:checked_at;type:bigint;" json:"checked_at"`
+}
+
+func (sectorHealth *sectorHealth) TableName() string {
+	return "sector_healths"
+}
+
+func (sectorHealth *sectorHealth) Health() *types.SectorHealth {
+	return &types.SectorHealth{
+		SectorNumber:  abi.SectorNumber(sectorHealth.SectorNumber),
+		Deadline:      sectorHealth.Deadline,
+		Partition:     sectorHealth.Partition,
+		Healthy:       sectorHealth.Healthy,
+		Reason:        sectorHealth.Reason,
+		FaultDeclared: sectorHealth.FaultDeclared,
+		DeadlineOpen:  abi.ChainEpoch(sectorHealth.DeadlineOpen),
+		CheckedAt:     sectorHealth.CheckedAt,
+	}
+}
+
+var _ repo.SectorHealthRepo = (*sectorHealthRepo)(nil)
+
+type sectorHealthRepo struct {
+	*gorm.DB
+}
+
+func newSectorHealthRepo(db *gorm.DB) *sectorHealthRepo {
+	return &sectorHealthRepo{DB: db}
+}
+
+func (s *sectorHealthRepo) SaveDeadlineHealth(deadline uint64, healths []*types.SectorHealth) error {
+	rows := make([]*sectorHealth, len(healths))
+	for index, health := range healths {
+		rows[index] = &sectorHealth{
+			SectorNumber:  uint64(health.SectorNumber),
+			Deadline:      health.Deadline,
+			Partition:     health.Partition,
+			Healthy:       health.Healthy,
+			Reason:        health.Reason,
+			FaultDeclared: health.FaultDeclared,
+			DeadlineOpen:  int64(health.DeadlineOpen),
+			CheckedAt:     health.CheckedAt,
+		}
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&sectorHealth{}, "deadline = ?", deadline).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		// a sector moved from another deadline replaces its old row
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(&rows, 500).Error
+	})
+}
+
+func (s *sectorHealthRepo) ListSectorHealth() ([]*types.SectorHealth, error) {
+	var rows []*sectorHealth
+	err := s.DB.Table("sector_healths").Order("deadline, partition_index, sector_number").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*types.SectorHealth, len(rows))
+	for index, row := range rows {
+		result[index] = row.Health()
+	}
+	return result, nil
+}
@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/backup"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"golang.org/x/xerrors"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type PostgresRepo struct {
+	*gorm.DB
+}
+
+func (d PostgresRepo) DealRefRepo() repo.DealRefRepo {
+	return newDealRefRepo(d.GetDb())
+}
+
+func (d PostgresRepo) LogRepo() repo.LogRepo {
+	return newLogRepo(d.GetDb())
+}
+
+func (d PostgresRepo) WorkerCallRepo() repo.WorkerCallRepo {
+	return newWorkerCallRepo(d.GetDb())
+}
+
+func (d PostgresRepo) SectorInfoRepo() repo.SectorInfoRepo {
+	return newSectorInfoRepo(d.GetDb())
+}
+
+func (d PostgresRepo) WorkerStateRepo() repo.WorkerStateRepo {
+	return newWorkerStateRepo(d.GetDb())
+}
+
+func (d PostgresRepo) MetaDataRepo() repo.MetaDataRepo {
+	return newMetadataRepo(d.GetDb())
+}
+
+func (d PostgresRepo) StorageIndexRepo() repo.StorageIndexRepo {
+	return newStorageIndexRepo(d.GetDb())
+}
+
+func (d PostgresRepo) NotifyOutboxRepo() repo.NotifyOutboxRepo {
+	return newNotifyOutboxRepo(d.GetDb())
+}
+
+func (d PostgresRepo) SectorHealthRepo() repo.SectorHealthRepo {
+	return newSectorHealthRepo(d.GetDb())
+}
+
+func (d PostgresRepo) ProvingHistoryRepo() repo.ProvingHistoryRepo {
+	return newProvingHistoryRepo(d.GetDb())
+}
+
+func (d PostgresRepo) DealAssignmentRepo() repo.DealAssignmentRepo {
+	return newDealAssignmentRepo(d.GetDb())
+}
+
+func (d PostgresRepo) AutoMigrate() error {
+	for _, table := range tables {
+		if err := d.GetDb().AutoMigrate(table); err != nil {
+			return xerrors.Errorf("migrate table %s: %w", table.TableName(), err)
+		}
+	}
+	return nil
+}
+
+// tables are all models stored by the repo, in the order they are backed up and restored
+var tables = []backup.Table{&dealRef{}, &metadata{}, &Log{}, &sectorInfo{}, &workerCall{}, &workerState{}, &storagePath{}, &sectorDecl{}, &notifyMessage{}, &sectorHealth{}, &partitionPoSt{}, &dealAssignment{}}
+
+func (d PostgresRepo) Backup(ctx context.Context, out io.Writer) error {
+	return backup.Backup(ctx, d.GetDb(), out, tables...)
+}
+
+func (d PostgresRepo) Restore(ctx context.Context, in io.Reader) error {
+	if err := backup.Restore(ctx, d.GetDb(), in, tables...); err != nil {
+		return err
+	}
+	// rows are restored with their ids, the serials must be moved past them
+	for _, table := range []string{"logs", "notify_outbox"} {
+		err := d.GetDb().WithContext(ctx).
+			Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM %s", table, table)).Error
+		if err != nil {
+			return xerrors.Errorf("reset id sequence of %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+func (d PostgresRepo) GetDb() *gorm.DB {
+	return d.DB
+}
+
+func (d PostgresRepo) DbClose() error {
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+func OpenPostgres(cfg *config.PostgresConfig) (repo.Repo, error) {
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Pass),
+		Host:     cfg.Addr,
+		Path:     cfg.Name,
+		RawQuery: url.Values{"sslmode": []string{sslMode}}.Encode(),
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn.String()), &gorm.Config{
+		//Logger: logger.Default.LogMode(logger.Info),
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("[db connection failed] Database name: %s %w", cfg.Name, err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConn)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConn)
+	sqlDB.SetConnMaxLifetime(time.Minute * cfg.ConnMaxLifeTime)
+
+	return &PostgresRepo{
+		db,
+	}, nil
+}
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type partitionPoSt struct {
+	Deadline  uint64 `gorm:"column:deadline;type:bigint;primary_key;" json:"deadline"`
+	Partition uint64 `gorm:"column:partition_index;type:bigint;primary_key;" json:"partition_index"`
+	Open      int64  `gorm:"column:open_epoch;type:bigint;primary_key;" json:"open_epoch"`
+
+	Status      string `gorm:"column:status;type:varchar(16);" json:"status"`
+	Height      int64  `gorm:"column:height;type:bigint;" json:"height"`
+	MessageUID  string `gorm:"column:message_uid;type:varchar(256);" json:"message_uid"`
+	Skipped     []byte `gorm:"column:skipped;type:bytea;" json:"skipped"`
+	ProvingTime int64  `gorm:"column:proving_time;type:bigint;" json:"proving_time"`
+	Error       string `gorm:"column:error;type:text;" json:"error"`
+
+	DeclaredFaults       []byte `gorm:"column:declared_faults;type:bytea;" json:"declared_faults"`
+	FaultsMessageUID     string `gorm:"column:faults_message_uid;type:varchar(256);" json:"faults_message_uid"`
+	DeclaredRecoveries   []byte `gorm:"column:declared_recoveries;type:bytea;" json:"declared_recoveries"`
+	RecoveriesMessageUID string `gorm:"column:recoveries_message_uid;type:varchar(256);" json:"recoveries_message_uid"`
+
+	UpdatedAt int64 `gorm:"column:updated_at;type:bigint;" json:"updated_at"`
+}
+
+func (partitionPoSt *partitionPoSt) TableName() string {
+	return "partition_posts"
+}
+
+func (partitionPoSt *partitionPoSt) PartitionPoSt() (*types.PartitionPoSt, error) {
+	record := &types.PartitionPoSt{
+		Deadline:             partitionPoSt.Deadline,
+		Partition:            partitionPoSt.Partition,
+		Open:                 abi.ChainEpoch(partitionPoSt.Open),
+		Status:               types.PoStStatus(partitionPoSt.Status),
+		Height:               abi.ChainEpoch(partitionPoSt.Height),
+		MessageUID:           partitionPoSt.MessageUID,
+		ProvingTime:          time.Duration(partitionPoSt.ProvingTime),
+		Error:                partitionPoSt.Error,
+		FaultsMessageUID:     partitionPoSt.FaultsMessageUID,
+		RecoveriesMessageUID: partitionPoSt.RecoveriesMessageUID,
+		UpdatedAt:            partitionPoSt.UpdatedAt,
+	}
+
+	for _, field := range []struct {
+		from []byte
+		to   *bitfield.BitField
+	}{
+		{partitionPoSt.Skipped, &record.Skipped},
+		{partitionPoSt.DeclaredFaults, &record.DeclaredFaults},
+		{partitionPoSt.DeclaredRecoveries, &record.DeclaredRecoveries},
+	} {
+		*field.to = bitfield.New()
+		if len(field.from) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(field.from, field.to); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+func fromPartitionPoSt(record *types.PartitionPoSt) (*partitionPoSt, error) {
+	row := &partitionPoSt{
+		Deadline:             record.Deadline,
+		Partition:            record.Partition,
+		Open:                 int64(record.Open),
+		Status:               string(record.Status),
+		Height:               int64(record.Height),
+		MessageUID:           record.MessageUID,
+		ProvingTime:          int64(record.ProvingTime),
+		Error:                record.Error,
+		FaultsMessageUID:     record.FaultsMessageUID,
+		RecoveriesMessageUID: record.RecoveriesMessageUID,
+		UpdatedAt:            record.UpdatedAt,
+	}
+
+	var err error
+	if row.Skipped, err = json.Marshal(record.Skipped); err != nil {
+		return nil, err
+	}
+	if row.DeclaredFaults, err = json.Marshal(record.DeclaredFaults); err != nil {
+		return nil, err
+	}
+	if row.DeclaredRecoveries, err = json.Marshal(record.DeclaredRecoveries); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+var _ repo.ProvingHistoryRepo = (*provingHistoryRepo)(nil)
+
+type provingHistoryRepo struct {
+	*gorm.DB
+}
+
+func newProvingHistoryRepo(db *gorm.DB) *provingHistoryRepo {
+	return &provingHistoryRepo{DB: db}
+}
+
+func (p *provingHistoryRepo) GetDeadlinePoSt(deadline uint64, open abi.ChainEpoch) ([]*types.PartitionPoSt, error) {
+	var rows []*partitionPoSt
+	err := p.DB.Table("partition_posts").Where("deadline = ? and open_epoch = ?", deadline, int64(open)).Order("partition_index").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return toPartitionPoSts(rows)
+}
+
+func (p *provingHistoryRepo) SavePartitionPoSt(records []*types.PartitionPoSt) error {
+	if len(records) == 0 {
+		return nil
+	}
+	rows := make([]*partitionPoSt, len(records))
+	for index, record := range records {
+		row, err := fromPartitionPoSt(record)
+		if err != nil {
+			return err
+		}
+		rows[index] = row
+	}
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(&rows, 500).Error
+	})
+}
+
+func (p *provingHistoryRepo) ListPartitionPoSt(from abi.ChainEpoch) ([]*types.PartitionPoSt, error) {
+	var rows []*partitionPoSt
+	err := p.DB.Table("partition_posts").Where("open_epoch >= ?", int64(from)).Order("open_epoch desc, partition_index").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return toPartitionPoSts(rows)
+}
+
+func toPartitionPoSts(rows []*partitionPoSt) ([]*types.PartitionPoSt, error) {
+	result := make([]*types.PartitionPoSt, len(rows))
+	for index, row := range rows {
+		record, err := row.PartitionPoSt()
+		if err != nil {
+			return nil, err
+		}
+		result[index] = record
+	}
+	return result, nil
+}
@@ -0,0 +1,14 @@
+package repo
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type DealAssignmentRepo interface {
+	SaveDealAssignment(assignment *types.DealAssignment) error
+	DeleteDealAssignment(dealID abi.DealID) error
+	// ListDealAssignments returns the pending assignments ordered by deal id
+	ListDealAssignments() ([]*types.DealAssignment, error)
+}
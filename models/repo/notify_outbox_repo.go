@@ -0,0 +1,16 @@
+package repo
+
+import (
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type NotifyOutboxRepo interface {
+	// Enqueue saves the messages in one transaction, ids are assigned by the db
+	Enqueue(msgs []*types.NotifyMessage) error
+	// ListDue returns at most limit messages with NextAttempt <= now, oldest first
+	ListDue(now int64, limit int) ([]*types.NotifyMessage, error)
+	// UpdateAttempt records a failed delivery
+	UpdateAttempt(id int64, attempts int, nextAttempt int64, lastErr string) error
+	Delete(id int64) error
+	Count() (int64, error)
+}
@@ -0,0 +1,18 @@
+package repo
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type ProvingHistoryRepo interface {
+	// GetDeadlinePoSt returns the history of the partitions of the deadline in
+	// the proving period where it opens at open
+	GetDeadlinePoSt(deadline uint64, open abi.ChainEpoch) ([]*types.PartitionPoSt, error)
+	// SavePartitionPoSt inserts or updates the history of the partitions in one transaction
+	SavePartitionPoSt(records []*types.PartitionPoSt) error
+	// ListPartitionPoSt returns the history of the deadlines opening at or
+	// after from, latest first
+	ListPartitionPoSt(from abi.ChainEpoch) ([]*types.PartitionPoSt, error)
+}
@@ -1,6 +1,9 @@
 package repo
 
 import (
+	"context"
+	"io"
+
 	"gorm.io/gorm"
 )
 
@@ -12,6 +15,15 @@ type Repo interface {
 	SectorInfoRepo() SectorInfoRepo
 	DealRefRepo() DealRefRepo
 	LogRepo() LogRepo
+	StorageIndexRepo() StorageIndexRepo
+	NotifyOutboxRepo() NotifyOutboxRepo
+	SectorHealthRepo() SectorHealthRepo
+	ProvingHistoryRepo() ProvingHistoryRepo
+	DealAssignmentRepo() DealAssignmentRepo
 	DbClose() error
 	AutoMigrate() error
+	// Backup writes a consistent snapshot of every table into out
+	Backup(ctx context.Context, out io.Writer) error
+	// Restore replaces every table with the content of a snapshot written by Backup
+	Restore(ctx context.Context, in io.Reader) error
 }
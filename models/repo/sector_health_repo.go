@@ -0,0 +1,14 @@
+package repo
+
+import (
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type SectorHealthRepo interface {
+	// SaveDeadlineHealth replaces the health of the sectors of the deadline in
+	// one transaction, the sectors not in healths are dropped
+	SaveDeadlineHealth(deadline uint64, healths []*types.SectorHealth) error
+	// ListSectorHealth returns the health of the sectors ordered by deadline,
+	// partition and sector number
+	ListSectorHealth() ([]*types.SectorHealth, error)
+}
@@ -0,0 +1,17 @@
+package repo
+
+import (
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type StorageIndexRepo interface {
+	GetAllStoragePaths() ([]*types.StoragePath, error)
+	// SaveStoragePath insert or update a storage path
+	SaveStoragePath(path *types.StoragePath) error
+	// ResetStoragePath saves the path and drops all sectors declared in it in one transaction
+	ResetStoragePath(path *types.StoragePath) error
+	GetAllSectorDecls() ([]*types.SectorDecl, error)
+	// SaveSectorDecl insert or update a sector declaration
+	SaveSectorDecl(decl *types.SectorDecl) error
+	DeleteSectorDecl(decl *types.SectorDecl) error
+}
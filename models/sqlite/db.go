@@ -1,7 +1,11 @@
 package sqlite
 
 import (
+	"context"
+	"io"
+
 	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/backup"
 	"github.com/filecoin-project/venus-sealer/models/repo"
 	"github.com/mitchellh/go-homedir"
 	"golang.org/x/xerrors"
@@ -37,6 +41,26 @@ func (d SqlLiteRepo) MetaDataRepo() repo.MetaDataRepo {
 	return newMetadataRepo(d.GetDb())
 }
 
+func (d SqlLiteRepo) StorageIndexRepo() repo.StorageIndexRepo {
+	return newStorageIndexRepo(d.GetDb())
+}
+
+func (d SqlLiteRepo) NotifyOutboxRepo() repo.NotifyOutboxRepo {
+	return newNotifyOutboxRepo(d.GetDb())
+}
+
+func (d SqlLiteRepo) SectorHealthRepo() repo.SectorHealthRepo {
+	return newSectorHealthRepo(d.GetDb())
+}
+
+func (d SqlLiteRepo) ProvingHistoryRepo() repo.ProvingHistoryRepo {
+	return newProvingHistoryRepo(d.GetDb())
+}
+
+func (d SqlLiteRepo) DealAssignmentRepo() repo.DealAssignmentRepo {
+	return newDealAssignmentRepo(d.GetDb())
+}
+
 func (d SqlLiteRepo) AutoMigrate() error {
 	err := d.GetDb().AutoMigrate(&dealRef{})
 	if err != nil {
@@ -68,9 +92,50 @@ func (d SqlLiteRepo) AutoMigrate() error {
 		return err
 	}
 
+	err = d.GetDb().AutoMigrate(&storagePath{})
+	if err != nil {
+		return err
+	}
+
+	err = d.GetDb().AutoMigrate(&sectorDecl{})
+	if err != nil {
+		return err
+	}
+
+	err = d.GetDb().AutoMigrate(&notifyMessage{})
+	if err != nil {
+		return err
+	}
+
+	err = d.GetDb().AutoMigrate(&sectorHealth{})
+	if err != nil {
+		return err
+	}
+
+	err = d.GetDb().AutoMigrate(&partitionPoSt{})
+	if err != nil {
+		return err
+	}
+
+	err = d.GetDb().AutoMigrate(&dealAssignment{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// tables are all models stored by the repo, in the order they are backed up and restored
+var tables = []backup.Table{&dealRef{}, &metadata{}, &Log{}, &sectorInfo{}, &workerCall{}, &workerState{}, &storagePath{}, &sectorDecl{}, &notifyMessage{}, &sectorHealth{}, &partitionPoSt{}, &dealAssignment{}}
+
+func (d SqlLiteRepo) Backup(ctx context.Context, out io.Writer) error {
+	return backup.Backup(ctx, d.GetDb(), out, tables...)
+}
+
+func (d SqlLiteRepo) Restore(ctx context.Context, in io.Reader) error {
+	return backup.Restore(ctx, d.GetDb(), in, tables...)
+}
+
 func (d SqlLiteRepo) GetDb() *gorm.DB {
 	return d.DB
 }
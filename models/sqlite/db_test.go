@@ -0,0 +1,359 @@
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+)
+
+func setupRepo(suffix string, t *testing.T) repo.Repo {
+	r, err := OpenSqlite(&config.SqliteConfig{Path: "./repo_" + suffix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func cleanRepo(suffix string, t *testing.T) {
+	for _, ext := range []string{"", "-shm", "-wal"} {
+		os.Remove("./repo_" + suffix + ext)
+	}
+}
+
+func TestSqlLiteRepo_BackupRestore(t *testing.T) {
+	src := setupRepo("backup_src", t)
+	defer cleanRepo("backup_src", t)
+	dst := setupRepo("backup_dst", t)
+	defer cleanRepo("backup_dst", t)
+
+	addr, _ := address.NewFromString("t01000")
+	if err := src.MetaDataRepo().SaveMinerAddress(addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.MetaDataRepo().SetStorageCounter(10); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.SectorInfoRepo().Save(&types.SectorInfo{SectorNumber: 1, State: types.Proving}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.LogRepo().Append(&types.Log{SectorNumber: 1, Kind: "event;sealing.SectorStart"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.DealRefRepo().Save(12, types.SealedRef{SectorID: 1, Offset: 111, Size: 222}, nil); err != nil {
+		t.Fatal(err)
+	}
+	callID := types.CallID{Sector: abi.SectorID{Miner: 1000, Number: 1}, ID: uuid.New()}
+	if err := src.WorkerCallRepo().Save("worker", callID, &types.Call{ID: callID, RetType: "xx", State: 2, Result: types.NewManyBytes([]byte{1, 2, 3})}); err != nil {
+		t.Fatal(err)
+	}
+
+	// rows already in the destination are replaced by the backup
+	if err := dst.SectorInfoRepo().Save(&types.SectorInfo{SectorNumber: 2, State: types.Removed}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.Backup(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Restore(context.Background(), bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	sectors, err := dst.SectorInfoRepo().GetAllSectorInfos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sectors) != 1 || sectors[0].SectorNumber != 1 || sectors[0].State != types.Proving {
+		t.Errorf("expect restored sector 1 in Proving but got %v", sectors)
+	}
+
+	counter, err := dst.MetaDataRepo().GetStorageCounter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter != 10 {
+		t.Errorf("expect storage counter %d but got %d", 10, counter)
+	}
+
+	logs, err := dst.LogRepo().List(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Kind != "event;sealing.SectorStart" {
+		t.Errorf("expect one restored log but got %v", logs)
+	}
+
+	refs, err := dst.DealRefRepo().Get(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs.Refs) != 1 || refs.Refs[0].Size != 222 {
+		t.Errorf("expect restored deal ref but got %v", refs.Refs)
+	}
+
+	call, err := dst.WorkerCallRepo().GetCallByCallID("worker", callID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(call.Result.Bytes(), []byte{1, 2, 3}) {
+		t.Errorf("expect call result [1 2 3] but got %v", call.Result.Bytes())
+	}
+}
+
+func TestSqlLiteRepo_RestoreCorrupted(t *testing.T) {
+	src := setupRepo("corrupt_src", t)
+	defer cleanRepo("corrupt_src", t)
+	dst := setupRepo("corrupt_dst", t)
+	defer cleanRepo("corrupt_dst", t)
+
+	if err := src.SectorInfoRepo().Save(&types.SectorInfo{SectorNumber: 1, State: types.Proving}); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.SectorInfoRepo().Save(&types.SectorInfo{SectorNumber: 2, State: types.Proving}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.Backup(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// flip a byte in the checksum
+	data[len(data)-1] ^= 0xff
+
+	if err := dst.Restore(context.Background(), bytes.NewReader(data)); err == nil {
+		t.Errorf("expect checksum error")
+	}
+
+	has, err := dst.SectorInfoRepo().HasSectorInfo(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !has {
+		t.Errorf("failed restore should be rolled back")
+	}
+}
+
+func TestSqlLiteRepo_NotifyOutbox(t *testing.T) {
+	r := setupRepo("notify", t)
+	defer cleanRepo("notify", t)
+
+	outbox := r.NotifyOutboxRepo()
+	msgs := []*types.NotifyMessage{
+		{Sink: "a", Topic: "sector/Proving", Payload: "{}", NextAttempt: 10},
+		{Sink: "b", Topic: "sector/Proving", Payload: "{}", NextAttempt: 20},
+	}
+	if err := outbox.Enqueue(msgs); err != nil {
+		t.Fatal(err)
+	}
+	if msgs[0].ID == 0 || msgs[1].ID == 0 || msgs[0].ID == msgs[1].ID {
+		t.Fatalf("expect distinct ids but got %d and %d", msgs[0].ID, msgs[1].ID)
+	}
+
+	due, err := outbox.ListDue(15, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].Sink != "a" {
+		t.Fatalf("expect the message of sink a to be due but got %v", due)
+	}
+
+	if err := outbox.UpdateAttempt(due[0].ID, 1, 30, "503"); err != nil {
+		t.Fatal(err)
+	}
+	due, err = outbox.ListDue(25, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].Sink != "b" {
+		t.Fatalf("expect the message of sink b to be due but got %v", due)
+	}
+
+	if err := outbox.Delete(due[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	due, err = outbox.ListDue(30, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].Attempts != 1 || due[0].LastErr != "503" {
+		t.Fatalf("expect the retried message of sink a but got %v", due)
+	}
+
+	count, err := outbox.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expect 1 message in the outbox but got %d", count)
+	}
+}
+
+func TestSqlLiteRepo_SectorHealth(t *testing.T) {
+	r := setupRepo("health", t)
+	defer cleanRepo("health", t)
+
+	healthRepo := r.SectorHealthRepo()
+	if err := healthRepo.SaveDeadlineHealth(1, []*types.SectorHealth{
+		{SectorNumber: 3, Deadline: 1, Partition: 0, Healthy: true, DeadlineOpen: 100, CheckedAt: 10},
+		{SectorNumber: 2, Deadline: 1, Partition: 0, Healthy: true, DeadlineOpen: 100, CheckedAt: 10},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := healthRepo.SaveDeadlineHealth(2, []*types.SectorHealth{
+		{SectorNumber: 1, Deadline: 2, Partition: 0, Healthy: true, DeadlineOpen: 160, CheckedAt: 10},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the next sweep of deadline 1 finds the files of sector 2 missing, sector 3 was terminated
+	if err := healthRepo.SaveDeadlineHealth(1, []*types.SectorHealth{
+		{SectorNumber: 2, Deadline: 1, Partition: 0, Reason: "file not found", FaultDeclared: true, DeadlineOpen: 2980, CheckedAt: 20},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	healths, err := healthRepo.ListSectorHealth()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(healths) != 2 {
+		t.Fatalf("expect 2 sectors but got %d", len(healths))
+	}
+	for i, sn := range []abi.SectorNumber{2, 1} {
+		if healths[i].SectorNumber != sn {
+			t.Errorf("expect sector %d at %d but got %d", sn, i, healths[i].SectorNumber)
+		}
+	}
+	if healths[0].Healthy || !healths[0].FaultDeclared || healths[0].Reason != "file not found" || healths[0].DeadlineOpen != 2980 {
+		t.Errorf("expect the last sweep of sector 2 but got %+v", healths[0])
+	}
+}
+
+func TestSqlLiteRepo_ProvingHistory(t *testing.T) {
+	r := setupRepo("history", t)
+	defer cleanRepo("history", t)
+
+	historyRepo := r.ProvingHistoryRepo()
+	if err := historyRepo.SavePartitionPoSt([]*types.PartitionPoSt{
+		{Deadline: 1, Partition: 0, Open: 60, Status: types.PoStProven, MessageUID: "a", Skipped: bitfield.NewFromSet([]uint64{3}), ProvingTime: time.Minute},
+		{Deadline: 1, Partition: 1, Open: 60, Status: types.PoStSkipped},
+		{Deadline: 1, Partition: 0, Open: 2940, Status: types.PoStPending, DeclaredFaults: bitfield.NewFromSet([]uint64{4, 5}), FaultsMessageUID: "b"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := historyRepo.GetDeadlinePoSt(1, 2940)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expect 1 partition but got %d", len(records))
+	}
+	faults, err := records[0].DeclaredFaults.All(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(faults) != 2 || records[0].FaultsMessageUID != "b" {
+		t.Errorf("expect the declared faults but got %v %s", faults, records[0].FaultsMessageUID)
+	}
+
+	// the proof of the deadline updates the record
+	records[0].Status = types.PoStFailed
+	records[0].Error = "boom"
+	if err := historyRepo.SavePartitionPoSt(records); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err = historyRepo.ListPartitionPoSt(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expect 3 partitions but got %d", len(records))
+	}
+	if records[0].Open != 2940 || records[0].Status != types.PoStFailed || records[0].Error != "boom" || records[0].FaultsMessageUID != "b" {
+		t.Errorf("expect the failed post of the latest period first but got %+v", records[0])
+	}
+	skipped, err := records[1].Skipped.All(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[1].Partition != 0 || records[1].ProvingTime != time.Minute || len(skipped) != 1 || skipped[0] != 3 {
+		t.Errorf("unexpected partition record %+v", records[1])
+	}
+
+	records, err = historyRepo.ListPartitionPoSt(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("expect 1 partition from epoch 100 but got %d", len(records))
+	}
+}
+
+func TestSqlLiteRepo_DealAssignment(t *testing.T) {
+	r := setupRepo("assignment", t)
+	defer cleanRepo("assignment", t)
+
+	pieceCID, err := cid.Decode("baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assignmentRepo := r.DealAssignmentRepo()
+	for _, dealID := range []abi.DealID{7, 3} {
+		if err := assignmentRepo.SaveDealAssignment(&types.DealAssignment{
+			DealID:       dealID,
+			SectorNumber: 10,
+			Offset:       2048,
+			PieceCID:     pieceCID,
+			Size:         1024,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a failed attempt updates the assignment
+	if err := assignmentRepo.SaveDealAssignment(&types.DealAssignment{
+		DealID:       3,
+		SectorNumber: 10,
+		Offset:       2048,
+		PieceCID:     pieceCID,
+		Size:         1024,
+		Attempts:     1,
+		LastError:    "boom",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := assignmentRepo.DeleteDealAssignment(7); err != nil {
+		t.Fatal(err)
+	}
+
+	assignments, err := assignmentRepo.ListDealAssignments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assignments) != 1 {
+		t.Fatalf("expect 1 assignment but got %d", len(assignments))
+	}
+	if assignments[0].DealID != 3 || assignments[0].Attempts != 1 || !assignments[0].PieceCID.Equals(pieceCID) {
+		t.Errorf("unexpected assignment %+v", assignments[0])
+	}
+}
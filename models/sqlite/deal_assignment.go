@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+	"github.com/ipfs/go-cid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type dealAssignment struct {
+	DealID       uint64 `gorm:"column:deal_id;type:unsigned bigint;primary_key;" json:"deal_id"`
+	SectorNumber uint64 `gorm:"column:sector_number;type:unsigned bigint;" json:"sector_number"`
+	Offset       uint64 `gorm:"column:piece_offset;type:unsigned bigint;" json:"piece_offset"`
+	PieceCID     string `gorm:"column:piece_cid;type:varchar(256);" json:"piece_cid"`
+	Size         uint64 `gorm:"column:size;type:unsigned bigint;" json:"size"`
+
+	Attempts  int    `gorm:"column:attempts;type:int;" json:"attempts"`
+	LastError string `gorm:"column:last_error;type:text;" json:"last_error"`
+
+	CreatedAt int64 `gorm:"column:created_at;type:bigint;" json:"created_at"`
+	UpdatedAt int64 `gorm:"column:updated_at;type:bigint;" json:"updated_at"`
+}
+
+func (dealAssignment *dealAssignment) TableName() string {
+	return "deal_assignments"
+}
+
+func (dealAssignment *dealAssignment) Assignment() (*types.DealAssignment, error) {
+	pieceCID, err := cid.Decode(dealAssignment.PieceCID)
+	if err != nil {
+		return nil, err
+	}
+	return &types.DealAssignment{
+		DealID:       abi.DealID(dealAssignment.DealID),
+		SectorNumber: abi.SectorNumber(dealAssignment.SectorNumber),
+		Offset:       abi.PaddedPieceSize(dealAssignment.Offset),
+		PieceCID:     pieceCID,
+		Size:         abi.PaddedPieceSize(dealAssignment.Size),
+		Attempts:     dealAssignment.Attempts,
+		LastError:    dealAssignment.LastError,
+		CreatedAt:    dealAssignment.CreatedAt,
+		UpdatedAt:    dealAssignment.UpdatedAt,
+	}, nil
+}
+
+var _ repo.DealAssignmentRepo = (*dealAssignmentRepo)(nil)
+
+type dealAssignmentRepo struct {
+	*gorm.DB
+}
+
+func newDealAssignmentRepo(db *gorm.DB) *dealAssignmentRepo {
+	return &dealAssignmentRepo{DB: db}
+}
+
+func (d *dealAssignmentRepo) SaveDealAssignment(assignment *types.DealAssignment) error {
+	row := &dealAssignment{
+		DealID:       uint64(assignment.DealID),
+		SectorNumber: uint64(assignment.SectorNumber),
+		Offset:       uint64(assignment.Offset),
+		PieceCID:     assignment.PieceCID.String(),
+		Size:         uint64(assignment.Size),
+		Attempts:     assignment.Attempts,
+		LastError:    assignment.LastError,
+		CreatedAt:    assignment.CreatedAt,
+		UpdatedAt:    assignment.UpdatedAt,
+	}
+	return d.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(row).Error
+}
+
+func (d *dealAssignmentRepo) DeleteDealAssignment(dealID abi.DealID) error {
+	return d.DB.Delete(&dealAssignment{}, "deal_id = ?", uint64(dealID)).Error
+}
+
+func (d *dealAssignmentRepo) ListDealAssignments() ([]*types.DealAssignment, error) {
+	var rows []*dealAssignment
+	err := d.DB.Table("deal_assignments").Order("deal_id").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*types.DealAssignment, len(rows))
+	for index, row := range rows {
+		result[index], err = row.Assignment()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
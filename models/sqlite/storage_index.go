@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"encoding/json"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
+	"github.com/filecoin-project/venus-sealer/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type storagePath struct {
+	Id         string `gorm:"column:id;type:varchar(128);primary_key;" json:"id"` // 主键
+	URLs       string `gorm:"column:urls;type:text;" json:"urls"`                 // json []string
+	Weight     uint64 `gorm:"column:weight;type:unsigned bigint;" json:"weight"`
+	MaxStorage uint64 `gorm:"column:max_storage;type:unsigned bigint;" json:"max_storage"`
+	CanSeal    bool   `gorm:"column:can_seal;type:bool;" json:"can_seal"`
+	CanStore   bool   `gorm:"column:can_store;type:bool;" json:"can_store"`
+	Groups     string `gorm:"column:storage_groups;type:text;" json:"storage_groups"` // json []string
+	AllowTo    string `gorm:"column:allow_to;type:text;" json:"allow_to"`             // json []string
+
+	Capacity    int64 `gorm:"column:capacity;type:bigint;" json:"capacity"`
+	Available   int64 `gorm:"column:available;type:bigint;" json:"available"`
+	FSAvailable int64 `gorm:"column:fs_available;type:bigint;" json:"fs_available"`
+	Reserved    int64 `gorm:"column:reserved;type:bigint;" json:"reserved"`
+	Max         int64 `gorm:"column:max;type:bigint;" json:"max"`
+	Used        int64 `gorm:"column:used;type:bigint;" json:"used"`
+}
+
+func fromStoragePath(path *types.StoragePath) (*storagePath, error) {
+	urls, err := json.Marshal(path.URLs)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := json.Marshal(path.Groups)
+	if err != nil {
+		return nil, err
+	}
+	allowTo, err := json.Marshal(path.AllowTo)
+	if err != nil {
+		return nil, err
+	}
+	return &storagePath{
+		Id:          path.ID,
+		URLs:        string(urls),
+		Weight:      path.Weight,
+		MaxStorage:  path.MaxStorage,
+		CanSeal:     path.CanSeal,
+		CanStore:    path.CanStore,
+		Groups:      string(groups),
+		AllowTo:     string(allowTo),
+		Capacity:    path.Stat.Capacity,
+		Available:   path.Stat.Available,
+		FSAvailable: path.Stat.FSAvailable,
+		Reserved:    path.Stat.Reserved,
+		Max:         path.Stat.Max,
+		Used:        path.Stat.Used,
+	}, nil
+}
+
+func (storagePath *storagePath) Path() (*types.StoragePath, error) {
+	path := &types.StoragePath{
+		ID:         storagePath.Id,
+		Weight:     storagePath.Weight,
+		MaxStorage: storagePath.MaxStorage,
+		CanSeal:    storagePath.CanSeal,
+		CanStore:   storagePath.CanStore,
+		Stat: fsutil.FsStat{
+			Capacity:    storagePath.Capacity,
+			Available:   storagePath.Available,
+			FSAvailable: storagePath.FSAvailable,
+			Reserved:    storagePath.Reserved,
+			Max:         storagePath.Max,
+			Used:        storagePath.Used,
+		},
+	}
+	if err := json.Unmarshal([]byte(storagePath.URLs), &path.URLs); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(storagePath.Groups), &path.Groups); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(storagePath.AllowTo), &path.AllowTo); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+func (storagePath *storagePath) TableName() string {
+	return "storage_paths"
+}
+
+type sectorDecl struct {
+	StorageId    string `gorm:"column:storage_id;type:varchar(128);primary_key;" json:"storage_id"`
+	MinerId      uint64 `gorm:"column:miner_id;type:unsigned bigint;primary_key;" json:"miner_id"`
+	SectorNumber uint64 `gorm:"column:sector_number;type:unsigned bigint;primary_key;" json:"sector_number"`
+	FileType     int    `gorm:"column:file_type;type:int;primary_key;" json:"file_type"`
+	Primary      bool   `gorm:"column:is_primary;type:bool;" json:"is_primary"`
+}
+
+func (sectorDecl *sectorDecl) TableName() string {
+	return "sector_decls"
+}
+
+var _ repo.StorageIndexRepo = (*storageIndexRepo)(nil)
+
+type storageIndexRepo struct {
+	*gorm.DB
+}
+
+func newStorageIndexRepo(db *gorm.DB) *storageIndexRepo {
+	return &storageIndexRepo{DB: db}
+}
+
+func (s *storageIndexRepo) GetAllStoragePaths() ([]*types.StoragePath, error) {
+	var storagePaths []*storagePath
+	err := s.DB.Table("storage_paths").Find(&storagePaths).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*types.StoragePath, len(storagePaths))
+	for index, p := range storagePaths {
+		path, err := p.Path()
+		if err != nil {
+			return nil, err
+		}
+		result[index] = path
+	}
+	return result, nil
+}
+
+func (s *storageIndexRepo) SaveStoragePath(path *types.StoragePath) error {
+	sp, err := fromStoragePath(path)
+	if err != nil {
+		return err
+	}
+	return s.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(sp).Error
+}
+
+func (s *storageIndexRepo) ResetStoragePath(path *types.StoragePath) error {
+	sp, err := fromStoragePath(path)
+	if err != nil {
+		return err
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(sp).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&sectorDecl{}, "storage_id=?", path.ID).Error
+	})
+}
+
+func (s *storageIndexRepo) GetAllSectorDecls() ([]*types.SectorDecl, error) {
+	var sectorDecls []*sectorDecl
+	err := s.DB.Table("sector_decls").Find(&sectorDecls).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*types.SectorDecl, len(sectorDecls))
+	for index, decl := range sectorDecls {
+		result[index] = &types.SectorDecl{
+			StorageID: decl.StorageId,
+			Sector: abi.SectorID{
+				Miner:  abi.ActorID(decl.MinerId),
+				Number: abi.SectorNumber(decl.SectorNumber),
+			},
+			FileType: decl.FileType,
+			Primary:  decl.Primary,
+		}
+	}
+	return result, nil
+}
+
+func (s *storageIndexRepo) SaveSectorDecl(decl *types.SectorDecl) error {
+	return s.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&sectorDecl{
+		StorageId:    decl.StorageID,
+		MinerId:      uint64(decl.Sector.Miner),
+		SectorNumber: uint64(decl.Sector.Number),
+		FileType:     decl.FileType,
+		Primary:      decl.Primary,
+	}).Error
+}
+
+func (s *storageIndexRepo) DeleteSectorDecl(decl *types.SectorDecl) error {
+	return s.DB.Delete(&sectorDecl{}, "storage_id=? AND miner_id=? AND sector_number=? AND file_type=?",
+		decl.StorageID, uint64(decl.Sector.Miner), uint64(decl.Sector.Number), decl.FileType).Error
+}
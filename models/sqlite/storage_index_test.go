@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"os"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
+	"github.com/filecoin-project/venus-sealer/types"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupStorageIndex(suffix string, t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("./storage_index_"+suffix), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&storagePath{}, &sectorDecl{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func cleanStorageIndex(suffix string, t *testing.T) {
+	os.Remove("./storage_index_" + suffix)
+}
+
+func Test_storageIndexRepo_SaveStoragePath(t *testing.T) {
+	db := setupStorageIndex("save_path", t)
+	defer cleanStorageIndex("save_path", t)
+	sRepo := newStorageIndexRepo(db)
+
+	path := &types.StoragePath{
+		ID:       "path-1",
+		URLs:     []string{"http://127.0.0.1:2345/remote"},
+		Weight:   10,
+		CanSeal:  true,
+		Groups:   []string{"grp1"},
+		AllowTo:  nil,
+		Stat:     fsutil.FsStat{Capacity: 100, Available: 50},
+		CanStore: false,
+	}
+	if err := sRepo.SaveStoragePath(path); err != nil {
+		t.Fatal(err)
+	}
+
+	path.URLs = append(path.URLs, "http://127.0.0.2:2345/remote")
+	path.Stat.Available = 40
+	if err := sRepo.SaveStoragePath(path); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := sRepo.GetAllStoragePaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("expect one storage path but got %d", len(paths))
+	}
+	if len(paths[0].URLs) != 2 || paths[0].Stat.Available != 40 || paths[0].Groups[0] != "grp1" || !paths[0].CanSeal {
+		t.Errorf("storage path not updated, got %+v", paths[0])
+	}
+}
+
+func Test_storageIndexRepo_SectorDecls(t *testing.T) {
+	db := setupStorageIndex("sector_decls", t)
+	defer cleanStorageIndex("sector_decls", t)
+	sRepo := newStorageIndexRepo(db)
+
+	sector := abi.SectorID{Miner: 1000, Number: 1}
+	decls := []*types.SectorDecl{
+		{StorageID: "path-1", Sector: sector, FileType: 1, Primary: false},
+		{StorageID: "path-1", Sector: sector, FileType: 2, Primary: true},
+		{StorageID: "path-2", Sector: sector, FileType: 2, Primary: false},
+	}
+	for _, decl := range decls {
+		if err := sRepo.SaveSectorDecl(decl); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// redeclare as primary
+	if err := sRepo.SaveSectorDecl(&types.SectorDecl{StorageID: "path-1", Sector: sector, FileType: 1, Primary: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sRepo.DeleteSectorDecl(&types.SectorDecl{StorageID: "path-1", Sector: sector, FileType: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := sRepo.GetAllSectorDecls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expect 2 sector decls but got %d", len(result))
+	}
+	for _, decl := range result {
+		if decl.StorageID == "path-1" && (decl.FileType != 1 || !decl.Primary) {
+			t.Errorf("unexpected decl %+v", decl)
+		}
+	}
+
+	if err := sRepo.ResetStoragePath(&types.StoragePath{ID: "path-2"}); err != nil {
+		t.Fatal(err)
+	}
+	result, err = sRepo.GetAllSectorDecls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].StorageID != "path-1" {
+		t.Errorf("expect decls of path-2 to be dropped but got %v", result)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/filecoin-project/venus/venus-shared/actors/builtin"
 	"github.com/filecoin-project/venus/venus-shared/api/market"
 
+	"github.com/docker/go-units"
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/ipfs/go-datastore"
 	"github.com/mitchellh/go-homedir"
@@ -30,9 +31,11 @@ import (
 	"github.com/filecoin-project/venus-sealer/config"
 	"github.com/filecoin-project/venus-sealer/journal"
 	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/notify"
 	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 	"github.com/filecoin-project/venus-sealer/service"
 	"github.com/filecoin-project/venus-sealer/storage"
 	"github.com/filecoin-project/venus-sealer/storage-sealing/sealiface"
@@ -60,6 +63,24 @@ func OpenFilesystemJournal(homeDir config.HomeDir, lc fx.Lifecycle, disabled jou
 	return jrnl, err
 }
 
+func NotifyDispatcher(mctx MetricsCtx, lc fx.Lifecycle, cfg *config.StorageMiner, repo repo.Repo, maddr types2.MinerAddress) (notify.Notifier, error) {
+	d, err := notify.NewDispatcher(cfg.Notify, repo.NotifyOutboxRepo(), address.Address(maddr))
+	if err != nil {
+		return nil, xerrors.Errorf("creating webhook notifier: %w", err)
+	}
+
+	ctx := LifecycleCtx(mctx, lc)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go d.Run(ctx)
+			return nil
+		},
+		OnStop: d.Close,
+	})
+
+	return d, nil
+}
+
 //auth
 
 const (
@@ -155,16 +176,33 @@ func SectorIDCounter(metaDataService *service.MetadataService) types2.SectorIDCo
 var WorkerCallsPrefix = datastore.NewKey("/worker/calls")
 var ManagerWorkPrefix = datastore.NewKey("/stmgr/calls")
 
+func StorageIndex(mctx MetricsCtx, repo repo.Repo) (*stores.Index, error) {
+	return stores.NewPersistentIndex(mctx, service.NewStorageIndexService(repo))
+}
+
 func LocalStorage(mctx MetricsCtx, lc fx.Lifecycle, ls stores.LocalStorage, si stores.SectorIndex, urls sectorstorage.URLs) (*stores.Local, error) {
 	ctx := LifecycleCtx(mctx, lc)
 	return stores.NewLocal(ctx, ls, si, urls)
 }
 
-func RemoteStorage(lstor *stores.Local, si stores.SectorIndex, sa sectorstorage.StorageAuth, sc sectorstorage.SealerConfig) *stores.Remote {
-	return stores.NewRemote(lstor, si, http.Header(sa), sc.ParallelFetchLimit, &stores.DefaultPartialFileHandler{})
+func RemoteStorage(lstor *stores.Local, si stores.SectorIndex, sa sectorstorage.StorageAuth, sc sectorstorage.SealerConfig) (*stores.Remote, error) {
+	limits := stores.FetchLimits{
+		Parallel:  sc.ParallelFetchLimit,
+		PerSource: sc.ParallelFetchPerSource,
+		DirFiles:  sc.ParallelFetchDirFiles,
+	}
+	if sc.FetchBandwidth != "" {
+		bw, err := units.RAMInBytes(sc.FetchBandwidth)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing FetchBandwidth: %w", err)
+		}
+		limits.Bandwidth = bw
+	}
+
+	return stores.NewRemote(lstor, si, http.Header(sa), limits, &stores.DefaultPartialFileHandler{}), nil
 }
 
-func SectorStorage(mctx MetricsCtx, lc fx.Lifecycle, lstor *stores.Local, stor *stores.Remote, ls stores.LocalStorage, si stores.SectorIndex, sc sectorstorage.SealerConfig, repo repo.Repo) (*sectorstorage.Manager, error) {
+func SectorStorage(mctx MetricsCtx, lc fx.Lifecycle, lstor *stores.Local, stor *stores.Remote, ls stores.LocalStorage, si stores.SectorIndex, sc sectorstorage.SealerConfig, repo repo.Repo, notifier notify.Notifier) (*sectorstorage.Manager, error) {
 	ctx := LifecycleCtx(mctx, lc)
 
 	wsts := service.NewWorkCallService(repo, "sealer")
@@ -175,6 +213,13 @@ func SectorStorage(mctx MetricsCtx, lc fx.Lifecycle, lstor *stores.Local, stor *
 		return nil, err
 	}
 
+	sst.OnWorkerDisconnect(func(wid storiface.WorkerID, info storiface.WorkerInfo) {
+		notifier.Notify(notify.TopicWorkerDisconnected, notify.WorkerEvent{
+			WorkerID: wid.String(),
+			Hostname: info.Hostname,
+		})
+	})
+
 	lc.Append(fx.Hook{
 		OnStop: sst.Close,
 	})
@@ -257,6 +302,7 @@ type StorageMinerParams struct {
 	MarketClient       market.IMarket
 	MetadataService    *service.MetadataService
 	LogService         *service.LogService
+	AssignmentService  *service.DealAssignmentService
 	SectorInfoService  *service.SectorInfoService
 	Sealer             sectorstorage.SectorManager
 	SectorIDCounter    types2.SectorIDCounter
@@ -264,6 +310,8 @@ type StorageMinerParams struct {
 	Prover             ffiwrapper.Prover
 	GetSealingConfigFn types2.GetSealingConfigFunc
 	Journal            journal.Journal
+	Notifier           notify.Notifier
+	Repo               repo.Repo
 	AddrSel            *storage.AddressSelector
 	NetworkParams      *config.NetParamsConfig
 	PieceStorageMgr    *piecestorage.PieceStorageManager `optional:"true"`
@@ -349,6 +397,7 @@ func StorageMiner(fc config.MinerFeeConfig) func(params StorageMinerParams) (*st
 			metadataService   = params.MetadataService
 			sectorinfoService = params.SectorInfoService
 			logService        = params.LogService
+			assignmentService = params.AssignmentService
 			mctx              = params.MetricsCtx
 			lc                = params.Lifecycle
 			api               = params.API
@@ -360,6 +409,7 @@ func StorageMiner(fc config.MinerFeeConfig) func(params StorageMinerParams) (*st
 			prover            = params.Prover
 			gsd               = params.GetSealingConfigFn
 			j                 = params.Journal
+			n                 = params.Notifier
 			as                = params.AddrSel
 			np                = params.NetworkParams
 			ps                = params.PieceStorageMgr
@@ -368,7 +418,7 @@ func StorageMiner(fc config.MinerFeeConfig) func(params StorageMinerParams) (*st
 
 		ctx := LifecycleCtx(mctx, lc)
 
-		sm, err := storage.NewMiner(api, ps, messager, marketClient, maddr, metadataService, sectorinfoService, logService, sealer, sc, verif, prover, gsd, fc, j, as, np)
+		sm, err := storage.NewMiner(api, ps, messager, marketClient, maddr, metadataService, sectorinfoService, logService, assignmentService, sealer, sc, verif, prover, gsd, fc, j, n, as, np)
 		if err != nil {
 			return nil, err
 		}
@@ -384,7 +434,7 @@ func StorageMiner(fc config.MinerFeeConfig) func(params StorageMinerParams) (*st
 	}
 }
 
-func WindowPostScheduler(fc config.MinerFeeConfig) func(params StorageMinerParams) (*storage.WindowPoStScheduler, error) {
+func WindowPostScheduler(fc config.MinerFeeConfig, pc config.ProvingConfig) func(params StorageMinerParams) (*storage.WindowPoStScheduler, error) {
 	return func(params StorageMinerParams) (*storage.WindowPoStScheduler, error) {
 		var (
 			mctx     = params.MetricsCtx
@@ -394,6 +444,8 @@ func WindowPostScheduler(fc config.MinerFeeConfig) func(params StorageMinerParam
 			sealer   = params.Sealer
 			verif    = params.Verifier
 			j        = params.Journal
+			n        = params.Notifier
+			r        = params.Repo
 			as       = params.AddrSel
 			np       = params.NetworkParams
 			maddr    = address.Address(params.Maddr)
@@ -401,7 +453,7 @@ func WindowPostScheduler(fc config.MinerFeeConfig) func(params StorageMinerParam
 
 		ctx := LifecycleCtx(mctx, lc)
 
-		fps, err := storage.NewWindowedPoStScheduler(api, messager, fc, as, sealer, verif, sealer, j, maddr, np)
+		fps, err := storage.NewWindowedPoStScheduler(api, messager, fc, as, sealer, verif, sealer, j, n, pc, r.SectorHealthRepo(), r.ProvingHistoryRepo(), maddr, np)
 		if err != nil {
 			return nil, err
 		}
@@ -496,6 +548,17 @@ func NewGetSealConfigFunc(r *config.StorageMiner) (types2.GetSealingConfigFunc,
 				DisableCollateralFallback:  cfg.Sealing.DisableCollateralFallback,
 
 				StartEpochSealingBuffer: abi.ChainEpoch(cfg.Dealmaking.StartEpochSealingBuffer),
+
+				DealPacking: sealiface.DealPackingConfig{
+					MaxDeals:          cfg.DealPacking.MaxDeals,
+					GroupByClient:     cfg.DealPacking.GroupByClient,
+					SeparateVerified:  cfg.DealPacking.SeparateVerified,
+					MaxEndEpochSpread: abi.ChainEpoch(cfg.DealPacking.MaxEndEpochSpread),
+					MinUtilisation:    cfg.DealPacking.MinUtilisation,
+					UrgentStartEpochs: abi.ChainEpoch(cfg.DealPacking.UrgentStartEpochs),
+					PollInterval:      time.Duration(cfg.DealPacking.PollInterval),
+					PollMaxBackoff:    time.Duration(cfg.DealPacking.PollMaxBackoff),
+				},
 			}
 		})
 		return
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+const (
+	// TopicWdPoStSucceeded is sent after the window post of a deadline got submitted
+	TopicWdPoStSucceeded = "wdpost/succeeded"
+	// TopicWdPoStFailed is sent when generating or submitting a window post failed
+	TopicWdPoStFailed = "wdpost/failed"
+	// TopicWdPoStUnhealthy is sent when the health sweep of a deadline found
+	// sectors with missing or unreadable files
+	TopicWdPoStUnhealthy = "wdpost/unhealthy"
+	// TopicWorkerDisconnected is sent when the sealer lost the connection to a worker
+	TopicWorkerDisconnected = "worker/disconnected"
+)
+
+// SectorTopic returns the topic of a sector entering the state, such as
+// "sector/Proving" or "sector/PreCommitFailed".
+func SectorTopic(state types.SectorState) string {
+	return "sector/" + string(state)
+}
+
+// Notifier sends events to the configured sinks, it must not block the caller.
+type Notifier interface {
+	Notify(topic string, data interface{})
+}
+
+// Nil is a Notifier dropping every event.
+var Nil Notifier = nilNotifier{}
+
+type nilNotifier struct{}
+
+func (nilNotifier) Notify(string, interface{}) {}
+
+// Event is the json body posted to a webhook.
+type Event struct {
+	ID    string          `json:"id"`
+	Topic string          `json:"topic"`
+	Miner address.Address `json:"miner"`
+	Time  time.Time       `json:"time"`
+	Data  interface{}     `json:"data"`
+}
+
+// SectorStateEvent is the data of the sector topics.
+type SectorStateEvent struct {
+	SectorNumber abi.SectorNumber        `json:"sector_number"`
+	SectorType   abi.RegisteredSealProof `json:"sector_type"`
+	From         types.SectorState       `json:"from"`
+	To           types.SectorState       `json:"to"`
+	Error        string                  `json:"error,omitempty"`
+}
+
+// WdPoStEvent is the data of the wdpost topics.
+type WdPoStEvent struct {
+	Deadline uint64         `json:"deadline"`
+	Open     abi.ChainEpoch `json:"open"`
+	Height   abi.ChainEpoch `json:"height"`
+	Error    string         `json:"error,omitempty"`
+	// Sectors are the unhealthy sectors of wdpost/unhealthy
+	Sectors []abi.SectorNumber `json:"sectors,omitempty"`
+}
+
+// WorkerEvent is the data of the worker topics.
+type WorkerEvent struct {
+	WorkerID string `json:"worker_id"`
+	Hostname string `json:"hostname"`
+}
@@ -0,0 +1,292 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/google/uuid"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+var log = logging.Logger("notify")
+
+const (
+	defaultRetryInterval = 30 * time.Second
+	defaultTimeout       = 10 * time.Second
+
+	// number of outbox messages loaded at once
+	deliverBatch = 100
+)
+
+type webhook struct {
+	cfg    config.WebhookConfig
+	client *http.Client
+}
+
+func (w *webhook) match(topic string) bool {
+	if len(w.cfg.Topics) == 0 {
+		return true
+	}
+	for _, pattern := range w.cfg.Topics {
+		if ok, _ := path.Match(pattern, topic); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *webhook) send(ctx context.Context, msg *types.NotifyMessage) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader([]byte(msg.Payload)))
+	if err != nil {
+		return xerrors.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+w.cfg.Token)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return xerrors.Errorf("webhook returned %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// Dispatcher is a Notifier posting events to webhooks. Events are written to
+// the outbox of the database first and deleted once the webhook accepted them,
+// failed deliveries are retried with an exponential backoff, also after a restart.
+type Dispatcher struct {
+	outbox repo.NotifyOutboxRepo
+	miner  address.Address
+
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+	maxAttempts      int
+
+	sinks map[string]*webhook
+	order []string
+
+	wake    chan struct{}
+	closing chan struct{}
+	closed  chan struct{}
+}
+
+var _ Notifier = (*Dispatcher)(nil)
+
+func NewDispatcher(cfg config.NotifyConfig, outbox repo.NotifyOutboxRepo, miner address.Address) (*Dispatcher, error) {
+	d := &Dispatcher{
+		outbox: outbox,
+		miner:  miner,
+
+		retryInterval:    time.Duration(cfg.RetryInterval),
+		maxRetryInterval: time.Duration(cfg.MaxRetryInterval),
+		maxAttempts:      cfg.MaxAttempts,
+
+		sinks: map[string]*webhook{},
+
+		wake:    make(chan struct{}, 1),
+		closing: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	if d.retryInterval <= 0 {
+		d.retryInterval = defaultRetryInterval
+	}
+	if d.maxRetryInterval < d.retryInterval {
+		d.maxRetryInterval = d.retryInterval
+	}
+
+	for _, hook := range cfg.Webhooks {
+		if hook.Name == "" {
+			return nil, xerrors.Errorf("webhook %s: name must be set", hook.URL)
+		}
+		if _, ok := d.sinks[hook.Name]; ok {
+			return nil, xerrors.Errorf("duplicate webhook name %s", hook.Name)
+		}
+		if hook.URL == "" {
+			return nil, xerrors.Errorf("webhook %s: url must be set", hook.Name)
+		}
+		for _, pattern := range hook.Topics {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, xerrors.Errorf("webhook %s: invalid topic %q: %w", hook.Name, pattern, err)
+			}
+		}
+
+		timeout := time.Duration(hook.Timeout)
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		d.sinks[hook.Name] = &webhook{
+			cfg:    hook,
+			client: &http.Client{Timeout: timeout},
+		}
+		d.order = append(d.order, hook.Name)
+	}
+
+	return d, nil
+}
+
+// Notify queues the event for every webhook subscribed to the topic.
+func (d *Dispatcher) Notify(topic string, data interface{}) {
+	var sinks []string
+	for _, name := range d.order {
+		if d.sinks[name].match(topic) {
+			sinks = append(sinks, name)
+		}
+	}
+	if len(sinks) == 0 {
+		return
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(&Event{
+		ID:    uuid.New().String(),
+		Topic: topic,
+		Miner: d.miner,
+		Time:  now,
+		Data:  data,
+	})
+	if err != nil {
+		log.Errorf("marshal event %s: %s", topic, err)
+		return
+	}
+
+	msgs := make([]*types.NotifyMessage, len(sinks))
+	for i, sink := range sinks {
+		msgs[i] = &types.NotifyMessage{
+			Sink:        sink,
+			Topic:       topic,
+			Payload:     string(payload),
+			NextAttempt: now.Unix(),
+			CreatedAt:   now.Unix(),
+		}
+	}
+	if err := d.outbox.Enqueue(msgs); err != nil {
+		log.Errorf("queue event %s: %s", topic, err)
+		return
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers the queued events until ctx is done or the dispatcher is closed.
+func (d *Dispatcher) Run(ctx context.Context) {
+	defer close(d.closed)
+
+	ticker := time.NewTicker(d.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue(ctx)
+
+		select {
+		case <-d.wake:
+		case <-ticker.C:
+		case <-d.closing:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) Close(ctx context.Context) error {
+	close(d.closing)
+	select {
+	case <-d.closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for {
+		msgs, err := d.outbox.ListDue(time.Now().Unix(), deliverBatch)
+		if err != nil {
+			log.Errorf("list notify outbox: %s", err)
+			return
+		}
+
+		for _, msg := range msgs {
+			select {
+			case <-d.closing:
+				return
+			case <-ctx.Done():
+				return
+			default:
+			}
+			d.deliver(ctx, msg)
+		}
+
+		if len(msgs) < deliverBatch {
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, msg *types.NotifyMessage) {
+	sink, ok := d.sinks[msg.Sink]
+	if !ok {
+		log.Warnf("dropping event %d for removed webhook %s", msg.ID, msg.Sink)
+		d.delete(msg)
+		return
+	}
+
+	err := sink.send(ctx, msg)
+	if err == nil {
+		d.delete(msg)
+		return
+	}
+
+	attempts := msg.Attempts + 1
+	if d.maxAttempts > 0 && attempts >= d.maxAttempts {
+		log.Errorf("dropping event %d (%s) for webhook %s after %d attempts: %s", msg.ID, msg.Topic, msg.Sink, attempts, err)
+		d.delete(msg)
+		return
+	}
+
+	next := time.Now().Add(d.backoff(attempts))
+	log.Warnf("delivering event %d (%s) to webhook %s failed, retry at %s: %s", msg.ID, msg.Topic, msg.Sink, next.Format(time.RFC3339), err)
+	if err := d.outbox.UpdateAttempt(msg.ID, attempts, next.Unix(), err.Error()); err != nil {
+		log.Errorf("update notify outbox %d: %s", msg.ID, err)
+	}
+}
+
+func (d *Dispatcher) delete(msg *types.NotifyMessage) {
+	if err := d.outbox.Delete(msg.ID); err != nil {
+		log.Errorf("delete notify outbox %d: %s", msg.ID, err)
+	}
+}
+
+// backoff returns the delay before the next attempt after the given number of failed ones
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.retryInterval
+	for i := 1; i < attempts && delay < d.maxRetryInterval; i++ {
+		delay *= 2
+	}
+	if delay > d.maxRetryInterval {
+		delay = d.maxRetryInterval
+	}
+	return delay
+}
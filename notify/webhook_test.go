@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type memOutbox struct {
+	lk   sync.Mutex
+	next int64
+	msgs map[int64]*types.NotifyMessage
+}
+
+func newMemOutbox() *memOutbox {
+	return &memOutbox{msgs: map[int64]*types.NotifyMessage{}}
+}
+
+func (m *memOutbox) Enqueue(msgs []*types.NotifyMessage) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	for _, msg := range msgs {
+		m.next++
+		msg.ID = m.next
+		cpy := *msg
+		m.msgs[msg.ID] = &cpy
+	}
+	return nil
+}
+
+func (m *memOutbox) ListDue(now int64, limit int) ([]*types.NotifyMessage, error) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	var out []*types.NotifyMessage
+	for _, msg := range m.msgs {
+		if msg.NextAttempt <= now {
+			cpy := *msg
+			out = append(out, &cpy)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *memOutbox) UpdateAttempt(id int64, attempts int, nextAttempt int64, lastErr string) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	if msg, ok := m.msgs[id]; ok {
+		msg.Attempts, msg.NextAttempt, msg.LastErr = attempts, nextAttempt, lastErr
+	}
+	return nil
+}
+
+func (m *memOutbox) Delete(id int64) error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	delete(m.msgs, id)
+	return nil
+}
+
+func (m *memOutbox) Count() (int64, error) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	return int64(len(m.msgs)), nil
+}
+
+func TestDispatcherDeliver(t *testing.T) {
+	var (
+		lk     sync.Mutex
+		events []Event
+		fail   = true
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		lk.Lock()
+		defer lk.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var evt Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&evt))
+		events = append(events, evt)
+	}))
+	defer srv.Close()
+
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	outbox := newMemOutbox()
+	d, err := NewDispatcher(config.NotifyConfig{
+		RetryInterval:    config.Duration(time.Second),
+		MaxRetryInterval: config.Duration(time.Minute),
+		Webhooks: []config.WebhookConfig{{
+			Name:   "ops",
+			URL:    srv.URL,
+			Token:  "secret",
+			Topics: []string{"sector/*Failed", TopicWorkerDisconnected},
+		}},
+	}, outbox, maddr)
+	require.NoError(t, err)
+
+	d.Notify(SectorTopic(types.Proving), SectorStateEvent{SectorNumber: 1, To: types.Proving})
+	d.Notify(SectorTopic(types.PreCommitFailed), SectorStateEvent{SectorNumber: 2, To: types.PreCommitFailed})
+	count, err := outbox.Count()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count, "only subscribed topics are queued")
+
+	// the webhook is down, the event stays in the outbox
+	d.deliverDue(context.Background())
+	due, err := outbox.ListDue(time.Now().Add(time.Hour).Unix(), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	require.Equal(t, 1, due[0].Attempts)
+	require.Contains(t, due[0].LastErr, "503")
+
+	lk.Lock()
+	fail = false
+	lk.Unlock()
+
+	// a new dispatcher picks up the queued event, like after a restart
+	d2, err := NewDispatcher(config.NotifyConfig{
+		RetryInterval: config.Duration(time.Second),
+		Webhooks:      []config.WebhookConfig{{Name: "ops", URL: srv.URL, Token: "secret"}},
+	}, outbox, maddr)
+	require.NoError(t, err)
+	require.NoError(t, outbox.UpdateAttempt(due[0].ID, 1, 0, ""))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d2.Run(ctx)
+	d2.Notify(TopicWdPoStFailed, WdPoStEvent{Deadline: 3, Error: "boom"})
+
+	require.Eventually(t, func() bool {
+		count, err := outbox.Count()
+		return err == nil && count == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, d2.Close(context.Background()))
+
+	lk.Lock()
+	defer lk.Unlock()
+	require.Len(t, events, 2)
+	require.Equal(t, SectorTopic(types.PreCommitFailed), events[0].Topic)
+	require.Equal(t, maddr, events[0].Miner)
+	require.Equal(t, TopicWdPoStFailed, events[1].Topic)
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	d, err := NewDispatcher(config.NotifyConfig{
+		RetryInterval:    config.Duration(10 * time.Second),
+		MaxRetryInterval: config.Duration(time.Minute),
+	}, newMemOutbox(), address.Undef)
+	require.NoError(t, err)
+
+	require.Equal(t, 10*time.Second, d.backoff(1))
+	require.Equal(t, 20*time.Second, d.backoff(2))
+	require.Equal(t, 40*time.Second, d.backoff(3))
+	require.Equal(t, time.Minute, d.backoff(4))
+	require.Equal(t, time.Minute, d.backoff(100))
+}
+
+func TestDispatcherConfig(t *testing.T) {
+	_, err := NewDispatcher(config.NotifyConfig{Webhooks: []config.WebhookConfig{
+		{Name: "a", URL: "http://localhost"},
+		{Name: "a", URL: "http://localhost"},
+	}}, newMemOutbox(), address.Undef)
+	require.Error(t, err)
+
+	_, err = NewDispatcher(config.NotifyConfig{Webhooks: []config.WebhookConfig{
+		{Name: "a", URL: "http://localhost", Topics: []string{"sector/["}},
+	}}, newMemOutbox(), address.Undef)
+	require.Error(t, err)
+}
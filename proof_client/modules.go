@@ -23,13 +23,16 @@ func NewGatewayFullnodes(lc fx.Lifecycle, cfg *config.RegisterProofConfig) (Gate
 		}
 	}
 
-	if len(clients) == 0 {
+	if len(clients) == 0 && !cfg.Native {
 		return nil, xerrors.Errorf("must have a GateWayNode, check 'RegisterProof' configuration")
 	}
 	return clients, nil
 }
 
 func StartProofEvent(lc fx.Lifecycle, clients GatewayClientSets, prover storage.WinningPoStProver, mAddr types.MinerAddress) error {
+	if len(clients) == 0 {
+		log.Info("no gateway configured, winning PoSt is served by ComputeProof on the sealer API only")
+	}
 	for _, client := range clients {
 		proofEvent := ProofEvent{
 			prover: prover,
@@ -17,6 +17,7 @@ import (
 
 	"github.com/filecoin-project/venus-sealer/api"
 	"github.com/filecoin-project/venus-sealer/api/impl"
+	"github.com/filecoin-project/venus-sealer/metrics"
 )
 
 // ServeRPC serves an HTTP handler over the supplied listen multiaddr.
@@ -62,12 +63,16 @@ func MinerHandler(mapi api.StorageMiner, permissioned bool) (http.Handler, error
 	mux.Handle("/rpc/v0", rpcServer)
 	mux.PathPrefix("/remote").HandlerFunc(mapi.(*impl.StorageMinerAPI).ServeRemote)
 
+	exporter, err := metrics.Exporter()
+	if err != nil {
+		return nil, err
+	}
+
 	// debugging
-	// m.Handle("/debug/metrics", metrics.Exporter())
 	m.PathPrefix("/").Handler(http.DefaultServeMux) // pprof
 
 	if !permissioned {
-		return rpcServer, nil
+		return metrics.WithoutAuth(exporter, rpcServer), nil
 	}
 
 	ah := &auth.Handler{
@@ -75,6 +80,6 @@ func MinerHandler(mapi api.StorageMiner, permissioned bool) (http.Handler, error
 		Next:   mux.ServeHTTP,
 	}
 
-	return ah, nil
+	return metrics.WithoutAuth(exporter, ah), nil
 }
 
@@ -3,6 +3,8 @@
 
 package sectorstorage
 
+import "golang.org/x/xerrors"
+
 func cgroupV1Mem() (memoryMax, memoryUsed, swapMax, swapUsed uint64, err error) {
 	return 0, 0, 0, 0, nil
 }
@@ -10,3 +12,29 @@ func cgroupV1Mem() (memoryMax, memoryUsed, swapMax, swapUsed uint64, err error)
 func cgroupV2Mem() (memoryMax, memoryUsed, swapMax, swapUsed uint64, err error) {
 	return 0, 0, 0, 0, nil
 }
+
+// TaskCgroups creates the cgroup v2 groups of the tasks of a worker, which is
+// only supported on linux.
+type TaskCgroups struct{}
+
+func NewTaskCgroups() (*TaskCgroups, error) {
+	return nil, xerrors.Errorf("task cgroups are only supported on linux")
+}
+
+type taskCgroup struct{}
+
+func (tc *TaskCgroups) newTask(name string, memoryMax, threads uint64) (*taskCgroup, error) {
+	return nil, xerrors.Errorf("task cgroups are only supported on linux")
+}
+
+func (t *taskCgroup) add(pid int) error {
+	return xerrors.Errorf("task cgroups are only supported on linux")
+}
+
+func (t *taskCgroup) oomKills() (uint64, error) {
+	return 0, nil
+}
+
+func (t *taskCgroup) remove() error {
+	return nil
+}
@@ -6,12 +6,19 @@ package sectorstorage
 import (
 	"bufio"
 	"bytes"
+	"fmt"
+	"io/ioutil"
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/containerd/cgroups"
 	cgroupv2 "github.com/containerd/cgroups/v2"
+	"golang.org/x/xerrors"
 )
 
 func cgroupV2MountPoint() (string, error) {
@@ -115,3 +122,230 @@ func cgroupV2Mem() (memoryMax, memoryUsed, swapMax, swapUsed uint64, err error)
 
 	return memoryMax, memoryUsed, swapMax, swapUsed, nil
 }
+
+// cpuPeriod is the cpu.max period of the task groups, in microseconds
+const cpuPeriod = 100000
+
+// TaskCgroups creates the cgroup v2 groups of the tasks of a worker, under the
+// group the worker was started in. As only groups without processes can
+// enable controllers for their children, the worker process is moved to a
+// "worker" leaf group next to the task groups.
+//
+// The tasks are pinned to CPUs of the CPU set of the worker, the least used
+// ones first, so that the tasks running at the same time don't share CPUs
+// while there are enough of them.
+type TaskCgroups struct {
+	mountpoint string
+	group      string
+
+	lk   sync.Mutex
+	cpus []int
+	used map[int]int // tasks pinned by CPU
+}
+
+func NewTaskCgroups() (*TaskCgroups, error) {
+	mp, err := cgroupV2MountPoint()
+	if err != nil {
+		return nil, xerrors.Errorf("finding the cgroup v2 mount point: %w", err)
+	}
+	group, err := cgroupv2.PidGroupPath(os.Getpid())
+	if err != nil {
+		return nil, xerrors.Errorf("getting the cgroup of the worker: %w", err)
+	}
+	tc := &TaskCgroups{mountpoint: mp, group: group, used: map[int]int{}}
+
+	controllers, err := ioutil.ReadFile(tc.path("cgroup.controllers"))
+	if err != nil {
+		return nil, xerrors.Errorf("reading the controllers of cgroup %s: %w", group, err)
+	}
+	for _, c := range []string{"memory", "cpu", "cpuset"} {
+		if !strings.Contains(" "+strings.TrimSpace(string(controllers))+" ", " "+c+" ") {
+			return nil, xerrors.Errorf("the %s controller isn't available in cgroup %s", c, group)
+		}
+	}
+
+	if err := os.MkdirAll(tc.path("worker"), 0755); err != nil {
+		return nil, xerrors.Errorf("creating the worker leaf cgroup: %w", err)
+	}
+	leaf, err := cgroupv2.LoadManager(mp, filepath.Join(group, "worker"))
+	if err != nil {
+		return nil, xerrors.Errorf("loading the worker leaf cgroup: %w", err)
+	}
+	if err := leaf.AddProc(uint64(os.Getpid())); err != nil {
+		return nil, xerrors.Errorf("moving the worker to its leaf cgroup: %w", err)
+	}
+
+	// the groups of the tasks running when the worker was stopped
+	stale, _ := filepath.Glob(tc.path("task-*"))
+	for _, dir := range stale {
+		if err := os.Remove(dir); err != nil {
+			log.Warnf("removing stale task cgroup %s: %s", dir, err)
+		}
+	}
+
+	if err := ioutil.WriteFile(tc.path("cgroup.subtree_control"), []byte("+memory +cpu +cpuset"), 0); err != nil {
+		return nil, xerrors.Errorf("enabling the memory, cpu and cpuset controllers of cgroup %s, it must only hold the worker process: %w", group, err)
+	}
+
+	cpus, err := ioutil.ReadFile(tc.path("cpuset.cpus.effective"))
+	if err != nil {
+		return nil, xerrors.Errorf("reading the CPU set of cgroup %s: %w", group, err)
+	}
+	tc.cpus, err = parseCPUList(strings.TrimSpace(string(cpus)))
+	if err != nil {
+		return nil, xerrors.Errorf("parsing the CPU set of cgroup %s: %w", group, err)
+	}
+	if len(tc.cpus) == 0 {
+		return nil, xerrors.Errorf("cgroup %s has no CPU", group)
+	}
+
+	log.Infow("running tasks in their own cgroup", "group", group)
+	return tc, nil
+}
+
+func (tc *TaskCgroups) path(elem ...string) string {
+	return filepath.Join(append([]string{tc.mountpoint, tc.group}, elem...)...)
+}
+
+// assignCPUs picks the CPUs a task with threads threads is pinned to, the
+// least used ones first. All the CPUs are used when threads is 0 or more than
+// the CPU set.
+func (tc *TaskCgroups) assignCPUs(threads uint64) []int {
+	tc.lk.Lock()
+	defer tc.lk.Unlock()
+
+	cpus := append([]int{}, tc.cpus...)
+	sort.SliceStable(cpus, func(i, j int) bool {
+		return tc.used[cpus[i]] < tc.used[cpus[j]]
+	})
+	if threads > 0 && threads < uint64(len(cpus)) {
+		cpus = cpus[:threads]
+	}
+	sort.Ints(cpus)
+
+	for _, cpu := range cpus {
+		tc.used[cpu]++
+	}
+	return cpus
+}
+
+func (tc *TaskCgroups) releaseCPUs(cpus []int) {
+	tc.lk.Lock()
+	defer tc.lk.Unlock()
+
+	for _, cpu := range cpus {
+		tc.used[cpu]--
+		if tc.used[cpu] <= 0 {
+			delete(tc.used, cpu)
+		}
+	}
+}
+
+type taskCgroup struct {
+	mgr  *cgroupv2.Manager
+	tc   *TaskCgroups
+	cpus []int
+}
+
+// newTask creates the group of a task, its memory is limited to memoryMax
+// bytes, 0 for no limit, and it is pinned to threads CPUs with as much cpu
+// time.
+func (tc *TaskCgroups) newTask(name string, memoryMax, threads uint64) (*taskCgroup, error) {
+	dir := tc.path(name)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, xerrors.Errorf("creating task cgroup: %w", err)
+	}
+
+	cpus := tc.assignCPUs(threads)
+	fail := func(err error) (*taskCgroup, error) {
+		tc.releaseCPUs(cpus)
+		_ = os.Remove(dir)
+		return nil, err
+	}
+
+	memMax := "max"
+	if memoryMax > 0 {
+		memMax = strconv.FormatUint(memoryMax, 10)
+	}
+	// cpuset.cpus is set first, the cpu quota matches the CPUs of the task
+	for _, limit := range []struct{ file, value string }{
+		{"cpuset.cpus", formatCPUList(cpus)},
+		{"cpu.max", fmt.Sprintf("%d %d", uint64(len(cpus))*cpuPeriod, cpuPeriod)},
+		{"memory.max", memMax},
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, limit.file), []byte(limit.value), 0); err != nil {
+			return fail(xerrors.Errorf("setting %s of task cgroup %s: %w", limit.file, name, err))
+		}
+	}
+
+	mgr, err := cgroupv2.LoadManager(tc.mountpoint, filepath.Join(tc.group, name))
+	if err != nil {
+		return fail(xerrors.Errorf("loading task cgroup: %w", err))
+	}
+	return &taskCgroup{mgr: mgr, tc: tc, cpus: cpus}, nil
+}
+
+func (t *taskCgroup) add(pid int) error {
+	return t.mgr.AddProc(uint64(pid))
+}
+
+// oomKills returns the number of processes of the group killed by the OOM
+// killer
+func (t *taskCgroup) oomKills() (uint64, error) {
+	stats, err := t.mgr.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if stats.MemoryEvents == nil {
+		return 0, nil
+	}
+	return stats.MemoryEvents.OomKill, nil
+}
+
+func (t *taskCgroup) remove() error {
+	t.tc.releaseCPUs(t.cpus)
+	return t.mgr.Delete()
+}
+
+// parseCPUList parses a cgroup CPU list, eg. "0-3,8,10-11"
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	if list == "" {
+		return cpus, nil
+	}
+	for _, part := range strings.Split(list, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, xerrors.Errorf("parsing CPU list %q: %w", list, err)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, xerrors.Errorf("parsing CPU list %q: %w", list, err)
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// formatCPUList formats sorted CPUs as a cgroup CPU list
+func formatCPUList(cpus []int) string {
+	var parts []string
+	for i := 0; i < len(cpus); {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(cpus[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", cpus[i], cpus[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
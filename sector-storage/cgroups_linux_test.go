@@ -0,0 +1,38 @@
+//go:build linux
+// +build linux
+
+package sectorstorage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPUList(t *testing.T) {
+	cpus, err := parseCPUList("0-3,8,10-11")
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1, 2, 3, 8, 10, 11}, cpus)
+	require.Equal(t, "0-3,8,10-11", formatCPUList(cpus))
+
+	_, err = parseCPUList("0-x")
+	require.Error(t, err)
+}
+
+func TestAssignCPUs(t *testing.T) {
+	tc := &TaskCgroups{cpus: []int{0, 1, 2, 3}, used: map[int]int{}}
+
+	a := tc.assignCPUs(2)
+	require.Equal(t, []int{0, 1}, a)
+	b := tc.assignCPUs(1)
+	require.Equal(t, []int{2}, b)
+
+	// all the CPUs, shared with the other tasks
+	all := tc.assignCPUs(0)
+	require.Equal(t, []int{0, 1, 2, 3}, all)
+	tc.releaseCPUs(all)
+
+	// the least used CPUs come first
+	tc.releaseCPUs(a)
+	require.Equal(t, []int{0, 1, 3}, tc.assignCPUs(3))
+}
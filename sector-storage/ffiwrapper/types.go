@@ -28,6 +28,7 @@ type StorageSealer interface {
 
 type Storage interface {
 	storage.Prover
+	PartitionProver
 	StorageSealer
 
 	UnsealPiece(ctx context.Context, sector storage.SectorRef, offset storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, commd cid.Cid) error
@@ -44,6 +45,17 @@ type Verifier interface {
 	GenerateWinningPoStSectorChallenge(context.Context, abi.RegisteredPoStProof, abi.ActorID, abi.PoStRandomness, uint64) ([]uint64, error)
 }
 
+// PartitionProver proves a window PoSt batch partition by partition, the
+// partition proofs are merged into the single proof of the batch.
+type PartitionProver interface {
+	// GenerateWindowPoStPartition proves the sectors of the partition at
+	// partitionIdx of the batch, the skipped sectors are returned with an error
+	GenerateWindowPoStPartition(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (proof.PoStProof, []abi.SectorID, error)
+	// MergeWindowPoStPartitionProofs merges the proofs of all the partitions
+	// of a batch, ordered by partition index
+	MergeWindowPoStPartitionProofs(proofType abi.RegisteredPoStProof, partitionProofs []proof.PoStProof) (proof.PoStProof, error)
+}
+
 // Prover contains cheap proving-related methods
 type Prover interface {
 	// TODO: move GenerateWinningPoStSectorChallenge from the Verifier interface to here
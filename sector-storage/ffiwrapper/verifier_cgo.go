@@ -60,6 +60,63 @@ func (sb *Sealer) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, s
 	return proof, faultyIDs, err
 }
 
+func (sb *Sealer) GenerateWindowPoStPartition(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (proof.PoStProof, []abi.SectorID, error) {
+	randomness[31] &= 0x3f
+	privsectors, skipped, done, err := sb.pubExtendedSectorToPriv(ctx, minerID, sectorInfo, nil, abi.RegisteredSealProof.RegisteredWindowPoStProof)
+	if err != nil {
+		return proof.PoStProof{}, nil, xerrors.Errorf("gathering sector info: %w", err)
+	}
+
+	defer done()
+
+	if len(skipped) > 0 {
+		return proof.PoStProof{}, skipped, xerrors.Errorf("pubSectorToPriv skipped some sectors")
+	}
+
+	ppt, err := sectorInfo[0].SealProof.RegisteredWindowPoStProof()
+	if err != nil {
+		return proof.PoStProof{}, nil, err
+	}
+
+	sectors := privsectors.Values()
+	sectorNums := make([]abi.SectorNumber, len(sectors))
+	for i, s := range sectors {
+		sectorNums[i] = s.SectorNumber
+	}
+	challenges, err := ffi.GeneratePoStFallbackSectorChallenges(ppt, minerID, randomness, sectorNums)
+	if err != nil {
+		return proof.PoStProof{}, nil, xerrors.Errorf("generating fallback challenges: %w", err)
+	}
+
+	vanillas := make([][]byte, len(sectors))
+	for i, s := range sectors {
+		vanillas[i], err = ffi.GenerateSingleVanillaProof(s, challenges.Challenges[s.SectorNumber])
+		if err != nil {
+			log.Warnw("failed to generate vanilla proof, skipping", "sector", s.SectorNumber, "error", err)
+			skipped = append(skipped, abi.SectorID{Miner: minerID, Number: s.SectorNumber})
+		}
+	}
+	if len(skipped) > 0 {
+		return proof.PoStProof{}, skipped, xerrors.Errorf("generating vanilla proofs skipped some sectors")
+	}
+
+	out, err := sb.GenerateWindowPoStWithVanilla(ctx, ppt, minerID, randomness, vanillas, partitionIdx)
+	return out, nil, err
+}
+
+func (sb *Sealer) MergeWindowPoStPartitionProofs(proofType abi.RegisteredPoStProof, partitionProofs []proof.PoStProof) (proof.PoStProof, error) {
+	pps := make([]ffi.PartitionProof, len(partitionProofs))
+	for i, pp := range partitionProofs {
+		pps[i] = ffi.PartitionProof(pp)
+	}
+
+	out, err := ffi.MergeWindowPoStPartitionProofs(proofType, pps)
+	if err != nil {
+		return proof.PoStProof{}, err
+	}
+	return *out, nil
+}
+
 func (sb *Sealer) pubExtendedSectorToPriv(ctx context.Context, mid abi.ActorID, sectorInfo []proof.ExtendedSectorInfo, faults []abi.SectorNumber, rpt func(abi.RegisteredSealProof) (abi.RegisteredPoStProof, error)) (ffi.SortedPrivateSectorInfo, []abi.SectorID, func(), error) {
 	fmap := map[abi.SectorNumber]struct{}{} //nil
 	for _, fault := range faults {
@@ -17,6 +17,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/proof"
 	"github.com/filecoin-project/go-statestore"
 	"github.com/filecoin-project/specs-storage/storage"
 
@@ -36,9 +37,13 @@ type URLs []string
 type Worker interface {
 	storiface.WorkerCalls
 
+	// GenerateWindowPoSt proves the partition at partitionIdx of a window PoSt
+	// batch, unlike the sealing calls it returns the result directly
+	GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (storiface.WindowPoStResult, error)
+
 	TaskTypes(context.Context) (map[types.TaskType]struct{}, error)
 
-	TaskNumbers(context.Context) (string, error)
+	Capacity(context.Context) (storiface.WorkerCapacity, error)
 
 	// Returns paths accessible to the worker
 	Paths(context.Context) ([]stores.StoragePath, error)
@@ -69,6 +74,7 @@ type Manager struct {
 	sched *scheduler
 
 	storage.Prover
+	partitionProver ffiwrapper.PartitionProver // merges the window PoSt partition proofs of workers
 
 	workLk sync.Mutex
 	work   statestore.StateStore
@@ -102,6 +108,16 @@ const (
 
 type SealerConfig struct {
 	ParallelFetchLimit int
+	// ParallelFetchPerSource is the number of fetches running at the same time
+	// from a single storage, or host when the storage isn't known, 0 for no
+	// limit
+	ParallelFetchPerSource int
+	// FetchBandwidth is the bandwidth used by all the fetches, eg. "100MiB"
+	// for 100MiB per second, empty for no limit
+	FetchBandwidth string
+	// ParallelFetchDirFiles is the number of files of a cache directory
+	// fetched at the same time, from servers publishing directory manifests
+	ParallelFetchDirFiles int
 
 	// Local worker config
 	AllowAddPiece            bool
@@ -117,6 +133,10 @@ type SealerConfig struct {
 	// to use when evaluating tasks against this worker. An empty value defaults
 	// to "hardware".
 	ResourceFiltering ResourceFilteringStrategy
+
+	// SchedulingPolicy selects how tasks are assigned to workers, one of
+	// "default", "spread", "pack" or "pc1-affinity". Empty means "default".
+	SchedulingPolicy string
 }
 
 type StorageAuth http.Header
@@ -130,6 +150,11 @@ func New(ctx context.Context, lstor *stores.Local, stor *stores.Remote, ls store
 		return nil, xerrors.Errorf("creating prover instance: %w", err)
 	}
 
+	policy, err := NewSchedulingPolicy(sc.SchedulingPolicy)
+	if err != nil {
+		return nil, err
+	}
+
 	m := &Manager{
 		ls:         ls,
 		storage:    stor,
@@ -137,9 +162,10 @@ func New(ctx context.Context, lstor *stores.Local, stor *stores.Remote, ls store
 		remoteHnd:  &stores.FetchHandler{Local: lstor, PfHandler: &stores.DefaultPartialFileHandler{}},
 		index:      si,
 
-		sched: newScheduler(),
+		sched: newScheduler(policy),
 
-		Prover: prover,
+		Prover:          prover,
+		partitionProver: prover,
 
 		work:       mss,
 		callToWork: map[types.CallID]types.WorkID{},
@@ -217,6 +243,14 @@ func (m *Manager) AddWorker(ctx context.Context, w Worker) error {
 	return m.sched.runWorker(ctx, w)
 }
 
+// OnWorkerDisconnect sets a callback invoked after a worker got dropped by the
+// scheduler, it isn't called for the workers closed with the manager.
+func (m *Manager) OnWorkerDisconnect(cb func(wid storiface.WorkerID, info storiface.WorkerInfo)) {
+	m.sched.workersLk.Lock()
+	m.sched.workerDisconnected = cb
+	m.sched.workersLk.Unlock()
+}
+
 func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	m.remoteHnd.ServeHTTP(w, r)
 }
@@ -225,9 +259,29 @@ func schedNop(context.Context, Worker) error {
 	return nil
 }
 
-func (m *Manager) schedFetch(sector storage.SectorRef, ft storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode) func(context.Context, Worker) error {
+// OOMRetries is how many times a task killed by the OOM killer on its worker
+// is scheduled again before the error is returned to the sealing state machine
+var OOMRetries = 3
+
+// retryOOM runs a task again when its worker reports it went over the memory
+// limit of its cgroup, the scheduler may then pick another worker.
+func (m *Manager) retryOOM(ctx context.Context, tt types.TaskType, sector storage.SectorRef, run func() error) error {
+	for i := 0; ; i++ {
+		err := run()
+
+		var cerr *storiface.CallError
+		if i >= OOMRetries || !xerrors.As(err, &cerr) || cerr.Code != storiface.ErrTempOOM || ctx.Err() != nil {
+			return err
+		}
+		log.Warnf("%s of sector %d ran out of memory, scheduling it again (%d/%d): %s", tt, sector.ID.Number, i+1, OOMRetries, err)
+	}
+}
+
+// schedFetch fetches the files of the sector to the worker before it runs a
+// task of the type, see stores.FetchPriority.
+func (m *Manager) schedFetch(tt types.TaskType, sector storage.SectorRef, ft storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode) func(context.Context, Worker) error {
 	return func(ctx context.Context, worker Worker) error {
-		_, err := m.waitSimpleCall(ctx)(worker.Fetch(ctx, sector, ft, ptype, am))
+		_, err := m.waitSimpleCall(ctx)(worker.Fetch(ctx, sector, ft, ptype, am, stores.FetchPriority(ctx, tt)))
 		return err
 	}
 }
@@ -249,8 +303,8 @@ func (m *Manager) SectorsUnsealPiece(ctx context.Context, sector storage.SectorR
 	// put it in the sealing scratch space.
 	sealFetch := func(ctx context.Context, worker Worker) error {
 		log.Debugf("copy sealed/cache sector data for sector %d", sector.ID)
-		_, err := m.waitSimpleCall(ctx)(worker.Fetch(ctx, sector, storiface.FTSealed|storiface.FTCache, storiface.PathSealing, storiface.AcquireCopy))
-		_, err2 := m.waitSimpleCall(ctx)(worker.Fetch(ctx, sector, storiface.FTUpdate|storiface.FTUpdateCache, storiface.PathSealing, storiface.AcquireCopy))
+		_, err := m.waitSimpleCall(ctx)(worker.Fetch(ctx, sector, storiface.FTSealed|storiface.FTCache, storiface.PathSealing, storiface.AcquireCopy, stores.FetchPriority(ctx, types.TTUnseal)))
+		_, err2 := m.waitSimpleCall(ctx)(worker.Fetch(ctx, sector, storiface.FTUpdate|storiface.FTUpdateCache, storiface.PathSealing, storiface.AcquireCopy, stores.FetchPriority(ctx, types.TTUnseal)))
 
 		if err != nil && err2 != nil {
 			return xerrors.Errorf("cannot unseal piece. error fetching sealed data: %w. error fetching replica data: %w", err, err2)
@@ -351,6 +405,14 @@ func (m *Manager) AddPiece(ctx context.Context, sector storage.SectorRef, existi
 }
 
 func (m *Manager) SealPreCommit1(ctx context.Context, sector storage.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) (out storage.PreCommit1Out, err error) {
+	err = m.retryOOM(ctx, types.TTPreCommit1, sector, func() error {
+		out, err = m.sealPreCommit1(ctx, sector, ticket, pieces)
+		return err
+	})
+	return out, err
+}
+
+func (m *Manager) sealPreCommit1(ctx context.Context, sector storage.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) (out storage.PreCommit1Out, err error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -385,7 +447,7 @@ func (m *Manager) SealPreCommit1(ctx context.Context, sector storage.SectorRef,
 
 	selector := newAllocSelector(m.index, storiface.FTCache|storiface.FTSealed, storiface.PathSealing)
 
-	var prepare WorkerAction = m.schedFetch(sector, storiface.FTUnsealed, storiface.PathSealing, storiface.AcquireMove)
+	var prepare WorkerAction = m.schedFetch(types.TTPreCommit1, sector, storiface.FTUnsealed, storiface.PathSealing, storiface.AcquireMove)
 	if len(pieces) == 1 && ((pieces[0].Size == 34359738368 && pieces[0].PieceCID.String() == "baga6ea4seaqao7s73y24kcutaosvacpdjgfe5pw76ooefnyqw4ynr3d2y6x2mpq") ||
 		(pieces[0].Size == 68719476736 && pieces[0].PieceCID.String() == "baga6ea4seaqomqafu276g53zko4k23xzh4h4uecjwicbmvhsuqi7o4bhthhm4aq") ||
 		(pieces[0].Size == 536870912 && pieces[0].PieceCID.String() == "baga6ea4seaqdsvqopmj2soyhujb72jza76t4wpq5fzifvm3ctz47iyytkewnubq") ||
@@ -410,6 +472,14 @@ func (m *Manager) SealPreCommit1(ctx context.Context, sector storage.SectorRef,
 }
 
 func (m *Manager) SealPreCommit2(ctx context.Context, sector storage.SectorRef, phase1Out storage.PreCommit1Out) (out storage.SectorCids, err error) {
+	err = m.retryOOM(ctx, types.TTPreCommit2, sector, func() error {
+		out, err = m.sealPreCommit2(ctx, sector, phase1Out)
+		return err
+	})
+	return out, err
+}
+
+func (m *Manager) sealPreCommit2(ctx context.Context, sector storage.SectorRef, phase1Out storage.PreCommit1Out) (out storage.SectorCids, err error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -442,7 +512,7 @@ func (m *Manager) SealPreCommit2(ctx context.Context, sector storage.SectorRef,
 
 	selector := newExistingSelector(m.index, sector.ID, storiface.FTCache|storiface.FTSealed, true)
 
-	err = m.sched.Schedule(ctx, sector, types.TTPreCommit2, selector, m.schedFetch(sector, storiface.FTCache|storiface.FTSealed, storiface.PathSealing, storiface.AcquireMove), func(ctx context.Context, w Worker) error {
+	err = m.sched.Schedule(ctx, sector, types.TTPreCommit2, selector, m.schedFetch(types.TTPreCommit2, sector, storiface.FTCache|storiface.FTSealed, storiface.PathSealing, storiface.AcquireMove), func(ctx context.Context, w Worker) error {
 		err := m.startWork(ctx, w, wk)(w.SealPreCommit2(ctx, sector, phase1Out))
 		if err != nil {
 			return err
@@ -494,7 +564,7 @@ func (m *Manager) SealCommit1(ctx context.Context, sector storage.SectorRef, tic
 	// generally very cheap / fast, and transferring data is not worth the effort
 	selector := newExistingSelector(m.index, sector.ID, storiface.FTCache|storiface.FTSealed, false)
 
-	err = m.sched.Schedule(ctx, sector, types.TTCommit1, selector, m.schedFetch(sector, storiface.FTCache|storiface.FTSealed, storiface.PathSealing, storiface.AcquireMove), func(ctx context.Context, w Worker) error {
+	err = m.sched.Schedule(ctx, sector, types.TTCommit1, selector, m.schedFetch(types.TTCommit1, sector, storiface.FTCache|storiface.FTSealed, storiface.PathSealing, storiface.AcquireMove), func(ctx context.Context, w Worker) error {
 		err := m.startWork(ctx, w, wk)(w.SealCommit1(ctx, sector, ticket, seed, pieces, cids))
 		if err != nil {
 			return err
@@ -511,6 +581,14 @@ func (m *Manager) SealCommit1(ctx context.Context, sector storage.SectorRef, tic
 }
 
 func (m *Manager) SealCommit2(ctx context.Context, sector storage.SectorRef, phase1Out storage.Commit1Out) (out storage.Proof, err error) {
+	err = m.retryOOM(ctx, types.TTCommit2, sector, func() error {
+		out, err = m.sealCommit2(ctx, sector, phase1Out)
+		return err
+	})
+	return out, err
+}
+
+func (m *Manager) sealCommit2(ctx context.Context, sector storage.SectorRef, phase1Out storage.Commit1Out) (out storage.Proof, err error) {
 	wk, wait, cancel, err := m.getWork(ctx, types.TTCommit2, sector, phase1Out)
 	if err != nil {
 		return storage.Proof{}, xerrors.Errorf("getWork: %w", err)
@@ -591,7 +669,7 @@ func (m *Manager) FinalizeSector(ctx context.Context, sector storage.SectorRef,
 	selector := newExistingSelector(m.index, sector.ID, storiface.FTCache, false)
 
 	err := m.sched.Schedule(ctx, sector, types.TTFinalize, selector,
-		m.schedFetch(sector, storiface.FTCache|unsealed, pathType, storiface.AcquireMove),
+		m.schedFetch(types.TTFinalize, sector, storiface.FTCache|unsealed, pathType, storiface.AcquireMove),
 		func(ctx context.Context, w Worker) error {
 			_, err := m.waitSimpleCall(ctx)(w.FinalizeSector(ctx, sector, keepUnsealed))
 			return err
@@ -610,7 +688,7 @@ func (m *Manager) FinalizeSector(ctx context.Context, sector storage.SectorRef,
 	}
 
 	err = m.sched.Schedule(ctx, sector, types.TTFetch, fetchSel,
-		m.schedFetch(sector, storiface.FTCache|storiface.FTSealed|moveUnsealed, storiface.PathStorage, storiface.AcquireMove),
+		m.schedFetch(types.TTFetch, sector, storiface.FTCache|storiface.FTSealed|moveUnsealed, storiface.PathStorage, storiface.AcquireMove),
 		func(ctx context.Context, w Worker) error {
 			_, err := m.waitSimpleCall(ctx)(w.MoveStorage(ctx, sector, storiface.FTCache|storiface.FTSealed|moveUnsealed))
 			return err
@@ -660,7 +738,7 @@ func (m *Manager) FinalizeReplicaUpdate(ctx context.Context, sector storage.Sect
 	selector := newExistingSelector(m.index, sector.ID, storiface.FTCache|storiface.FTUpdateCache, false)
 
 	err := m.sched.Schedule(ctx, sector, types.TTFinalizeReplicaUpdate, selector,
-		m.schedFetch(sector, storiface.FTCache|storiface.FTUpdateCache|moveUnsealed, pathType, storiface.AcquireMove),
+		m.schedFetch(types.TTFinalizeReplicaUpdate, sector, storiface.FTCache|storiface.FTUpdateCache|moveUnsealed, pathType, storiface.AcquireMove),
 		func(ctx context.Context, w Worker) error {
 			_, err := m.waitSimpleCall(ctx)(w.FinalizeReplicaUpdate(ctx, sector, keepUnsealed))
 			return err
@@ -678,7 +756,7 @@ func (m *Manager) FinalizeReplicaUpdate(ctx context.Context, sector storage.Sect
 		}
 
 		err = m.sched.Schedule(ctx, sector, types.TTFetch, fetchSel,
-			m.schedFetch(sector, stypes, storiface.PathStorage, storiface.AcquireMove),
+			m.schedFetch(types.TTFetch, sector, stypes, storiface.PathStorage, storiface.AcquireMove),
 			func(ctx context.Context, w Worker) error {
 				_, err := m.waitSimpleCall(ctx)(w.MoveStorage(ctx, sector, stypes))
 				return err
@@ -783,7 +861,7 @@ func (m *Manager) GenerateSectorKeyFromData(ctx context.Context, sector storage.
 	// generally very cheap / fast, and transferring data is not worth the effort
 	selector := newExistingSelector(m.index, sector.ID, storiface.FTUnsealed|storiface.FTUpdate|storiface.FTUpdateCache|storiface.FTCache, true)
 
-	err = m.sched.Schedule(ctx, sector, types.TTRegenSectorKey, selector, m.schedFetch(sector, storiface.FTUpdate|storiface.FTUnsealed, storiface.PathSealing, storiface.AcquireMove), func(ctx context.Context, w Worker) error {
+	err = m.sched.Schedule(ctx, sector, types.TTRegenSectorKey, selector, m.schedFetch(types.TTRegenSectorKey, sector, storiface.FTUpdate|storiface.FTUnsealed, storiface.PathSealing, storiface.AcquireMove), func(ctx context.Context, w Worker) error {
 		err := m.startWork(ctx, w, wk)(w.GenerateSectorKeyFromData(ctx, sector, commD))
 		if err != nil {
 			return err
@@ -829,6 +907,14 @@ func (m *Manager) Remove(ctx context.Context, sector storage.SectorRef) error {
 }
 
 func (m *Manager) ReplicaUpdate(ctx context.Context, sector storage.SectorRef, pieces []abi.PieceInfo) (out storage.ReplicaUpdateOut, err error) {
+	err = m.retryOOM(ctx, types.TTReplicaUpdate, sector, func() error {
+		out, err = m.replicaUpdate(ctx, sector, pieces)
+		return err
+	})
+	return out, err
+}
+
+func (m *Manager) replicaUpdate(ctx context.Context, sector storage.SectorRef, pieces []abi.PieceInfo) (out storage.ReplicaUpdateOut, err error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	log.Debugf("manager is doing replica update")
@@ -861,7 +947,7 @@ func (m *Manager) ReplicaUpdate(ctx context.Context, sector storage.SectorRef, p
 
 	selector := newAllocSelector(m.index, storiface.FTUpdate|storiface.FTUpdateCache, storiface.PathSealing)
 
-	err = m.sched.Schedule(ctx, sector, types.TTReplicaUpdate, selector, m.schedFetch(sector, storiface.FTUnsealed|storiface.FTSealed|storiface.FTCache, storiface.PathSealing, storiface.AcquireCopy), func(ctx context.Context, w Worker) error {
+	err = m.sched.Schedule(ctx, sector, types.TTReplicaUpdate, selector, m.schedFetch(types.TTReplicaUpdate, sector, storiface.FTUnsealed|storiface.FTSealed|storiface.FTCache, storiface.PathSealing, storiface.AcquireCopy), func(ctx context.Context, w Worker) error {
 		log.Errorf("scheduled work for replica update")
 		err := m.startWork(ctx, w, wk)(w.ReplicaUpdate(ctx, sector, pieces))
 		if err != nil {
@@ -913,7 +999,7 @@ func (m *Manager) ProveReplicaUpdate1(ctx context.Context, sector storage.Sector
 	// generally very cheap / fast, and transferring data is not worth the effort
 	selector := newExistingSelector(m.index, sector.ID, storiface.FTUpdate|storiface.FTUpdateCache, false)
 
-	err = m.sched.Schedule(ctx, sector, types.TTProveReplicaUpdate1, selector, m.schedFetch(sector, storiface.FTSealed|storiface.FTCache|storiface.FTUpdate|storiface.FTUpdateCache, storiface.PathSealing, storiface.AcquireCopy), func(ctx context.Context, w Worker) error {
+	err = m.sched.Schedule(ctx, sector, types.TTProveReplicaUpdate1, selector, m.schedFetch(types.TTProveReplicaUpdate1, sector, storiface.FTSealed|storiface.FTCache|storiface.FTUpdate|storiface.FTUpdateCache, storiface.PathSealing, storiface.AcquireCopy), func(ctx context.Context, w Worker) error {
 
 		err := m.startWork(ctx, w, wk)(w.ProveReplicaUpdate1(ctx, sector, sectorKey, newSealed, newUnsealed))
 		if err != nil {
@@ -931,6 +1017,14 @@ func (m *Manager) ProveReplicaUpdate1(ctx context.Context, sector storage.Sector
 }
 
 func (m *Manager) ProveReplicaUpdate2(ctx context.Context, sector storage.SectorRef, sectorKey, newSealed, newUnsealed cid.Cid, vanillaProofs storage.ReplicaVanillaProofs) (out storage.ReplicaUpdateProof, err error) {
+	err = m.retryOOM(ctx, types.TTProveReplicaUpdate2, sector, func() error {
+		out, err = m.proveReplicaUpdate2(ctx, sector, sectorKey, newSealed, newUnsealed, vanillaProofs)
+		return err
+	})
+	return out, err
+}
+
+func (m *Manager) proveReplicaUpdate2(ctx context.Context, sector storage.SectorRef, sectorKey, newSealed, newUnsealed cid.Cid, vanillaProofs storage.ReplicaVanillaProofs) (out storage.ReplicaUpdateProof, err error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
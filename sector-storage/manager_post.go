@@ -0,0 +1,143 @@
+package sectorstorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/proof"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// GenerateWindowPoSt proves a window PoSt batch. When workers accepting window
+// PoSt tasks are connected, the sectors are split per partition and each
+// partition is proven, in parallel and with its partition index, on a worker
+// having all the sectors of the partition in its own storage. The partition
+// proofs are then merged into the single proof of the batch.
+//
+// The partitions no worker holds or a worker failed to prove are proven
+// locally the same way. Without such a worker the whole batch is proven
+// locally.
+func (m *Manager) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.ExtendedSectorInfo, randomness abi.PoStRandomness) ([]proof.PoStProof, []abi.SectorID, error) {
+	if len(sectorInfo) == 0 || !m.sched.hasWorkerFor(ctx, types.TTGenerateWindowPoSt, nil) {
+		return m.Prover.GenerateWindowPoSt(ctx, minerID, sectorInfo, randomness)
+	}
+
+	ppt, err := sectorInfo[0].SealProof.RegisteredWindowPoStProof()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	partitions, err := windowPoStPartitions(ppt, sectorInfo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]storiface.WindowPoStResult, len(partitions))
+	errs := make([]error, len(partitions))
+
+	var wg sync.WaitGroup
+	wg.Add(len(partitions))
+	for i := range partitions {
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = m.workerWindowPoSt(ctx, minerID, partitions[i], i, append(abi.PoStRandomness{}, randomness...))
+		}(i)
+	}
+	wg.Wait()
+
+	proofs := make([]proof.PoStProof, len(partitions))
+	var skipped []abi.SectorID
+	for i, res := range results {
+		if errs[i] != nil {
+			if ctx.Err() != nil {
+				return nil, nil, ctx.Err()
+			}
+
+			log.Warnw("window post on worker failed, proving the partition locally", "partition", i, "error", errs[i])
+			res.PoStProof, res.Skipped, err = m.partitionProver.GenerateWindowPoStPartition(ctx, minerID, partitions[i], i, append(abi.PoStRandomness{}, randomness...))
+			if err != nil && len(res.Skipped) == 0 {
+				return nil, nil, xerrors.Errorf("generating window post of partition %d locally: %w", i, err)
+			}
+		}
+
+		proofs[i] = res.PoStProof
+		skipped = append(skipped, res.Skipped...)
+	}
+
+	if len(skipped) > 0 {
+		return nil, skipped, xerrors.Errorf("window post skipped %d sectors", len(skipped))
+	}
+
+	merged, err := m.partitionProver.MergeWindowPoStPartitionProofs(ppt, proofs)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("merging partition proofs: %w", err)
+	}
+
+	return []proof.PoStProof{merged}, nil, nil
+}
+
+func (m *Manager) workerWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (storiface.WindowPoStResult, error) {
+	sector := storage.SectorRef{
+		ID:        abi.SectorID{Miner: minerID, Number: sectors[0].SectorNumber},
+		ProofType: sectors[0].SealProof,
+	}
+
+	ids := make([]abi.SectorID, len(sectors))
+	for i, s := range sectors {
+		ids[i] = abi.SectorID{Miner: minerID, Number: s.SectorNumber}
+	}
+
+	// workers read the sectors from their own storage, a worker fetching them
+	// would copy the whole partition
+	sel := newWindowPoStSelector(m.index, ids)
+	if !m.sched.hasWorkerFor(ctx, types.TTGenerateWindowPoSt, func(ctx context.Context, w *workerHandle) (bool, error) {
+		return sel.hasSectors(ctx, sector.ProofType, w)
+	}) {
+		return storiface.WindowPoStResult{}, xerrors.Errorf("no window post worker has all the sectors of partition %d", partitionIdx)
+	}
+
+	var out storiface.WindowPoStResult
+	err := m.sched.Schedule(ctx, sector, types.TTGenerateWindowPoSt, sel, schedNop, func(ctx context.Context, w Worker) error {
+		res, err := w.GenerateWindowPoSt(ctx, minerID, sectors, partitionIdx, randomness)
+		if err != nil {
+			return err
+		}
+		out = res
+		return nil
+	})
+
+	return out, err
+}
+
+// windowPoStPartitions sorts the sectors by number and splits them into the
+// partitions of the window PoSt proof, in partition index order
+func windowPoStPartitions(ppt abi.RegisteredPoStProof, sectorInfo []proof.ExtendedSectorInfo) ([][]proof.ExtendedSectorInfo, error) {
+	partSize, err := builtin.PoStProofWindowPoStPartitionSectors(ppt)
+	if err != nil {
+		return nil, xerrors.Errorf("getting partition size: %w", err)
+	}
+
+	sorted := append([]proof.ExtendedSectorInfo{}, sectorInfo...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].SectorNumber < sorted[j].SectorNumber
+	})
+
+	var out [][]proof.ExtendedSectorInfo
+	for len(sorted) > 0 {
+		n := uint64(len(sorted))
+		if n > partSize {
+			n = partSize
+		}
+		out = append(out, sorted[:n])
+		sorted = sorted[n:]
+	}
+	return out, nil
+}
@@ -0,0 +1,121 @@
+package sectorstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/proof"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/mock"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func testPoStSectors(t *testing.T, numbers ...abi.SectorNumber) []proof.ExtendedSectorInfo {
+	var out []proof.ExtendedSectorInfo
+	for _, n := range numbers {
+		comm := [32]byte{byte(n)}
+		sealed, err := commcid.ReplicaCommitmentV1ToCID(comm[:])
+		require.NoError(t, err)
+
+		out = append(out, proof.ExtendedSectorInfo{
+			SealProof:    abi.RegisteredSealProof_StackedDrg2KiBV1,
+			SectorNumber: n,
+			SealedCID:    sealed,
+		})
+	}
+	return out
+}
+
+func TestWindowPoStPartitions(t *testing.T) {
+	// 2KiB window PoSt partitions hold 2 sectors
+	partitions, err := windowPoStPartitions(abi.RegisteredPoStProof_StackedDrgWindow2KiBV1, testPoStSectors(t, 5, 2, 4, 1, 3))
+	require.NoError(t, err)
+
+	var numbers [][]abi.SectorNumber
+	for _, partition := range partitions {
+		var part []abi.SectorNumber
+		for _, s := range partition {
+			part = append(part, s.SectorNumber)
+		}
+		numbers = append(numbers, part)
+	}
+	require.Equal(t, [][]abi.SectorNumber{{1, 2}, {3, 4}, {5}}, numbers)
+}
+
+func TestGenerateWindowPoStOnWorkers(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		onWorker    bool // the worker storage holds the sectors
+		workerFails bool
+	}{
+		{name: "worker", onWorker: true},
+		{name: "worker-fails", onWorker: true, workerFails: true},
+		{name: "not-on-worker"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			m, lstor, _, index, cleanup := newTestMgr(ctx, t, datastore.NewMapDatastore())
+			defer cleanup()
+
+			sectors := testPoStSectors(t, 5, 2, 4, 1, 3)
+			var ids []abi.SectorID
+			for _, s := range sectors {
+				ids = append(ids, abi.SectorID{Miner: 1000, Number: s.SectorNumber})
+			}
+
+			// the partitions the worker doesn't prove are proven by the manager
+			local := mock.NewMockSectorMgr(ids)
+			m.Prover = local
+			m.partitionProver = local
+
+			tw := newTestWorker(WorkerConfig{
+				TaskTypes: []types.TaskType{types.TTGenerateWindowPoSt},
+			}, lstor, m)
+			if tc.onWorker {
+				paths, err := lstor.Local(ctx)
+				require.NoError(t, err)
+				for _, id := range ids {
+					require.NoError(t, index.StorageDeclareSector(ctx, paths[0].ID, id, storiface.FTSealed, true))
+					require.NoError(t, index.StorageDeclareSector(ctx, paths[0].ID, id, storiface.FTCache, true))
+				}
+				tw.mockSeal = mock.NewMockSectorMgr(ids)
+			} else {
+				// a worker scheduled without the sectors would skip them all
+				tw.mockSeal = mock.NewMockSectorMgr(nil)
+			}
+			if tc.workerFails {
+				tw.mockSeal.Fail()
+			}
+			require.NoError(t, m.AddWorker(ctx, tw))
+
+			randomness := abi.PoStRandomness(make([]byte, 32))
+			randomness[0] = 42
+
+			proofs, skipped, err := m.GenerateWindowPoSt(ctx, 1000, sectors, randomness)
+			require.NoError(t, err)
+			require.Empty(t, skipped)
+			// one proof for the three partitions of the batch
+			require.Len(t, proofs, 1)
+			require.Equal(t, abi.RegisteredPoStProof_StackedDrgWindow2KiBV1, proofs[0].PoStProof)
+
+			challenged := make([]proof.SectorInfo, 0, len(sectors))
+			for _, s := range testPoStSectors(t, 1, 2, 3, 4, 5) {
+				challenged = append(challenged, proof.SectorInfo{SealProof: s.SealProof, SectorNumber: s.SectorNumber, SealedCID: s.SealedCID})
+			}
+			ok, err := mock.MockVerifier.VerifyWindowPoSt(ctx, proof.WindowPoStVerifyInfo{
+				Randomness:        randomness,
+				Proofs:            proofs,
+				ChallengedSectors: challenged,
+				Prover:            1000,
+			})
+			require.NoError(t, err)
+			require.True(t, ok)
+		})
+	}
+}
@@ -106,7 +106,7 @@ func newTestMgr(ctx context.Context, t *testing.T, ds datastore.Datastore) (*Man
 	prover, err := ffiwrapper.New(&readonlyProvider{stor: lstor, index: si})
 	require.NoError(t, err)
 
-	stor := stores.NewRemote(lstor, si, nil, 6000, &stores.DefaultPartialFileHandler{})
+	stor := stores.NewRemote(lstor, si, nil, stores.FetchLimits{Parallel: 6000}, &stores.DefaultPartialFileHandler{})
 
 	m := &Manager{
 		ls:         st,
@@ -115,9 +115,10 @@ func newTestMgr(ctx context.Context, t *testing.T, ds datastore.Datastore) (*Man
 		remoteHnd:  &stores.FetchHandler{Local: lstor},
 		index:      si,
 
-		sched: newScheduler(),
+		sched: newScheduler(&defaultPolicy{}),
 
-		Prover: prover,
+		Prover:          prover,
+		partitionProver: prover,
 
 		work:       statestore.NewDsStateStore(ds),
 		callToWork: map[types.CallID]types.WorkID{},
@@ -204,7 +205,7 @@ func TestSnapDeals(t *testing.T) {
 	}
 	wds := datastore.NewMapDatastore()
 
-	w := NewLocalWorker(WorkerConfig{TaskTypes: localTasks}, stor, lstor, idx, m, statestore.NewDsStateStore(wds))
+	w := NewLocalWorker(WorkerConfig{TaskTypes: localTasks, TaskTotal: -1}, stor, lstor, idx, m, statestore.NewDsStateStore(wds))
 	err := m.AddWorker(ctx, w)
 	require.NoError(t, err)
 
@@ -490,6 +491,7 @@ func TestRestartWorker(t *testing.T) {
 		return &testExec{apch: arch}, nil
 	}, WorkerConfig{
 		TaskTypes: localTasks,
+		TaskTotal: -1,
 	}, os.LookupEnv, stor, lstor, idx, m, statestore.NewDsStateStore(wds))
 
 	err := m.AddWorker(ctx, w)
@@ -526,6 +528,7 @@ func TestRestartWorker(t *testing.T) {
 		return &testExec{apch: arch}, nil
 	}, WorkerConfig{
 		TaskTypes: localTasks,
+		TaskTotal: -1,
 	}, os.LookupEnv, stor, lstor, idx, m, statestore.NewDsStateStore(wds))
 
 	err = m.AddWorker(ctx, w)
@@ -562,6 +565,7 @@ func TestReenableWorker(t *testing.T) {
 		return &testExec{apch: arch}, nil
 	}, WorkerConfig{
 		TaskTypes: localTasks,
+		TaskTotal: -1,
 	}, os.LookupEnv, stor, lstor, idx, m, statestore.NewDsStateStore(wds))
 
 	err := m.AddWorker(ctx, w)
@@ -635,6 +639,7 @@ func TestResUse(t *testing.T) {
 		return &testExec{apch: arch}, nil
 	}, WorkerConfig{
 		TaskTypes: localTasks,
+		TaskTotal: -1,
 	}, func(s string) (string, bool) {
 		return "", false
 	}, stor, lstor, idx, m, statestore.NewDsStateStore(wds))
@@ -693,6 +698,7 @@ func TestResOverride(t *testing.T) {
 		return &testExec{apch: arch}, nil
 	}, WorkerConfig{
 		TaskTypes: localTasks,
+		TaskTotal: -1,
 	}, func(s string) (string, bool) {
 		if s == "AP_2K_MAX_MEMORY" {
 			return "99999", true
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -364,11 +365,81 @@ func (mgr *SectorMgr) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorI
 		return nil, nil, xerrors.Errorf("failed to post (mock)")
 	}
 
-	si := make([]prooftypes.ExtendedSectorInfo, 0, len(xSectorInfo))
+	sectorInfo, skipped := mgr.provableSectors(minerID, xSectorInfo)
+	if len(skipped) > 0 {
+		return nil, skipped, xerrors.Errorf("skipped some sectors")
+	}
 
-	var skipped []abi.SectorID
+	return generateFakePoSt(sectorInfo, abi.RegisteredSealProof.RegisteredWindowPoStProof, randomness), skipped, nil
+}
+
+// GenerateWindowPoStPartition returns the partition index, the randomness and
+// the sectors of the partition, MergeWindowPoStPartitionProofs turns them into
+// the proof of the whole batch.
+func (mgr *SectorMgr) GenerateWindowPoStPartition(ctx context.Context, minerID abi.ActorID, xSectorInfo []prooftypes.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (prooftypes.PoStProof, []abi.SectorID, error) {
+	mgr.lk.Lock()
+	defer mgr.lk.Unlock()
+
+	if mgr.failPoSt {
+		return prooftypes.PoStProof{}, nil, xerrors.Errorf("failed to post (mock)")
+	}
+
+	sectorInfo, skipped := mgr.provableSectors(minerID, xSectorInfo)
+	if len(skipped) > 0 {
+		return prooftypes.PoStProof{}, skipped, xerrors.Errorf("skipped some sectors")
+	}
+
+	wp, err := xSectorInfo[0].SealProof.RegisteredWindowPoStProof()
+	if err != nil {
+		return prooftypes.PoStProof{}, nil, err
+	}
+
+	randomness[31] &= 0x3f
+
+	var buf bytes.Buffer
+	var idx [8]byte
+	binary.BigEndian.PutUint64(idx[:], uint64(partitionIdx))
+	buf.Write(idx[:])
+	buf.Write(randomness)
+	for _, info := range sectorInfo {
+		if err := info.MarshalCBOR(&buf); err != nil {
+			return prooftypes.PoStProof{}, nil, err
+		}
+	}
+
+	return prooftypes.PoStProof{PoStProof: wp, ProofBytes: buf.Bytes()}, nil, nil
+}
+
+// MergeWindowPoStPartitionProofs checks the partition proofs are given in
+// partition order and returns the proof generateFakePoSt would return for all
+// their sectors.
+func (mgr *SectorMgr) MergeWindowPoStPartitionProofs(proofType abi.RegisteredPoStProof, partitionProofs []prooftypes.PoStProof) (prooftypes.PoStProof, error) {
+	hasher := sha256.New()
+	var randomness []byte
+	for i, pp := range partitionProofs {
+		if len(pp.ProofBytes) < 40 {
+			return prooftypes.PoStProof{}, xerrors.Errorf("partition proof %d too short", i)
+		}
+		if idx := binary.BigEndian.Uint64(pp.ProofBytes[:8]); idx != uint64(i) {
+			return prooftypes.PoStProof{}, xerrors.Errorf("proof %d was generated for partition %d", i, idx)
+		}
 
-	var err error
+		if i == 0 {
+			randomness = pp.ProofBytes[8:40]
+			_, _ = hasher.Write(randomness)
+		} else if !bytes.Equal(randomness, pp.ProofBytes[8:40]) {
+			return prooftypes.PoStProof{}, xerrors.Errorf("proof %d was generated with another randomness", i)
+		}
+		_, _ = hasher.Write(pp.ProofBytes[40:])
+	}
+
+	return prooftypes.PoStProof{PoStProof: proofType, ProofBytes: hasher.Sum(nil)}, nil
+}
+
+// call with mgr.lk
+func (mgr *SectorMgr) provableSectors(minerID abi.ActorID, xSectorInfo []prooftypes.ExtendedSectorInfo) ([]prooftypes.SectorInfo, []abi.SectorID) {
+	var sectorInfo []prooftypes.SectorInfo
+	var skipped []abi.SectorID
 
 	for _, xsi := range xSectorInfo {
 		sid := abi.SectorID{
@@ -379,27 +450,17 @@ func (mgr *SectorMgr) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorI
 		_, found := mgr.sectors[sid]
 
 		if found && !mgr.sectors[sid].failed && !mgr.sectors[sid].corrupted {
-			si = append(si, xsi)
+			sectorInfo = append(sectorInfo, prooftypes.SectorInfo{
+				SealProof:    xsi.SealProof,
+				SectorNumber: xsi.SectorNumber,
+				SealedCID:    xsi.SealedCID,
+			})
 		} else {
 			skipped = append(skipped, sid)
-			err = xerrors.Errorf("skipped some sectors")
 		}
 	}
 
-	if err != nil {
-		return nil, skipped, err
-	}
-
-	sectorInfo := make([]prooftypes.SectorInfo, len(si))
-	for i, xssi := range si {
-		sectorInfo[i] = prooftypes.SectorInfo{
-			SealProof:    xssi.SealProof,
-			SectorNumber: xssi.SectorNumber,
-			SealedCID:    xssi.SealedCID,
-		}
-	}
-
-	return generateFakePoSt(sectorInfo, abi.RegisteredSealProof.RegisteredWindowPoStProof, randomness), skipped, nil
+	return sectorInfo, skipped
 }
 
 func generateFakePoStProof(sectorInfo []prooftypes.SectorInfo, randomness abi.PoStRandomness) []byte {
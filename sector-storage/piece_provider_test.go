@@ -211,7 +211,7 @@ func newPieceProviderTestHarness(t *testing.T, mgrConfig SealerConfig, sectorPro
 	storage := newTestStorage(t)
 	localStore, err := stores.NewLocal(ctx, storage, index, []string{"http://" + nl.Addr().String() + "/remote"})
 	require.NoError(t, err)
-	remoteStore := stores.NewRemote(localStore, index, nil, 6000, &stores.DefaultPartialFileHandler{})
+	remoteStore := stores.NewRemote(localStore, index, nil, stores.FetchLimits{Parallel: 6000}, &stores.DefaultPartialFileHandler{})
 
 	// data stores for state tracking.
 	dstore := ds_sync.MutexWrap(datastore.NewMapDatastore())
@@ -280,7 +280,7 @@ func (p *pieceProviderTestHarness) addRemoteWorker(t *testing.T, tasks []types.T
 		_ = svc.Serve(nl)
 	}()
 
-	remote := stores.NewRemote(localStore, p.index, nil, 1000,
+	remote := stores.NewRemote(localStore, p.index, nil, stores.FetchLimits{Parallel: 1000},
 		&stores.DefaultPartialFileHandler{})
 
 	dstore := ds_sync.MutexWrap(datastore.NewMapDatastore())
@@ -288,6 +288,7 @@ func (p *pieceProviderTestHarness) addRemoteWorker(t *testing.T, tasks []types.T
 
 	worker := newLocalWorker(nil, WorkerConfig{
 		TaskTypes: tasks,
+		TaskTotal: -1,
 	}, os.LookupEnv, remote, localStore, p.index, p.mgr, csts)
 
 	p.servers = append(p.servers, svc)
@@ -24,6 +24,7 @@ var SchedPriorityKey schedPrioCtxKey
 var DefaultSchedPriority = 0
 var SelectorTimeout = 5 * time.Second
 var InitWait = 3 * time.Second
+var MetricsInterval = 15 * time.Second
 
 var (
 	SchedWindows = 2
@@ -57,6 +58,8 @@ type scheduler struct {
 	workersLk sync.RWMutex
 	workers   map[storiface.WorkerID]*workerHandle
 
+	workerDisconnected func(storiface.WorkerID, storiface.WorkerInfo) // use with workersLk
+
 	// todo 没有用到?
 	tasksCache  map[types.TaskType]struct{}
 	tasksUpdate time.Time
@@ -71,8 +74,12 @@ type scheduler struct {
 	schedQueue  *requestQueue
 	openWindows []*schedWindowRequest
 
+	reportedTasks map[types.TaskType]struct{} // task types with a queue gauge, owned by sh.runSched
+
 	workTracker *workTracker
 
+	policy SchedulingPolicy
+
 	info chan func(interface{})
 
 	closing  chan struct{}
@@ -154,7 +161,7 @@ type workerResponse struct {
 	err error
 }
 
-func newScheduler() *scheduler {
+func newScheduler(policy SchedulingPolicy) *scheduler {
 	return &scheduler{
 		workers: map[storiface.WorkerID]*workerHandle{},
 
@@ -163,7 +170,8 @@ func newScheduler() *scheduler {
 		workerChange:   make(chan struct{}, 20),
 		workerDisable:  make(chan workerDisableReq),
 
-		schedQueue: &requestQueue{},
+		schedQueue:    &requestQueue{},
+		reportedTasks: map[types.TaskType]struct{}{},
 
 		workTracker: &workTracker{
 			done:     map[types.CallID]struct{}{},
@@ -171,6 +179,8 @@ func newScheduler() *scheduler {
 			prepared: map[uuid.UUID]trackedWork{},
 		},
 
+		policy: policy,
+
 		info: make(chan func(interface{})),
 
 		closing: make(chan struct{}),
@@ -237,6 +247,9 @@ func (sh *scheduler) runSched() {
 	iw := time.After(InitWait)
 	var initialised bool
 
+	metricsTick := time.NewTicker(MetricsInterval)
+	defer metricsTick.Stop()
+
 	for {
 		var doSched bool
 		var toDisable []workerDisableReq
@@ -259,6 +272,8 @@ func (sh *scheduler) runSched() {
 			doSched = true
 		case ireq := <-sh.info:
 			ireq(sh.diag())
+		case <-metricsTick.C:
+			sh.recordMetrics()
 
 		case <-iw:
 			initialised = true
@@ -347,6 +362,7 @@ func (sh *scheduler) trySched() {
 		- Task priority (achieved by handling sh.schedQueue in order, since it's already sorted by priority)
 		- Worker resource availability
 		- Task-specified worker preference (acceptableWindows array below sorted by this preference)
+		- The scheduling policy, which can restrict and reorder the acceptable workers
 		- Window request age
 
 		1. For each task in the schedQueue find windows which can handle them
@@ -430,6 +446,8 @@ func (sh *scheduler) trySched() {
 				return
 			}
 
+			acceptableWindows[sqi] = sh.filterWindows(task, acceptableWindows[sqi])
+
 			// Pick best worker (shuffle in case some workers are equally as good)
 			rand.Shuffle(len(acceptableWindows[sqi]), func(i, j int) {
 				acceptableWindows[sqi][i], acceptableWindows[sqi][j] = acceptableWindows[sqi][j], acceptableWindows[sqi][i] // nolint:scopelint
@@ -449,7 +467,7 @@ func (sh *scheduler) trySched() {
 				rpcCtx, cancel := context.WithTimeout(task.ctx, SelectorTimeout)
 				defer cancel()
 
-				r, err := task.sel.Cmp(rpcCtx, task.taskType, wi, wj)
+				r, err := sh.policy.Cmp(rpcCtx, task, wi, wj)
 				if err != nil {
 					log.Errorf("selecting best worker: %s", err)
 				}
@@ -475,7 +493,8 @@ func (sh *scheduler) trySched() {
 		var needRes storiface.Resources
 		var info storiface.WorkerInfo
 		var bestWid storiface.WorkerID
-		bestUtilization := math.MaxFloat64 // smaller = better
+		bestUtilization := math.MaxFloat64
+		bestScore := math.MaxFloat64 // smaller = better
 
 		for i, wnd := range acceptableWindows[task.indexHeap] {
 			wid := sh.openWindows[wnd].worker
@@ -495,7 +514,8 @@ func (sh *scheduler) trySched() {
 				wu = w.utilization()
 				workerUtil[wid] = wu
 			}
-			if wu >= bestUtilization {
+			score := sh.policy.Score(task, w, wu)
+			if score >= bestScore {
 				// acceptable worker list is initially sorted by utilization, and the initially-best workers
 				// will be assigned tasks first. This means that if we find a worker which isn't better, it
 				// probably means that the other workers aren't better either.
@@ -517,6 +537,7 @@ func (sh *scheduler) trySched() {
 			bestWid = wid
 			selectedWindow = wnd
 			bestUtilization = wu
+			bestScore = score
 		}
 
 		if selectedWindow < 0 {
@@ -534,6 +555,7 @@ func (sh *scheduler) trySched() {
 
 		workerUtil[bestWid] += windows[selectedWindow].allocated.add(info.Resources, needRes)
 		windows[selectedWindow].todo = append(windows[selectedWindow].todo, task)
+		sh.policy.Assigned(task, sh.workers[bestWid])
 
 		rmQueue = append(rmQueue, sqi)
 		scheduled++
@@ -582,6 +604,34 @@ func (sh *scheduler) trySched() {
 	sh.openWindows = newOpenWindows
 }
 
+// filterWindows drops the windows of workers the scheduling policy doesn't
+// allow for the task
+func (sh *scheduler) filterWindows(task *workerRequest, acceptable []int) []int {
+	var candidates []*workerHandle
+	seen := map[storiface.WorkerID]struct{}{}
+	for _, wnd := range acceptable {
+		wid := sh.openWindows[wnd].worker
+		if _, ok := seen[wid]; ok {
+			continue
+		}
+		seen[wid] = struct{}{}
+		candidates = append(candidates, sh.workers[wid])
+	}
+
+	allowed := map[*workerHandle]struct{}{}
+	for _, w := range sh.policy.Filter(task, candidates, sh.workers) {
+		allowed[w] = struct{}{}
+	}
+
+	out := acceptable[:0]
+	for _, wnd := range acceptable {
+		if _, ok := allowed[sh.workers[sh.openWindows[wnd].worker]]; ok {
+			out = append(out, wnd)
+		}
+	}
+	return out
+}
+
 func (sh *scheduler) schedClose() {
 	sh.workersLk.Lock()
 	defer sh.workersLk.Unlock()
@@ -592,6 +642,46 @@ func (sh *scheduler) schedClose() {
 	}
 }
 
+// hasWorkerFor returns true if an enabled worker accepts tasks of the type,
+// and if accept isn't nil, is accepted by it
+func (sh *scheduler) hasWorkerFor(ctx context.Context, tt types.TaskType, accept func(context.Context, *workerHandle) (bool, error)) bool {
+	sh.workersLk.RLock()
+	defer sh.workersLk.RUnlock()
+
+	for wid, w := range sh.workers {
+		if !w.enabled {
+			continue
+		}
+
+		rpcCtx, cancel := context.WithTimeout(ctx, SelectorTimeout)
+		tasks, err := w.TaskTypes(rpcCtx)
+		cancel()
+		if err != nil {
+			log.Warnw("getting supported worker task types", "worker", wid, "error", err)
+			continue
+		}
+		if _, ok := tasks[tt]; !ok {
+			continue
+		}
+		if accept == nil {
+			return true
+		}
+
+		rpcCtx, cancel = context.WithTimeout(ctx, SelectorTimeout)
+		ok, err := accept(rpcCtx, w)
+		cancel()
+		if err != nil {
+			log.Warnw("checking worker", "worker", wid, "error", err)
+			continue
+		}
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (sh *scheduler) Info(ctx context.Context) (interface{}, error) {
 	ch := make(chan interface{}, 1)
 
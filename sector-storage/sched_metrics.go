@@ -0,0 +1,58 @@
+package sectorstorage
+
+import (
+	"context"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/filecoin-project/venus-sealer/metrics"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// recordMetrics exports the scheduler queue length per task type and the
+// resources claimed on every worker. Must be called from sh.runSched.
+func (sh *scheduler) recordMetrics() {
+	ctx := context.TODO()
+
+	queued := map[types.TaskType]int64{}
+	for _, req := range *sh.schedQueue {
+		queued[req.taskType]++
+	}
+	// report zero for task types which left the queue so the gauge doesn't get stuck
+	for tt := range sh.reportedTasks {
+		if _, ok := queued[tt]; !ok {
+			queued[tt] = 0
+		}
+	}
+	for tt, n := range queued {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.TaskType, string(tt))}, metrics.SchedQueueTasks.M(n))
+		if n == 0 {
+			delete(sh.reportedTasks, tt)
+		} else {
+			sh.reportedTasks[tt] = struct{}{}
+		}
+	}
+
+	sh.workersLk.RLock()
+	defer sh.workersLk.RUnlock()
+
+	for _, w := range sh.workers {
+		w.lk.Lock()
+		recordResources(ctx, w.info.Hostname, "active", w.active)
+		recordResources(ctx, w.info.Hostname, "preparing", w.preparing)
+		w.lk.Unlock()
+	}
+}
+
+func recordResources(ctx context.Context, host string, stage string, a *activeResources) {
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(metrics.WorkerHost, host),
+		tag.Upsert(metrics.ResourceStage, stage),
+	},
+		metrics.SchedWorkerMemUsedMin.M(int64(a.memUsedMin)),
+		metrics.SchedWorkerMemUsedMax.M(int64(a.memUsedMax)),
+		metrics.SchedWorkerCpuUse.M(int64(a.cpuUse)),
+		metrics.SchedWorkerGpuUsed.M(a.gpuUsed),
+	)
+}
@@ -0,0 +1,210 @@
+package sectorstorage
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+const (
+	// SchedulingPolicyDefault assigns tasks to the least utilized worker preferred by the task selector
+	SchedulingPolicyDefault = "default"
+	// SchedulingPolicySpread assigns tasks round-robin across worker hosts
+	SchedulingPolicySpread = "spread"
+	// SchedulingPolicyPack fills a worker host before using the next one
+	SchedulingPolicyPack = "pack"
+	// SchedulingPolicyPC1Affinity keeps all tasks of a sector on the host which ran its PC1
+	SchedulingPolicyPC1Affinity = "pc1-affinity"
+)
+
+// SchedulingPolicy decides which of the workers able to run a task gets it.
+// Methods are only called from the scheduling loop, Filter and Cmp may be
+// called concurrently for different tasks.
+type SchedulingPolicy interface {
+	// Filter returns the workers out of the ones accepted by the task selector
+	// the task may be assigned to, workers holds all the connected workers.
+	Filter(task *workerRequest, candidates []*workerHandle, workers map[storiface.WorkerID]*workerHandle) []*workerHandle
+	// Cmp returns true if worker a is preferred over b for the task, the
+	// windows of a task are tried in this order.
+	Cmp(ctx context.Context, task *workerRequest, a, b *workerHandle) (bool, error)
+	// Score rates assigning the task to a worker with the given utilization,
+	// which includes the tasks assigned during the current scheduling pass.
+	// Smaller is better, it must agree with the order of Cmp.
+	Score(task *workerRequest, w *workerHandle, utilization float64) float64
+	// Assigned is called after the task got assigned to the worker.
+	Assigned(task *workerRequest, w *workerHandle)
+}
+
+// NewSchedulingPolicy returns the built-in policy with the given name, an
+// empty name selects the default policy.
+func NewSchedulingPolicy(name string) (SchedulingPolicy, error) {
+	switch name {
+	case "", SchedulingPolicyDefault:
+		return &defaultPolicy{}, nil
+	case SchedulingPolicySpread:
+		return &spreadPolicy{lastAssigned: map[string]uint64{}}, nil
+	case SchedulingPolicyPack:
+		return &packPolicy{}, nil
+	case SchedulingPolicyPC1Affinity:
+		return &pc1AffinityPolicy{pc1Hosts: map[abi.SectorID]pc1Host{}}, nil
+	default:
+		return nil, xerrors.Errorf("unknown scheduling policy %q", name)
+	}
+}
+
+// defaultPolicy orders workers with the task selector and picks the least
+// utilized one.
+type defaultPolicy struct{}
+
+func (p *defaultPolicy) Filter(task *workerRequest, candidates []*workerHandle, workers map[storiface.WorkerID]*workerHandle) []*workerHandle {
+	return candidates
+}
+
+func (p *defaultPolicy) Cmp(ctx context.Context, task *workerRequest, a, b *workerHandle) (bool, error) {
+	return task.sel.Cmp(ctx, task.taskType, a, b)
+}
+
+func (p *defaultPolicy) Score(task *workerRequest, w *workerHandle, utilization float64) float64 {
+	return utilization
+}
+
+func (p *defaultPolicy) Assigned(task *workerRequest, w *workerHandle) {}
+
+// spreadPolicy assigns tasks to the host which got a task the longest time ago.
+type spreadPolicy struct {
+	defaultPolicy
+
+	seq          uint64
+	lastAssigned map[string]uint64 // hostname -> seq of its last assignment
+}
+
+func (p *spreadPolicy) Cmp(ctx context.Context, task *workerRequest, a, b *workerHandle) (bool, error) {
+	la, lb := p.lastAssigned[a.info.Hostname], p.lastAssigned[b.info.Hostname]
+	if la != lb {
+		return la < lb, nil
+	}
+	return p.defaultPolicy.Cmp(ctx, task, a, b)
+}
+
+func (p *spreadPolicy) Score(task *workerRequest, w *workerHandle, utilization float64) float64 {
+	return float64(p.lastAssigned[w.info.Hostname])
+}
+
+func (p *spreadPolicy) Assigned(task *workerRequest, w *workerHandle) {
+	p.seq++
+	p.lastAssigned[w.info.Hostname] = p.seq
+}
+
+// packPolicy assigns tasks to the most utilized worker which still has room
+// for them, so that a host is full before the next one is used.
+type packPolicy struct {
+	defaultPolicy
+}
+
+func (p *packPolicy) Cmp(ctx context.Context, task *workerRequest, a, b *workerHandle) (bool, error) {
+	ua, ub := a.utilization(), b.utilization()
+	if ua != ub {
+		return ua > ub, nil
+	}
+	// keep the order stable so that equally used hosts are filled one by one
+	return a.info.Hostname < b.info.Hostname, nil
+}
+
+func (p *packPolicy) Score(task *workerRequest, w *workerHandle, utilization float64) float64 {
+	return -utilization
+}
+
+// pc1AffinityExpiry is how long the PC1 host of a sector is remembered, a
+// sector not finalized by then was most likely removed or failed.
+var pc1AffinityExpiry = 72 * time.Hour
+
+// pc1AffinityPolicy behaves like the default policy, but once a sector was
+// assigned to a host for PC1 the following tasks of the sector only run on
+// that host, waiting for it when it is busy. This avoids moving the sector
+// cache between workers. The tasks go to any worker when no enabled worker of
+// the host is able to run them.
+//
+// The PC1 hosts are only kept in memory, the sectors sealing while the sealer
+// restarts lose their affinity.
+type pc1AffinityPolicy struct {
+	defaultPolicy
+
+	pc1Hosts map[abi.SectorID]pc1Host
+}
+
+type pc1Host struct {
+	hostname string
+	expires  time.Time
+}
+
+func (p *pc1AffinityPolicy) Filter(task *workerRequest, candidates []*workerHandle, workers map[storiface.WorkerID]*workerHandle) []*workerHandle {
+	if task.taskType == types.TTPreCommit1 {
+		return candidates
+	}
+	host, ok := p.pc1Hosts[task.sector.ID]
+	if !ok || time.Now().After(host.expires) {
+		return candidates
+	}
+
+	var out []*workerHandle
+	for _, w := range candidates {
+		if w.info.Hostname == host.hostname {
+			out = append(out, w)
+		}
+	}
+	if len(out) == 0 && !p.hostRuns(task, host.hostname, workers) {
+		// the host is gone, disabled or can't run this task type
+		return candidates
+	}
+	return out
+}
+
+// hostRuns returns true if an enabled worker of the host accepts the task type
+func (p *pc1AffinityPolicy) hostRuns(task *workerRequest, hostname string, workers map[storiface.WorkerID]*workerHandle) bool {
+	for wid, w := range workers {
+		if w.info.Hostname != hostname || !w.enabled {
+			continue
+		}
+
+		rpcCtx, cancel := context.WithTimeout(task.ctx, SelectorTimeout)
+		tasks, err := w.TaskTypes(rpcCtx)
+		cancel()
+		if err != nil {
+			log.Warnw("getting supported worker task types", "worker", wid, "error", err)
+			continue
+		}
+		if _, ok := tasks[task.taskType]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *pc1AffinityPolicy) Assigned(task *workerRequest, w *workerHandle) {
+	switch task.taskType {
+	case types.TTPreCommit1:
+		now := time.Now()
+		for sector, host := range p.pc1Hosts {
+			if now.After(host.expires) {
+				delete(p.pc1Hosts, sector)
+			}
+		}
+		p.pc1Hosts[task.sector.ID] = pc1Host{hostname: w.info.Hostname, expires: now.Add(pc1AffinityExpiry)}
+	case types.TTFinalize:
+		// the sector leaves the sealing worker
+		delete(p.pc1Hosts, task.sector.ID)
+	}
+}
+
+var (
+	_ SchedulingPolicy = &defaultPolicy{}
+	_ SchedulingPolicy = &spreadPolicy{}
+	_ SchedulingPolicy = &packPolicy{}
+	_ SchedulingPolicy = &pc1AffinityPolicy{}
+)
@@ -0,0 +1,140 @@
+package sectorstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func policyTestWorker(host string, tasks ...types.TaskType) *workerHandle {
+	tasksCache := map[types.TaskType]struct{}{}
+	for _, tt := range tasks {
+		tasksCache[tt] = struct{}{}
+	}
+
+	return &workerHandle{
+		info:        storiface.WorkerInfo{Hostname: host},
+		tasksCache:  tasksCache,
+		tasksUpdate: time.Now(),
+		active:      &activeResources{},
+		preparing:   &activeResources{},
+		enabled:     true,
+	}
+}
+
+func policyTestTask(sector abi.SectorNumber, tt types.TaskType) *workerRequest {
+	return &workerRequest{
+		sector:   storage.SectorRef{ID: abi.SectorID{Miner: 1000, Number: sector}},
+		taskType: tt,
+		ctx:      context.TODO(),
+	}
+}
+
+func TestNewSchedulingPolicy(t *testing.T) {
+	for _, name := range []string{"", SchedulingPolicyDefault, SchedulingPolicySpread, SchedulingPolicyPack, SchedulingPolicyPC1Affinity} {
+		_, err := NewSchedulingPolicy(name)
+		require.NoError(t, err, name)
+	}
+
+	_, err := NewSchedulingPolicy("random")
+	require.Error(t, err)
+}
+
+func TestSpreadPolicy(t *testing.T) {
+	p, err := NewSchedulingPolicy(SchedulingPolicySpread)
+	require.NoError(t, err)
+
+	a, b := policyTestWorker("a"), policyTestWorker("b")
+	task := policyTestTask(1, types.TTPreCommit1)
+
+	p.Assigned(task, a)
+
+	// b never got a task, it goes first
+	better, err := p.Cmp(context.TODO(), task, b, a)
+	require.NoError(t, err)
+	require.True(t, better)
+	require.Less(t, p.Score(task, b, 1), p.Score(task, a, 0))
+
+	p.Assigned(task, b)
+	require.Less(t, p.Score(task, a, 1), p.Score(task, b, 0))
+}
+
+func TestPackPolicy(t *testing.T) {
+	p, err := NewSchedulingPolicy(SchedulingPolicyPack)
+	require.NoError(t, err)
+
+	a := policyTestWorker("a")
+	task := policyTestTask(1, types.TTPreCommit1)
+
+	// the busier worker is preferred
+	require.Less(t, p.Score(task, a, 0.8), p.Score(task, a, 0.2))
+}
+
+func TestPC1AffinityPolicy(t *testing.T) {
+	p, err := NewSchedulingPolicy(SchedulingPolicyPC1Affinity)
+	require.NoError(t, err)
+
+	a := policyTestWorker("a", types.TTPreCommit2, types.TTCommit1, types.TTCommit2, types.TTFetch)
+	b := policyTestWorker("b", types.TTPreCommit2, types.TTCommit1, types.TTFetch)
+	all := []*workerHandle{a, b}
+	workers := map[storiface.WorkerID]*workerHandle{
+		storiface.WorkerID(uuid.New()): a,
+		storiface.WorkerID(uuid.New()): b,
+	}
+
+	// nothing known about the sector yet
+	require.Equal(t, all, p.Filter(policyTestTask(1, types.TTPreCommit2), all, workers))
+
+	p.Assigned(policyTestTask(1, types.TTPreCommit1), b)
+
+	require.Equal(t, []*workerHandle{b}, p.Filter(policyTestTask(1, types.TTPreCommit2), all, workers))
+	require.Equal(t, []*workerHandle{b}, p.Filter(policyTestTask(1, types.TTCommit1), all, workers))
+	// other sectors are not affected
+	require.Equal(t, all, p.Filter(policyTestTask(2, types.TTPreCommit2), all, workers))
+	// wait for the PC1 host while it has no room for the task
+	require.Empty(t, p.Filter(policyTestTask(1, types.TTCommit1), []*workerHandle{a}, workers))
+	// fall back to any worker when the PC1 host can't run the task
+	require.Equal(t, []*workerHandle{a}, p.Filter(policyTestTask(1, types.TTCommit2), []*workerHandle{a}, workers))
+	// or when it is disabled
+	b.enabled = false
+	require.Equal(t, []*workerHandle{a}, p.Filter(policyTestTask(1, types.TTCommit1), []*workerHandle{a}, workers))
+	b.enabled = true
+	// or disconnected
+	require.Equal(t, []*workerHandle{a}, p.Filter(policyTestTask(1, types.TTCommit1), []*workerHandle{a}, map[storiface.WorkerID]*workerHandle{
+		storiface.WorkerID(uuid.New()): a,
+	}))
+
+	p.Assigned(policyTestTask(1, types.TTFinalize), b)
+	require.Equal(t, all, p.Filter(policyTestTask(1, types.TTFetch), all, workers))
+}
+
+func TestPC1AffinityExpiry(t *testing.T) {
+	p := &pc1AffinityPolicy{pc1Hosts: map[abi.SectorID]pc1Host{}}
+
+	a, b := policyTestWorker("a", types.TTPreCommit2), policyTestWorker("b", types.TTPreCommit2)
+	all := []*workerHandle{a, b}
+	workers := map[storiface.WorkerID]*workerHandle{
+		storiface.WorkerID(uuid.New()): a,
+		storiface.WorkerID(uuid.New()): b,
+	}
+
+	p.Assigned(policyTestTask(1, types.TTPreCommit1), b)
+	p.pc1Hosts[abi.SectorID{Miner: 1000, Number: 1}] = pc1Host{hostname: "b", expires: time.Now().Add(-time.Second)}
+
+	// the expired sector goes anywhere
+	require.Equal(t, all, p.Filter(policyTestTask(1, types.TTPreCommit2), all, workers))
+
+	// and is forgotten on the next PC1 assignment
+	p.Assigned(policyTestTask(2, types.TTPreCommit1), a)
+	require.Len(t, p.pc1Hosts, 1)
+	require.Equal(t, []*workerHandle{a}, p.Filter(policyTestTask(2, types.TTPreCommit2), all, workers))
+}
@@ -5,6 +5,8 @@ import (
 	"sync"
 	"time"
 
+	"golang.org/x/xerrors"
+
 	"github.com/filecoin-project/venus-sealer/types"
 
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
@@ -176,3 +178,16 @@ func (wh *workerHandle) TaskTypes(ctx context.Context) (t map[types.TaskType]str
 
 	return wh.tasksCache, nil
 }
+
+// canAccept checks that the worker runs less tasks than its total limit and
+// its limit for the task type
+func (wh *workerHandle) canAccept(ctx context.Context, task types.TaskType) (bool, error) {
+	capacity, err := wh.workerRpc.Capacity(ctx)
+	if err != nil {
+		return false, xerrors.Errorf("getting worker capacity: %w", err)
+	}
+
+	log.Debugf("tasks allocate: %d-%d, %s: %d-%d for %s", capacity.Running, capacity.Total,
+		task, capacity.RunningByType[task], capacity.LimitByType[task], wh.info.Hostname)
+	return capacity.CanAccept(task), nil
+}
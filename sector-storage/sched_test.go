@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/proof"
 
 	"github.com/filecoin-project/specs-storage/storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
@@ -133,7 +134,7 @@ func (s *schedTestWorker) MoveStorage(ctx context.Context, sector storage.Sector
 	panic("implement me")
 }
 
-func (s *schedTestWorker) Fetch(ctx context.Context, id storage.SectorRef, ft storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode) (types.CallID, error) {
+func (s *schedTestWorker) Fetch(ctx context.Context, id storage.SectorRef, ft storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode, priority int) (types.CallID, error) {
 	panic("implement me")
 }
 
@@ -145,12 +146,16 @@ func (s *schedTestWorker) ReadPiece(ctx context.Context, writer io.Writer, id st
 	panic("implement me")
 }
 
+func (s *schedTestWorker) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (storiface.WindowPoStResult, error) {
+	panic("implement me")
+}
+
 func (s *schedTestWorker) TaskTypes(ctx context.Context) (map[types.TaskType]struct{}, error) {
 	return s.taskTypes, nil
 }
 
-func (t *schedTestWorker) TaskNumbers(ctx context.Context) (string, error) {
-	return "0-0", nil
+func (t *schedTestWorker) Capacity(ctx context.Context) (storiface.WorkerCapacity, error) {
+	return storiface.WorkerCapacity{Total: -1}, nil
 }
 
 func (s *schedTestWorker) Paths(ctx context.Context) ([]stores.StoragePath, error) {
@@ -212,7 +217,7 @@ func addTestWorker(t *testing.T, sched *scheduler, index *stores.Index, name str
 }
 
 func TestSchedStartStop(t *testing.T) {
-	sched := newScheduler()
+	sched := newScheduler(&defaultPolicy{})
 	go sched.runSched()
 
 	addTestWorker(t, sched, stores.NewIndex(), "fred", nil, decentWorkerResources, false)
@@ -340,7 +345,7 @@ func TestSched(t *testing.T) {
 		return func(t *testing.T) {
 			index := stores.NewIndex()
 
-			sched := newScheduler()
+			sched := newScheduler(&defaultPolicy{})
 			sched.testSync = make(chan struct{})
 
 			go sched.runSched()
@@ -592,7 +597,7 @@ func BenchmarkTrySched(b *testing.B) {
 			for i := 0; i < b.N; i++ {
 				b.StopTimer()
 
-				sched := newScheduler()
+				sched := newScheduler(&defaultPolicy{})
 				sched.workers[storiface.WorkerID{}] = &workerHandle{
 					workerRpc: nil,
 					info: storiface.WorkerInfo{
@@ -101,7 +101,16 @@ func (sw *schedWorker) handleWorker() {
 
 		sched.workersLk.Lock()
 		delete(sched.workers, sw.wid)
+		disconnected := sched.workerDisconnected
 		sched.workersLk.Unlock()
+
+		select {
+		case <-sched.closing:
+		default:
+			if disconnected != nil {
+				disconnected(sw.wid, worker.info)
+			}
+		}
 	}()
 
 	defer sw.heartbeatTimer.Stop()
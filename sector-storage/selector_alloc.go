@@ -2,8 +2,6 @@ package sectorstorage
 
 import (
 	"context"
-	"strconv"
-	"strings"
 
 	"golang.org/x/xerrors"
 
@@ -39,19 +37,8 @@ func (s *allocSelector) Ok(ctx context.Context, task types.TaskType, spt abi.Reg
 	}
 
 	// Check the number of tasks
-	taskNum, err := whnd.workerRpc.TaskNumbers(ctx)
-	if err != nil {
-		return false, xerrors.Errorf("getting supported worker task number: %w", err)
-	}
-
-	log.Debugf("tasks allocate: %s for %s", taskNum, whnd.info.Hostname)
-	nums := strings.Split(taskNum, "-")
-	if len(nums) == 2 {
-		curNum, _ := strconv.ParseInt(nums[0], 10, 64)
-		total, _ := strconv.ParseInt(nums[1], 10, 64)
-		if total > 0 && curNum >= total {
-			return false, nil
-		}
+	if ok, err := whnd.canAccept(ctx, task); err != nil || !ok {
+		return false, err
 	}
 
 	paths, err := whnd.workerRpc.Paths(ctx)
@@ -0,0 +1,94 @@
+package sectorstorage
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// windowPoStSelector only accepts the workers which have the sealed and cache
+// files of every sector of a window PoSt partition in their own storage, the
+// window PoSt workers never fetch sectors.
+type windowPoStSelector struct {
+	index   stores.SectorIndex
+	sectors []abi.SectorID
+}
+
+func newWindowPoStSelector(index stores.SectorIndex, sectors []abi.SectorID) *windowPoStSelector {
+	return &windowPoStSelector{
+		index:   index,
+		sectors: sectors,
+	}
+}
+
+func (s *windowPoStSelector) Ok(ctx context.Context, task types.TaskType, spt abi.RegisteredSealProof, sector storage.SectorRef, whnd *workerHandle) (bool, error) {
+	tasks, err := whnd.TaskTypes(ctx)
+	if err != nil {
+		return false, xerrors.Errorf("getting supported worker task types: %w", err)
+	}
+	if _, supported := tasks[task]; !supported {
+		return false, nil
+	}
+
+	// Check the number of tasks
+	if ok, err := whnd.canAccept(ctx, task); err != nil || !ok {
+		return false, err
+	}
+
+	return s.hasSectors(ctx, spt, whnd)
+}
+
+// hasSectors returns true if the worker storage holds the files of all the
+// sectors of the partition
+func (s *windowPoStSelector) hasSectors(ctx context.Context, spt abi.RegisteredSealProof, whnd *workerHandle) (bool, error) {
+	paths, err := whnd.workerRpc.Paths(ctx)
+	if err != nil {
+		return false, xerrors.Errorf("getting worker paths: %w", err)
+	}
+
+	have := map[stores.ID]struct{}{}
+	for _, path := range paths {
+		have[path.ID] = struct{}{}
+	}
+
+	ssize, err := spt.SectorSize()
+	if err != nil {
+		return false, xerrors.Errorf("getting sector size: %w", err)
+	}
+
+	for _, sid := range s.sectors {
+		// sealed and cache files may be in different paths of the worker
+		for _, ft := range []storiface.SectorFileType{storiface.FTSealed, storiface.FTCache} {
+			found, err := s.index.StorageFindSector(ctx, sid, ft, ssize, false)
+			if err != nil {
+				return false, xerrors.Errorf("finding sector %d: %w", sid.Number, err)
+			}
+
+			local := false
+			for _, info := range found {
+				if _, ok := have[info.ID]; ok {
+					local = true
+					break
+				}
+			}
+			if !local {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func (s *windowPoStSelector) Cmp(ctx context.Context, task types.TaskType, a, b *workerHandle) (bool, error) {
+	return a.utilization() < b.utilization(), nil
+}
+
+var _ WorkerSelector = &windowPoStSelector{}
@@ -1,6 +1,8 @@
 package sectorstorage
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,9 +13,9 @@ import (
 
 func (m *Manager) WorkerStats() map[uuid.UUID]storiface.WorkerStats {
 	m.sched.workersLk.RLock()
-	defer m.sched.workersLk.RUnlock()
 
 	out := map[uuid.UUID]storiface.WorkerStats{}
+	handles := map[uuid.UUID]*workerHandle{}
 
 	for id, handle := range m.sched.workers {
 		handle.lk.Lock()
@@ -27,8 +29,39 @@ func (m *Manager) WorkerStats() map[uuid.UUID]storiface.WorkerStats {
 			CpuUse:     handle.active.cpuUse,
 		}
 		handle.lk.Unlock()
+		handles[uuid.UUID(id)] = handle
 	}
 
+	m.sched.workersLk.RUnlock()
+
+	// ask the workers in parallel and without holding the scheduler lock, so
+	// that slow workers don't add up their timeouts
+	var (
+		wg    sync.WaitGroup
+		outLk sync.Mutex
+	)
+	for id, handle := range handles {
+		wg.Add(1)
+		go func(id uuid.UUID, handle *workerHandle) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.TODO(), SelectorTimeout)
+			capacity, err := handle.workerRpc.Capacity(ctx)
+			cancel()
+			if err != nil {
+				log.Warnf("getting capacity of worker %s: %s", id, err)
+				return
+			}
+
+			outLk.Lock()
+			stat := out[id]
+			stat.Capacity = capacity
+			out[id] = stat
+			outLk.Unlock()
+		}(id, handle)
+	}
+	wg.Wait()
+
 	return out
 }
 
@@ -0,0 +1,49 @@
+package sectorstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+)
+
+type slowCapacityWorker struct {
+	schedTestWorker
+	delay time.Duration
+}
+
+func (s *slowCapacityWorker) Capacity(ctx context.Context) (storiface.WorkerCapacity, error) {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return storiface.WorkerCapacity{}, ctx.Err()
+	}
+	return storiface.WorkerCapacity{Running: 1, Total: -1}, nil
+}
+
+func TestWorkerStatsParallel(t *testing.T) {
+	sched := &scheduler{workers: map[storiface.WorkerID]*workerHandle{}}
+	for i := 0; i < 10; i++ {
+		sched.workers[storiface.WorkerID(uuid.New())] = &workerHandle{
+			workerRpc: &slowCapacityWorker{delay: 200 * time.Millisecond},
+			active:    &activeResources{},
+			enabled:   true,
+		}
+	}
+
+	m := &Manager{sched: sched}
+
+	start := time.Now()
+	stats := m.WorkerStats()
+	require.Less(t, time.Since(start), time.Second)
+
+	require.Len(t, stats, 10)
+	for _, st := range stats {
+		require.True(t, st.Enabled)
+		require.Equal(t, int64(1), st.Capacity.Running)
+	}
+}
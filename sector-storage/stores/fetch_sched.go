@@ -0,0 +1,335 @@
+package stores
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// FetchLimits throttles the transfers of a Remote
+type FetchLimits struct {
+	// Parallel is the number of transfers running at the same time
+	Parallel int
+	// PerSource is the number of transfers running at the same time from a
+	// single storage, or host when the storage isn't known, 0 for no limit
+	PerSource int
+	// Bandwidth is the number of bytes per second received by all the
+	// transfers, 0 for no limit
+	Bandwidth int64
+	// DirFiles is the number of files of a cache directory fetched at the same
+	// time, each of them is a transfer
+	DirFiles int
+}
+
+// fetchTaskPriority orders the fetches preparing the tasks, the sooner the
+// sealing pipeline waits on a task the earlier its files are fetched. The
+// moves to long term storage and the other tasks come last.
+var fetchTaskPriority = map[types.TaskType]int{
+	types.TTUnseal:              400,
+	types.TTCommit1:             300,
+	types.TTProveReplicaUpdate1: 300,
+	types.TTPreCommit2:          200,
+	types.TTReplicaUpdate:       200,
+	types.TTPreCommit1:          100,
+	types.TTRegenSectorKey:      100,
+}
+
+// FetchUrgentEpochs is how close to its deadline a sector is urgent, its
+// fetches then go before the ones of all the sectors which aren't.
+var FetchUrgentEpochs = abi.ChainEpoch(builtin.EpochsInDay)
+
+// fetchUrgentPriority is above any task and scheduling priority
+const fetchUrgentPriority = 1 << 20
+
+// FetchPriority returns the priority of the fetches preparing a task of the
+// type. It adds up the priority of the task type, the scheduling priority of
+// ctx and, when the sector of ctx is close to its deadline (see
+// types.WithSectorDeadline), the closer the higher an urgency priority.
+func FetchPriority(ctx context.Context, tt types.TaskType) int {
+	priority := fetchTaskPriority[tt] + types.GetPriority(ctx)
+
+	if left, ok := types.GetSectorDeadline(ctx); ok && left < FetchUrgentEpochs {
+		if left < 0 {
+			left = 0
+		}
+		priority += fetchUrgentPriority + int(FetchUrgentEpochs-left)
+	}
+
+	return priority
+}
+
+// FetchInfo describes a transfer waiting in the fetch queue, or running
+type FetchInfo struct {
+	URL      string
+	Source   string
+	Priority int
+
+	Queued  time.Time
+	Started time.Time // zero while waiting
+
+	Received int64
+}
+
+type fetchRequest struct {
+	id       uint64
+	url      string
+	source   string
+	priority int
+
+	queued  time.Time
+	started time.Time
+
+	received int64 // atomic
+
+	ready chan struct{}
+}
+
+// fetchScheduler runs the transfers by priority, larger values first, then in
+// the order they were queued. A transfer whose source is at the PerSource limit
+// doesn't hold back the ones from other sources.
+type fetchScheduler struct {
+	limits FetchLimits
+	bw     *bandwidthLimiter
+
+	lk       sync.Mutex
+	nextID   uint64
+	queue    []*fetchRequest
+	running  map[uint64]*fetchRequest
+	bySource map[string]int
+}
+
+func newFetchScheduler(limits FetchLimits) *fetchScheduler {
+	if limits.Parallel <= 0 {
+		limits.Parallel = 1
+	}
+	if limits.DirFiles <= 0 {
+		limits.DirFiles = 1
+	}
+
+	return &fetchScheduler{
+		limits:   limits,
+		bw:       newBandwidthLimiter(limits.Bandwidth),
+		running:  map[uint64]*fetchRequest{},
+		bySource: map[string]int{},
+	}
+}
+
+// fetchSource returns the source a transfer is limited by, the storage when
+// it's known, the host of the url otherwise.
+func fetchSource(storageID ID, rawurl string) string {
+	if storageID != "" {
+		return string(storageID)
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Host == "" {
+		return rawurl
+	}
+	return u.Host
+}
+
+// acquire waits for the transfer to be allowed to run, the priority is taken
+// from ctx, the fetches scheduled by the manager carry the one computed by
+// FetchPriority. The returned func must be called when the transfer is done.
+func (fs *fetchScheduler) acquire(ctx context.Context, rawurl, source string) (*fetchRequest, func(), error) {
+	fs.lk.Lock()
+	fs.nextID++
+	req := &fetchRequest{
+		id:       fs.nextID,
+		url:      rawurl,
+		source:   source,
+		priority: types.GetPriority(ctx),
+		queued:   time.Now(),
+		ready:    make(chan struct{}),
+	}
+	fs.queue = append(fs.queue, req)
+	sort.SliceStable(fs.queue, func(i, j int) bool {
+		return fs.queue[i].priority > fs.queue[j].priority
+	})
+	fs.dispatch()
+	if req.started.IsZero() {
+		log.Infow("throttling fetch", "url", rawurl, "source", source, "priority", req.priority,
+			"running", len(fs.running), "queued", len(fs.queue))
+	}
+	fs.lk.Unlock()
+
+	select {
+	case <-req.ready:
+	case <-ctx.Done():
+		fs.lk.Lock()
+		select {
+		case <-req.ready:
+			// started meanwhile
+			fs.release(req)
+		default:
+			for i, queued := range fs.queue {
+				if queued == req {
+					fs.queue = append(fs.queue[:i], fs.queue[i+1:]...)
+					break
+				}
+			}
+		}
+		fs.lk.Unlock()
+		return nil, nil, xerrors.Errorf("context error while waiting for fetch limiter: %w", ctx.Err())
+	}
+
+	var once sync.Once
+	return req, func() {
+		once.Do(func() {
+			fs.lk.Lock()
+			fs.release(req)
+			fs.lk.Unlock()
+		})
+	}, nil
+}
+
+// dispatch starts the queued transfers allowed to run, fs.lk must be held
+func (fs *fetchScheduler) dispatch() {
+	for i := 0; i < len(fs.queue) && len(fs.running) < fs.limits.Parallel; {
+		req := fs.queue[i]
+		if fs.limits.PerSource > 0 && fs.bySource[req.source] >= fs.limits.PerSource {
+			i++
+			continue
+		}
+
+		fs.queue = append(fs.queue[:i], fs.queue[i+1:]...)
+		fs.running[req.id] = req
+		fs.bySource[req.source]++
+		req.started = time.Now()
+		close(req.ready)
+	}
+}
+
+// release ends a running transfer, fs.lk must be held
+func (fs *fetchScheduler) release(req *fetchRequest) {
+	delete(fs.running, req.id)
+	fs.bySource[req.source]--
+	if fs.bySource[req.source] <= 0 {
+		delete(fs.bySource, req.source)
+	}
+	fs.dispatch()
+}
+
+// reader wraps the body of a running transfer to count the bytes received and
+// apply the bandwidth limit.
+func (fs *fetchScheduler) reader(ctx context.Context, req *fetchRequest, r io.Reader) io.Reader {
+	return &fetchReader{ctx: ctx, r: r, req: req, bw: fs.bw}
+}
+
+// list returns the running transfers, then the queued ones in the order they
+// will start.
+func (fs *fetchScheduler) list() []FetchInfo {
+	fs.lk.Lock()
+	defer fs.lk.Unlock()
+
+	out := make([]FetchInfo, 0, len(fs.running)+len(fs.queue))
+	for _, req := range fs.running {
+		out = append(out, req.info())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Started.Before(out[j].Started)
+	})
+	for _, req := range fs.queue {
+		out = append(out, req.info())
+	}
+	return out
+}
+
+func (req *fetchRequest) info() FetchInfo {
+	return FetchInfo{
+		URL:      req.url,
+		Source:   req.source,
+		Priority: req.priority,
+		Queued:   req.queued,
+		Started:  req.started,
+		Received: atomic.LoadInt64(&req.received),
+	}
+}
+
+type fetchReader struct {
+	ctx context.Context
+	r   io.Reader
+	req *fetchRequest
+	bw  *bandwidthLimiter
+}
+
+func (fr *fetchReader) Read(p []byte) (int, error) {
+	if max := fr.bw.maxRead(); max > 0 && len(p) > max {
+		p = p[:max]
+	}
+	n, err := fr.r.Read(p)
+	atomic.AddInt64(&fr.req.received, int64(n))
+	if werr := fr.bw.wait(fr.ctx, n); werr != nil && err == nil {
+		err = werr
+	}
+	return n, err
+}
+
+// bandwidthLimiter is a token bucket shared by the transfers of a Remote, it
+// holds at most one second worth of bytes.
+type bandwidthLimiter struct {
+	rate int64 // bytes per second, 0 for no limit
+
+	lk     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newBandwidthLimiter(rate int64) *bandwidthLimiter {
+	return &bandwidthLimiter{rate: rate, tokens: float64(rate), last: time.Now()}
+}
+
+// maxRead is the size of the reads keeping the transfers smooth, 0 when
+// they're not limited
+func (bl *bandwidthLimiter) maxRead() int {
+	if bl.rate <= 0 {
+		return 0
+	}
+	max := bl.rate / 10
+	if max < 4<<10 {
+		max = 4 << 10
+	}
+	if max > int64(CopyBuf) {
+		max = int64(CopyBuf)
+	}
+	return int(max)
+}
+
+// wait takes n bytes from the bucket, waiting until they are available
+func (bl *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	if bl.rate <= 0 || n <= 0 {
+		return nil
+	}
+
+	bl.lk.Lock()
+	now := time.Now()
+	bl.tokens += now.Sub(bl.last).Seconds() * float64(bl.rate)
+	if bl.tokens > float64(bl.rate) {
+		bl.tokens = float64(bl.rate)
+	}
+	bl.last = now
+	bl.tokens -= float64(n)
+	delay := time.Duration(-bl.tokens / float64(bl.rate) * float64(time.Second))
+	bl.lk.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,147 @@
+package stores
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestFetchSchedulerPriority(t *testing.T) {
+	fs := newFetchScheduler(FetchLimits{Parallel: 1})
+	ctx := context.Background()
+
+	_, done, err := fs.acquire(ctx, "http://a/first", "a")
+	require.NoError(t, err)
+
+	started := make(chan string, 3)
+	queue := func(url string, priority int) {
+		go func() {
+			_, done, err := fs.acquire(types.WithPriority(ctx, priority), url, "a")
+			if err != nil {
+				started <- err.Error()
+				return
+			}
+			started <- url
+			done()
+		}()
+		require.Eventually(t, func() bool {
+			for _, fetch := range fs.list() {
+				if fetch.URL == url {
+					return true
+				}
+			}
+			return false
+		}, time.Second, time.Millisecond)
+	}
+	queue("http://a/finalize", 0)
+	queue("http://a/c1", 10)
+	queue("http://a/other", 0)
+
+	list := fs.list()
+	require.Len(t, list, 4)
+	require.False(t, list[0].Started.IsZero())
+	require.Equal(t, []string{"http://a/c1", "http://a/finalize", "http://a/other"},
+		[]string{list[1].URL, list[2].URL, list[3].URL})
+
+	done()
+	require.Equal(t, "http://a/c1", <-started)
+	require.Equal(t, "http://a/finalize", <-started)
+	require.Equal(t, "http://a/other", <-started)
+	require.Empty(t, fs.list())
+}
+
+func TestFetchPriority(t *testing.T) {
+	ctx := context.Background()
+	deal := types.WithPriority(ctx, types.DealSectorPriority)
+
+	// the task type orders the fetches of the sectors far from their deadline
+	require.Greater(t, FetchPriority(ctx, types.TTCommit1), FetchPriority(ctx, types.TTPreCommit2))
+	require.Greater(t, FetchPriority(ctx, types.TTPreCommit2), FetchPriority(ctx, types.TTFinalize))
+	require.Equal(t, FetchPriority(ctx, types.TTFinalize), FetchPriority(ctx, types.TTFetch))
+	require.Equal(t, FetchPriority(types.WithSectorDeadline(ctx, 10*FetchUrgentEpochs), types.TTCommit1), FetchPriority(ctx, types.TTCommit1))
+
+	// an urgent sector goes before the deal sectors, the closer the earlier
+	urgent := FetchPriority(types.WithSectorDeadline(ctx, FetchUrgentEpochs/2), types.TTCommit1)
+	require.Greater(t, urgent, FetchPriority(deal, types.TTFinalize))
+	require.Greater(t, urgent, FetchPriority(deal, types.TTCommit1))
+	require.Greater(t, FetchPriority(types.WithSectorDeadline(ctx, 10), types.TTCommit1), urgent)
+	require.Equal(t, FetchPriority(types.WithSectorDeadline(ctx, -5), types.TTCommit1), FetchPriority(types.WithSectorDeadline(ctx, 0), types.TTCommit1))
+}
+
+func TestFetchSchedulerPerSource(t *testing.T) {
+	fs := newFetchScheduler(FetchLimits{Parallel: 3, PerSource: 1})
+	ctx := context.Background()
+
+	_, doneA, err := fs.acquire(ctx, "http://a/1", "a")
+	require.NoError(t, err)
+
+	// a is at its limit, b isn't held back
+	waiting := make(chan func())
+	go func() {
+		_, done, err := fs.acquire(ctx, "http://a/2", "a")
+		require.NoError(t, err)
+		waiting <- done
+	}()
+	require.Eventually(t, func() bool { return len(fs.list()) == 2 }, time.Second, time.Millisecond)
+
+	_, doneB, err := fs.acquire(ctx, "http://b/1", "b")
+	require.NoError(t, err)
+
+	select {
+	case <-waiting:
+		t.Fatal("second fetch from a started")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	doneA()
+	(<-waiting)()
+	doneB()
+	require.Empty(t, fs.list())
+}
+
+func TestFetchSchedulerCancel(t *testing.T) {
+	fs := newFetchScheduler(FetchLimits{Parallel: 1})
+
+	_, done, err := fs.acquire(context.Background(), "http://a/1", "a")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = fs.acquire(ctx, "http://a/2", "a")
+	require.Error(t, err)
+	require.Len(t, fs.list(), 1)
+
+	done()
+	done() // released once
+	_, done, err = fs.acquire(context.Background(), "http://a/3", "a")
+	require.NoError(t, err)
+	done()
+}
+
+func TestFetchSource(t *testing.T) {
+	require.Equal(t, "storage", fetchSource("storage", "http://host:3456/remote/sealed/s-t01000-1"))
+	require.Equal(t, "host:3456", fetchSource("", "http://host:3456/remote/sealed/s-t01000-1"))
+}
+
+func TestFetchBandwidth(t *testing.T) {
+	fs := newFetchScheduler(FetchLimits{Parallel: 1, Bandwidth: 64 << 10})
+	ctx := context.Background()
+
+	req, done, err := fs.acquire(ctx, "http://a/1", "a")
+	require.NoError(t, err)
+	defer done()
+
+	// the first second worth is in the bucket, the second one is limited
+	start := time.Now()
+	data, err := ioutil.ReadAll(fs.reader(ctx, req, bytes.NewReader(make([]byte, 128<<10))))
+	require.NoError(t, err)
+	require.Len(t, data, 128<<10)
+	require.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond)
+	require.Equal(t, int64(128<<10), fs.list()[0].Received)
+}
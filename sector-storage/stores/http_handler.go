@@ -1,10 +1,16 @@
 package stores
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	logging "github.com/ipfs/go-log/v2"
@@ -20,6 +26,22 @@ import (
 
 var log = logging.Logger("stores")
 
+// Headers of checksummed and resumable sector fetches, see Remote.fetch
+const (
+	// FetchChecksumHeader is set to "sha256" by clients verifying what they fetch
+	FetchChecksumHeader = "X-Fetch-Checksum"
+	// FetchResumeHeader holds the json map of the file sizes a client already
+	// has of a cache directory
+	FetchResumeHeader = "X-Fetch-Resume"
+	// ChecksumTrailer holds the sha256 of the part of a sector file sent, the
+	// whole file unless the fetch is resumed
+	ChecksumTrailer = "X-Sha256"
+	// PrefixChecksumTrailer holds the sha256 of the part of a sector file
+	// before a resumed range, sent as a header of the 416 response when the
+	// client already has the whole file
+	PrefixChecksumTrailer = "X-Sha256-Prefix"
+)
+
 var _ PartialFileHandler = &DefaultPartialFileHandler{}
 
 // DefaultPartialFileHandler is the default implementation of the PartialFileHandler interface.
@@ -54,6 +76,8 @@ func (handler *FetchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	mux.HandleFunc("/remote/stat/{id}", handler.remoteStatFs).Methods("GET")
 	mux.HandleFunc("/remote/{type}/{id}/{spt}/allocated/{offset}/{size}", handler.remoteGetAllocated).Methods("GET")
 	mux.HandleFunc("/remote/{type}/{id}", handler.remoteGetSector).Methods("GET")
+	mux.HandleFunc("/remote/{type}/{id}/manifest", handler.remoteGetManifest).Methods("GET")
+	mux.HandleFunc("/remote/{type}/{id}/file/{name}", handler.remoteGetDirFile).Methods("GET")
 	mux.HandleFunc("/remote/{type}/{id}", handler.remoteDeleteSector).Methods("DELETE")
 
 	mux.ServeHTTP(w, r)
@@ -81,24 +105,23 @@ func (handler *FetchHandler) remoteStatFs(w http.ResponseWriter, r *http.Request
 	}
 }
 
-// remoteGetSector returns the sector file/tared directory byte stream for the sectorID and sector file type sent in the request.
-// returns an error if it does NOT have the required sector file/dir.
-func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Request) {
-	log.Infof("SERVE GET %s", r.URL)
+// sectorPath returns the local path of the sector file/dir of the request, or
+// writes the error response and returns false.
+func (handler *FetchHandler) sectorPath(w http.ResponseWriter, r *http.Request) (string, bool) {
 	vars := mux.Vars(r)
 
 	id, err := storiface.ParseSectorID(vars["id"])
 	if err != nil {
 		log.Errorf("%+v", err)
 		w.WriteHeader(500)
-		return
+		return "", false
 	}
 
 	ft, err := ftFromString(vars["type"])
 	if err != nil {
 		log.Errorf("%+v", err)
 		w.WriteHeader(500)
-		return
+		return "", false
 	}
 
 	// The caller has a lock on this sector already, no need to get one here
@@ -112,7 +135,7 @@ func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Requ
 	if err != nil {
 		log.Errorf("AcquireSector: %+v", err)
 		w.WriteHeader(500)
-		return
+		return "", false
 	}
 
 	// TODO: reserve local storage here
@@ -121,6 +144,19 @@ func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Requ
 	if path == "" {
 		log.Error("acquired path was empty")
 		w.WriteHeader(500)
+		return "", false
+	}
+
+	return path, true
+}
+
+// remoteGetSector returns the sector file/tared directory byte stream for the sectorID and sector file type sent in the request.
+// returns an error if it does NOT have the required sector file/dir.
+func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Request) {
+	log.Infof("SERVE GET %s", r.URL)
+
+	path, ok := handler.sectorPath(w, r)
+	if !ok {
 		return
 	}
 
@@ -131,6 +167,8 @@ func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	checksum := r.Header.Get(FetchChecksumHeader) == "sha256"
+
 	if stat.IsDir() {
 		if _, has := r.Header["Range"]; has {
 			log.Error("Range not supported on directories")
@@ -138,21 +176,206 @@ func (handler *FetchHandler) remoteGetSector(w http.ResponseWriter, r *http.Requ
 			return
 		}
 
+		var have map[string]int64
+		if resume := r.Header.Get(FetchResumeHeader); resume != "" {
+			if err := json.Unmarshal([]byte(resume), &have); err != nil {
+				log.Errorf("parsing %s: %+v", FetchResumeHeader, err)
+				w.WriteHeader(400)
+				return
+			}
+		}
+
 		w.Header().Set("Content-Type", "application/x-tar")
 		w.WriteHeader(200)
 
-		err := tarutil.TarDirectory(path, w, make([]byte, CopyBuf))
+		if checksum {
+			err = tarutil.TarDirectoryResume(path, w, make([]byte, CopyBuf), have)
+		} else {
+			err = tarutil.TarDirectory(path, w, make([]byte, CopyBuf))
+		}
 		if err != nil {
 			log.Errorf("send tar: %+v", err)
 			return
 		}
 	} else {
 		w.Header().Set("Content-Type", "application/octet-stream")
-		// will do a ranged read over the file at the given path if the caller has asked for a ranged read in the request headers.
+		if offset, ok := openRangeStart(r.Header.Get("Range")); checksum && ok {
+			serveFileChecksummed(w, path, offset, stat.Size())
+		} else {
+			// will do a ranged read over the file at the given path if the caller has asked for a ranged read in the request headers.
+			http.ServeFile(w, r, path)
+		}
+	}
+
+	log.Debugf("served sector file/dir, path=%s", path)
+}
+
+// remoteGetManifest returns the tarutil.Manifest of a sector directory, the
+// files of which are then fetched one by one with remoteGetDirFile.
+func (handler *FetchHandler) remoteGetManifest(w http.ResponseWriter, r *http.Request) {
+	log.Infof("SERVE GET %s", r.URL)
+
+	path, ok := handler.sectorPath(w, r)
+	if !ok {
+		return
+	}
+	serveManifest(w, path)
+}
+
+func serveManifest(w http.ResponseWriter, dir string) {
+	stat, err := os.Stat(dir)
+	if err != nil {
+		log.Errorf("os.Stat: %+v", err)
+		w.WriteHeader(500)
+		return
+	}
+	if !stat.IsDir() {
+		log.Errorf("manifest of %s: not a directory", dir)
+		w.WriteHeader(400)
+		return
+	}
+
+	manifest, err := tarutil.DirManifest(dir)
+	if err != nil {
+		log.Errorf("manifest of %s: %+v", dir, err)
+		w.WriteHeader(500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(manifest); err != nil {
+		log.Warnf("error writing manifest response: %+v", err)
+	}
+}
+
+// remoteGetDirFile returns a file of a sector directory, as remoteGetSector
+// returns sector files.
+func (handler *FetchHandler) remoteGetDirFile(w http.ResponseWriter, r *http.Request) {
+	log.Infof("SERVE GET %s", r.URL)
+
+	path, ok := handler.sectorPath(w, r)
+	if !ok {
+		return
+	}
+	serveDirFile(w, r, path, mux.Vars(r)["name"])
+}
+
+func serveDirFile(w http.ResponseWriter, r *http.Request, dir, name string) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		log.Errorf("invalid file name %q", name)
+		w.WriteHeader(400)
+		return
+	}
+
+	path := filepath.Join(dir, name)
+	stat, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			w.WriteHeader(404)
+			return
+		}
+		log.Errorf("os.Stat: %+v", err)
+		w.WriteHeader(500)
+		return
+	}
+	if !stat.Mode().IsRegular() {
+		log.Errorf("%s isn't a regular file", path)
+		w.WriteHeader(400)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if offset, ok := openRangeStart(r.Header.Get("Range")); r.Header.Get(FetchChecksumHeader) == "sha256" && ok {
+		serveFileChecksummed(w, path, offset, stat.Size())
+	} else {
 		http.ServeFile(w, r, path)
 	}
+}
 
-	log.Debugf("served sector file/dir, sectorID=%+v, fileType=%s, path=%s", id, ft, path)
+// openRangeStart parses a "bytes=<start>-" range, the only one sent by
+// resumed fetches. An empty range starts at 0.
+func openRangeStart(rng string) (int64, bool) {
+	if rng == "" {
+		return 0, true
+	}
+	if !strings.HasPrefix(rng, "bytes=") || !strings.HasSuffix(rng, "-") {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"), 10, 64)
+	if err != nil || start < 0 {
+		return 0, false
+	}
+	return start, true
+}
+
+// serveFileChecksummed sends the file from offset, with the sha256 of the
+// bytes sent in the ChecksumTrailer. The sha256 of the start of the file the
+// client already has is computed while sending and put in the
+// PrefixChecksumTrailer, so that resumed fetches verify the whole file.
+func serveFileChecksummed(w http.ResponseWriter, path string, offset, size int64) {
+	if offset >= size && offset > 0 {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		if offset == size {
+			// the client has the whole file, it only needs to verify it
+			h, err := tarutil.HashFilePrefix(path, size, make([]byte, CopyBuf))
+			if err != nil {
+				log.Errorf("hashing %s: %+v", path, err)
+				w.WriteHeader(500)
+				return
+			}
+			w.Header().Set(PrefixChecksumTrailer, hex.EncodeToString(h.Sum(nil)))
+		}
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Errorf("opening %s: %+v", path, err)
+		w.WriteHeader(500)
+		return
+	}
+	defer f.Close() // nolint
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		log.Errorf("seeking %s: %+v", path, err)
+		w.WriteHeader(500)
+		return
+	}
+
+	prefix := make(chan string, 1)
+	if offset > 0 {
+		go func() {
+			h, err := tarutil.HashFilePrefix(path, offset, make([]byte, CopyBuf))
+			if err != nil {
+				log.Errorf("hashing start of %s: %+v", path, err)
+				prefix <- ""
+				return
+			}
+			prefix <- hex.EncodeToString(h.Sum(nil))
+		}()
+	}
+
+	// no Content-Length, trailers are only sent with chunked encoding
+	if offset > 0 {
+		w.Header().Set("Trailer", ChecksumTrailer+", "+PrefixChecksumTrailer)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, size-1, size))
+		w.WriteHeader(http.StatusPartialContent)
+	} else {
+		w.Header().Set("Trailer", ChecksumTrailer)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	h := sha256.New()
+	if _, err := io.CopyBuffer(io.MultiWriter(w, h), io.LimitReader(f, size-offset), make([]byte, CopyBuf)); err != nil {
+		log.Errorf("send file %s: %+v", path, err)
+		return
+	}
+	w.Header().Set(ChecksumTrailer, hex.EncodeToString(h.Sum(nil)))
+	if offset > 0 {
+		// an empty trailer makes the client start over
+		w.Header().Set(PrefixChecksumTrailer, <-prefix)
+	}
 }
 
 func (handler *FetchHandler) remoteDeleteSector(w http.ResponseWriter, r *http.Request) {
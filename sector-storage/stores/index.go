@@ -116,12 +116,19 @@ type storageEntry struct {
 
 	lastHeartbeat time.Time
 	heartbeatErr  error
+
+	// restored is set for entries loaded from the IndexStore which were not
+	// attached again since the sealer started
+	restored bool
 }
 
 type Index struct {
 	*indexLocks
 	lk sync.RWMutex
 
+	// store persists attached paths and sector declarations, nil for a memory only index
+	store IndexStore
+
 	sectors map[Decl][]*declMeta
 	stores  map[ID]*storageEntry
 }
@@ -136,6 +143,43 @@ func NewIndex() *Index {
 	}
 }
 
+// NewPersistentIndex creates an index backed by store, paths and sectors
+// declared before the last shutdown are loaded from it. Restored paths are
+// only used for allocation after their first heartbeat, and their sector
+// declarations are replaced when the path is attached again.
+func NewPersistentIndex(ctx context.Context, store IndexStore) (*Index, error) {
+	i := NewIndex()
+
+	paths, err := store.ListStorage(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("loading storage paths: %w", err)
+	}
+	for _, p := range paths {
+		info := p.Info
+		i.stores[info.ID] = &storageEntry{
+			info:     &info,
+			fsi:      p.Stat,
+			restored: true,
+		}
+	}
+
+	decls, err := store.ListDecls(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("loading sector declarations: %w", err)
+	}
+	for _, d := range decls {
+		i.sectors[d.Decl] = append(i.sectors[d.Decl], &declMeta{
+			storage: d.Storage,
+			primary: d.Primary,
+		})
+	}
+
+	log.Infof("restored sector index: %d storage paths, %d sector declarations", len(paths), len(decls))
+
+	i.store = store
+	return i, nil
+}
+
 func (i *Index) StorageList(ctx context.Context) (map[ID][]Decl, error) {
 	i.lk.RLock()
 	defer i.lk.RUnlock()
@@ -171,33 +215,73 @@ func (i *Index) StorageAttach(ctx context.Context, si StorageInfo, st fsutil.FsS
 
 	log.Infof("New sector storage: %s", si.ID)
 
-	if _, ok := i.stores[si.ID]; ok {
+	if ent, ok := i.stores[si.ID]; ok && !ent.restored {
 		for _, u := range si.URLs {
 			if _, err := url.Parse(u); err != nil {
 				return xerrors.Errorf("failed to parse url %s: %w", si.URLs, err)
 			}
 		}
 
+		info := *ent.info
+		info.URLs = append([]string{}, ent.info.URLs...)
+
 	uloop:
 		for _, u := range si.URLs {
-			for _, l := range i.stores[si.ID].info.URLs {
+			for _, l := range info.URLs {
 				if u == l {
 					continue uloop
 				}
 			}
 
-			i.stores[si.ID].info.URLs = append(i.stores[si.ID].info.URLs, u)
+			info.URLs = append(info.URLs, u)
 		}
 
-		i.stores[si.ID].info.Weight = si.Weight
-		i.stores[si.ID].info.MaxStorage = si.MaxStorage
-		i.stores[si.ID].info.CanSeal = si.CanSeal
-		i.stores[si.ID].info.CanStore = si.CanStore
-		i.stores[si.ID].info.Groups = si.Groups
-		i.stores[si.ID].info.AllowTo = si.AllowTo
+		info.Weight = si.Weight
+		info.MaxStorage = si.MaxStorage
+		info.CanSeal = si.CanSeal
+		info.CanStore = si.CanStore
+		info.Groups = si.Groups
+		info.AllowTo = si.AllowTo
+
+		if i.store != nil {
+			if err := i.store.SaveStorage(ctx, info, ent.fsi); err != nil {
+				return xerrors.Errorf("persisting storage %s: %w", si.ID, err)
+			}
+		}
 
+		*ent.info = info
 		return nil
 	}
+
+	if ent, ok := i.stores[si.ID]; ok && ent.restored {
+		// the path was known before restart, the attaching node redeclares every
+		// sector it has right after attaching, so stale declarations are dropped
+		// and its urls are replaced instead of merged
+		if i.store != nil {
+			if err := i.store.ResetStorage(ctx, si, st); err != nil {
+				return xerrors.Errorf("resetting storage %s: %w", si.ID, err)
+			}
+		}
+
+		for d, metas := range i.sectors {
+			rewritten := make([]*declMeta, 0, len(metas))
+			for _, meta := range metas {
+				if meta.storage != si.ID {
+					rewritten = append(rewritten, meta)
+				}
+			}
+			if len(rewritten) == 0 {
+				delete(i.sectors, d)
+				continue
+			}
+			i.sectors[d] = rewritten
+		}
+	} else if i.store != nil {
+		if err := i.store.SaveStorage(ctx, si, st); err != nil {
+			return xerrors.Errorf("persisting storage %s: %w", si.ID, err)
+		}
+	}
+
 	i.stores[si.ID] = &storageEntry{
 		info: &si,
 		fsi:  st,
@@ -242,6 +326,11 @@ loop:
 		for _, sid := range i.sectors[d] {
 			if sid.storage == storageID {
 				if !sid.primary && primary {
+					if i.store != nil {
+						if err := i.store.DeclareSector(ctx, storageID, d, true); err != nil {
+							return xerrors.Errorf("persisting sector %v in %s: %w", s, storageID, err)
+						}
+					}
 					sid.primary = true
 				} else {
 					log.Warnf("sector %v redeclared in %s", s, storageID)
@@ -250,6 +339,12 @@ loop:
 			}
 		}
 
+		if i.store != nil {
+			if err := i.store.DeclareSector(ctx, storageID, d, primary); err != nil {
+				return xerrors.Errorf("persisting sector %v in %s: %w", s, storageID, err)
+			}
+		}
+
 		i.sectors[d] = append(i.sectors[d], &declMeta{
 			storage: storageID,
 			primary: primary,
@@ -282,6 +377,11 @@ func (i *Index) StorageDropSector(ctx context.Context, storageID ID, s abi.Secto
 
 			rewritten = append(rewritten, sid)
 		}
+		if i.store != nil && len(rewritten) != len(i.sectors[d]) {
+			if err := i.store.DropSector(ctx, storageID, d); err != nil {
+				return xerrors.Errorf("dropping persisted sector %v in %s: %w", s, storageID, err)
+			}
+		}
 		if len(rewritten) == 0 {
 			delete(i.sectors, d)
 			continue
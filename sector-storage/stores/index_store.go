@@ -0,0 +1,34 @@
+package stores
+
+import (
+	"context"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
+)
+
+// StorageRecord is a storage path as saved in an IndexStore
+type StorageRecord struct {
+	Info StorageInfo
+	Stat fsutil.FsStat
+}
+
+// DeclRecord is a sector declaration as saved in an IndexStore
+type DeclRecord struct {
+	Decl
+	Storage ID
+	Primary bool
+}
+
+// IndexStore persists attached storage paths and sector declarations of an
+// Index, so that the sealer knows where sectors are right after a restart
+type IndexStore interface {
+	ListStorage(ctx context.Context) ([]StorageRecord, error)
+	ListDecls(ctx context.Context) ([]DeclRecord, error)
+
+	SaveStorage(ctx context.Context, si StorageInfo, st fsutil.FsStat) error
+	// ResetStorage saves the storage path and drops all sectors declared in it
+	ResetStorage(ctx context.Context, si StorageInfo, st fsutil.FsStat) error
+
+	DeclareSector(ctx context.Context, storageID ID, d Decl, primary bool) error
+	DropSector(ctx context.Context, storageID ID, d Decl) error
+}
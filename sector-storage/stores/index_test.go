@@ -152,3 +152,134 @@ func TestFindAllow(t *testing.T) {
 		}
 	}
 }
+
+type testIndexStore struct {
+	storage map[ID]StorageRecord
+	decls   map[ID]map[Decl]bool
+}
+
+func newTestIndexStore() *testIndexStore {
+	return &testIndexStore{
+		storage: map[ID]StorageRecord{},
+		decls:   map[ID]map[Decl]bool{},
+	}
+}
+
+func (s *testIndexStore) ListStorage(ctx context.Context) ([]StorageRecord, error) {
+	var out []StorageRecord
+	for _, r := range s.storage {
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (s *testIndexStore) ListDecls(ctx context.Context) ([]DeclRecord, error) {
+	var out []DeclRecord
+	for id, decls := range s.decls {
+		for d, primary := range decls {
+			out = append(out, DeclRecord{Decl: d, Storage: id, Primary: primary})
+		}
+	}
+	return out, nil
+}
+
+func (s *testIndexStore) SaveStorage(ctx context.Context, si StorageInfo, st fsutil.FsStat) error {
+	s.storage[si.ID] = StorageRecord{Info: si, Stat: st}
+	return nil
+}
+
+func (s *testIndexStore) ResetStorage(ctx context.Context, si StorageInfo, st fsutil.FsStat) error {
+	s.storage[si.ID] = StorageRecord{Info: si, Stat: st}
+	delete(s.decls, si.ID)
+	return nil
+}
+
+func (s *testIndexStore) DeclareSector(ctx context.Context, storageID ID, d Decl, primary bool) error {
+	if s.decls[storageID] == nil {
+		s.decls[storageID] = map[Decl]bool{}
+	}
+	s.decls[storageID][d] = primary
+	return nil
+}
+
+func (s *testIndexStore) DropSector(ctx context.Context, storageID ID, d Decl) error {
+	delete(s.decls[storageID], d)
+	return nil
+}
+
+func TestPersistentIndexRestore(t *testing.T) {
+	ctx := context.Background()
+	store := newTestIndexStore()
+
+	i, err := NewPersistentIndex(ctx, store)
+	require.NoError(t, err)
+
+	stor1 := newTestStorage()
+	stor1.URLs = []string{"http://node1/remote"}
+	stor2 := newTestStorage()
+
+	require.NoError(t, i.StorageAttach(ctx, stor1, bigFsStat))
+	require.NoError(t, i.StorageAttach(ctx, stor2, bigFsStat))
+
+	s1 := abi.SectorID{Miner: 12, Number: 34}
+	s2 := abi.SectorID{Miner: 12, Number: 35}
+
+	require.NoError(t, i.StorageDeclareSector(ctx, stor1.ID, s1, storiface.FTSealed|storiface.FTCache, true))
+	require.NoError(t, i.StorageDeclareSector(ctx, stor1.ID, s2, storiface.FTSealed, true))
+	require.NoError(t, i.StorageDeclareSector(ctx, stor2.ID, s2, storiface.FTUnsealed, false))
+	require.NoError(t, i.StorageDropSector(ctx, stor2.ID, s2, storiface.FTUnsealed))
+
+	// restart
+	i, err = NewPersistentIndex(ctx, store)
+	require.NoError(t, err)
+
+	{
+		si, err := i.StorageFindSector(ctx, s1, storiface.FTSealed, s32g, false)
+		require.NoError(t, err)
+		require.Len(t, si, 1)
+		require.Equal(t, stor1.ID, si[0].ID)
+		require.True(t, si[0].Primary)
+	}
+
+	{
+		si, err := i.StorageFindSector(ctx, s2, storiface.FTUnsealed, s32g, false)
+		require.NoError(t, err)
+		require.Len(t, si, 0)
+	}
+
+	// restored paths are not used for allocation before a heartbeat
+	{
+		_, err := i.StorageBestAlloc(ctx, storiface.FTSealed, s32g, storiface.PathSealing)
+		require.Error(t, err)
+
+		require.NoError(t, i.StorageReportHealth(ctx, stor2.ID, HealthReport{Stat: bigFsStat}))
+
+		si, err := i.StorageBestAlloc(ctx, storiface.FTSealed, s32g, storiface.PathSealing)
+		require.NoError(t, err)
+		require.Len(t, si, 1)
+		require.Equal(t, stor2.ID, si[0].ID)
+	}
+
+	// reattaching replaces urls and drops sectors which are not redeclared
+	stor1.URLs = []string{"http://node2/remote"}
+	require.NoError(t, i.StorageAttach(ctx, stor1, bigFsStat))
+	require.NoError(t, i.StorageDeclareSector(ctx, stor1.ID, s1, storiface.FTSealed, true))
+
+	{
+		info, err := i.StorageInfo(ctx, stor1.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"http://node2/remote"}, info.URLs)
+
+		si, err := i.StorageFindSector(ctx, s2, storiface.FTSealed, s32g, false)
+		require.NoError(t, err)
+		require.Len(t, si, 0)
+
+		si, err = i.StorageFindSector(ctx, s1, storiface.FTCache, s32g, false)
+		require.NoError(t, err)
+		require.Len(t, si, 0)
+	}
+
+	decls, err := store.ListDecls(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []DeclRecord{{Decl: Decl{s1, storiface.FTSealed}, Storage: stor1.ID, Primary: true}}, decls)
+}
@@ -11,11 +11,14 @@ import (
 	"sync"
 	"time"
 
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/specs-storage/storage"
 
+	"github.com/filecoin-project/venus-sealer/metrics"
 	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 )
@@ -356,7 +359,9 @@ func (st *Local) reportStorage(ctx context.Context) {
 	st.localLk.RLock()
 
 	toReport := map[ID]HealthReport{}
+	localPaths := map[ID]string{}
 	for id, p := range st.paths {
+		localPaths[id] = p.local
 		stat, err := p.stat(st.localStorage)
 		r := HealthReport{Stat: stat}
 		if err != nil {
@@ -369,6 +374,16 @@ func (st *Local) reportStorage(ctx context.Context) {
 	st.localLk.RUnlock()
 
 	for id, report := range toReport {
+		if report.Err == "" {
+			_ = stats.RecordWithTags(ctx, []tag.Mutator{
+				tag.Upsert(metrics.StorageID, string(id)),
+				tag.Upsert(metrics.StoragePath, localPaths[id]),
+			},
+				metrics.StorageCapacity.M(report.Stat.Capacity),
+				metrics.StorageAvailable.M(report.Stat.Available),
+			)
+		}
+
 		if err := st.index.StorageReportHealth(ctx, id, report); err != nil {
 			log.Warnf("error reporting storage health for %s (%+v): %+v", id, report, err)
 		}
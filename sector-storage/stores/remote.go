@@ -2,8 +2,11 @@ package stores
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"math/bits"
@@ -14,8 +17,14 @@ import (
 	gopath "path"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/filecoin-project/venus-sealer/metrics"
 	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 	"github.com/filecoin-project/venus-sealer/sector-storage/tarutil"
@@ -31,12 +40,16 @@ var FetchTempSubdir = "fetching"
 
 var CopyBuf = 1 << 20
 
+// FetchAttempts is the number of times an interrupted fetch is resumed from
+// the same URL before trying the next one
+var FetchAttempts = 3
+
 type Remote struct {
 	local Store
 	index SectorIndex
 	auth  http.Header
 
-	limit chan struct{}
+	fetches *fetchScheduler
 
 	fetchLk  sync.Mutex
 	fetching map[abi.SectorID]chan struct{}
@@ -76,13 +89,13 @@ func (r *Remote) RemoveCopies(ctx context.Context, s abi.SectorID, typ storiface
 	return r.Remove(ctx, s, typ, true, keep)
 }
 
-func NewRemote(local Store, index SectorIndex, auth http.Header, fetchLimit int, pfHandler PartialFileHandler) *Remote {
+func NewRemote(local Store, index SectorIndex, auth http.Header, fetchLimits FetchLimits, pfHandler PartialFileHandler) *Remote {
 	return &Remote{
 		local: local,
 		index: index,
 		auth:  auth,
 
-		limit: make(chan struct{}, fetchLimit),
+		fetches: newFetchScheduler(fetchLimits),
 
 		fetching:  map[abi.SectorID]chan struct{}{},
 		pfHandler: pfHandler,
@@ -235,7 +248,16 @@ func (r *Remote) acquireFromRemote(ctx context.Context, s abi.SectorID, fileType
 				return "", xerrors.Errorf("removing dest: %w", err)
 			}
 
-			err = r.fetch(ctx, url, tempDest)
+			// what an interrupted fetch left in tempDest is resumed, from this
+			// url or the next one
+			for attempt := 1; ; attempt++ {
+				var received int64
+				received, err = r.fetchSector(ctx, info.ID, fileType, url, tempDest)
+				if err == nil || received == 0 || attempt >= FetchAttempts || ctx.Err() != nil {
+					break
+				}
+				log.Warnw("resuming interrupted fetch", "url", url, "attempt", attempt, "received", received, "error", err)
+			}
 			if err != nil {
 				merr = multierror.Append(merr, xerrors.Errorf("fetch error %s (storage %s) -> %s: %w", url, info.ID, tempDest, err))
 				continue
@@ -255,39 +277,287 @@ func (r *Remote) acquireFromRemote(ctx context.Context, s abi.SectorID, fileType
 	return "", xerrors.Errorf("failed to acquire sector %v from remote (tried %v): %w", s, si, merr)
 }
 
-func (r *Remote) fetch(ctx context.Context, url, outname string) error {
-	log.Infof("Fetch %s -> %s", url, outname)
+// errNoManifest is returned by fetchDir when the server doesn't publish the
+// manifests of directories
+var errNoManifest = xerrors.New("manifest not supported")
+
+// fetchSector fetches the cache directories file by file when the server
+// supports it, fetch sends the other files and directories of older servers
+// as a tar stream.
+func (r *Remote) fetchSector(ctx context.Context, storageID ID, fileType storiface.SectorFileType, url, outname string) (int64, error) {
+	if fileType&(storiface.FTCache|storiface.FTUpdateCache) != 0 {
+		received, err := r.fetchDir(ctx, storageID, url, outname)
+		if !xerrors.Is(err, errNoManifest) {
+			return received, err
+		}
+		log.Infow("falling back to a tar fetch", "url", url, "reason", err)
+	}
+
+	return r.fetch(ctx, storageID, url, outname)
+}
+
+// fetchDir fetches a directory with the manifest published by the server:
+// the files outname already has whole are kept, the others are fetched, up to
+// DirFiles at the same time, resuming the partially received ones. It returns
+// the number of bytes received.
+func (r *Remote) fetchDir(ctx context.Context, storageID ID, url, outname string) (int64, error) {
+	manifest, err := r.fetchManifest(ctx, storageID, url)
+	if err != nil {
+		return 0, err
+	}
+
+	if st, err := os.Stat(outname); err == nil && !st.IsDir() {
+		if err := os.Remove(outname); err != nil {
+			return 0, xerrors.Errorf("removing dest: %w", err)
+		}
+	}
+	if err := os.MkdirAll(outname, 0755); err != nil { // nolint
+		return 0, xerrors.Errorf("mkdir: %w", err)
+	}
+
+	buf := make([]byte, CopyBuf)
+	missing, err := missingFiles(outname, manifest, buf)
+	if err != nil {
+		return 0, err
+	}
+	log.Infow("fetching directory", "url", url, "files", len(manifest.Files), "missing", len(missing))
+
+	var (
+		received int64
+		lk       sync.Mutex
+		merr     error
+		wg       sync.WaitGroup
+	)
+	throttle := make(chan struct{}, r.fetches.limits.DirFiles)
+	for _, file := range missing {
+		throttle <- struct{}{}
+		wg.Add(1)
+		go func(file tarutil.FileChecksum) {
+			defer wg.Done()
+			defer func() { <-throttle }()
+
+			path := filepath.Join(outname, file.Name)
+			st, serr := os.Stat(path)
+			whole := serr == nil && st.Size() == file.Size
+			n, err := r.fetch(ctx, storageID, url+"/file/"+file.Name, path)
+			if _, serr := os.Stat(path); err != nil && whole && os.IsNotExist(serr) && ctx.Err() == nil {
+				// the file received before didn't verify, fetch it again
+				n, err = r.fetch(ctx, storageID, url+"/file/"+file.Name, path)
+			}
+			if err == nil {
+				err = checkFetchedFile(path, file)
+			}
 
-	if len(r.limit) >= cap(r.limit) {
-		log.Infof("Throttling fetch, %d already running", len(r.limit))
+			lk.Lock()
+			defer lk.Unlock()
+			received += n
+			if err != nil {
+				merr = multierror.Append(merr, xerrors.Errorf("fetching %s: %w", file.Name, err))
+			}
+		}(file)
 	}
+	wg.Wait()
+
+	return received, merr
+}
 
-	// TODO: Smarter throttling
-	//  * Priority (just going sequentially is still pretty good)
-	//  * Per interface
-	//  * Aware of remote load
-	select {
-	case r.limit <- struct{}{}:
-		defer func() { <-r.limit }()
-	case <-ctx.Done():
-		return xerrors.Errorf("context error while waiting for fetch limiter: %w", ctx.Err())
+// fetchManifest gets the manifest of a directory, errNoManifest when the
+// server doesn't know the manifest route.
+func (r *Remote) fetchManifest(ctx context.Context, storageID ID, url string) (*tarutil.Manifest, error) {
+	url += "/manifest"
+	_, done, err := r.fetches.acquire(ctx, url, fetchSource(storageID, url))
+	if err != nil {
+		return nil, err
 	}
+	defer done()
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return xerrors.Errorf("request: %w", err)
+		return nil, xerrors.Errorf("request: %w", err)
+	}
+	if r.auth != nil {
+		req.Header = r.auth.Clone()
 	}
-	req.Header = r.auth
 	req = req.WithContext(ctx)
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return xerrors.Errorf("do request: %w", err)
+		return nil, xerrors.Errorf("do request: %w", err)
 	}
 	defer resp.Body.Close() // nolint
 
-	if resp.StatusCode != 200 {
-		return xerrors.Errorf("non-200 code: %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, errNoManifest
+	default:
+		return nil, xerrors.Errorf("non-200 code: %d", resp.StatusCode)
+	}
+
+	var manifest tarutil.Manifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, xerrors.Errorf("decoding manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// missingFiles returns the files of the manifest dir doesn't have whole. The
+// files which can't be resumed, and the ones not in the manifest, are removed.
+// The whole files are returned too when the manifest has no checksums, fetch
+// verifies them without receiving them again.
+func missingFiles(dir string, manifest *tarutil.Manifest, buf []byte) ([]tarutil.FileChecksum, error) {
+	have, err := tarutil.HaveFiles(dir)
+	if err != nil {
+		return nil, xerrors.Errorf("listing received files: %w", err)
+	}
+
+	var missing []tarutil.FileChecksum
+	for _, file := range manifest.Files {
+		if file.Name != filepath.Base(file.Name) {
+			return nil, xerrors.Errorf("invalid file name %q in manifest", file.Name)
+		}
+
+		size, ok := have[file.Name]
+		delete(have, file.Name)
+		path := filepath.Join(dir, file.Name)
+
+		switch {
+		case ok && size == file.Size && file.Sha256 != "":
+			// manifest of an older server
+			sum, _, err := tarutil.HashFile(path, buf)
+			if err != nil {
+				return nil, xerrors.Errorf("hashing %s: %w", file.Name, err)
+			}
+			if sum == file.Sha256 {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return nil, xerrors.Errorf("removing %s: %w", file.Name, err)
+			}
+		case ok && size > file.Size:
+			if err := os.Remove(path); err != nil {
+				return nil, xerrors.Errorf("removing %s: %w", file.Name, err)
+			}
+		}
+		missing = append(missing, file)
+	}
+
+	for name := range have {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return nil, xerrors.Errorf("removing %s: %w", name, err)
+		}
+	}
+
+	return missing, nil
+}
+
+// checkFetchedFile checks the size of a file fetched with fetchDir, its
+// content is verified by fetch.
+func checkFetchedFile(path string, file tarutil.FileChecksum) error {
+	st, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if st.Size() != file.Size {
+		if err := os.Remove(path); err != nil {
+			log.Warnf("removing %s: %v", path, err)
+		}
+		return xerrors.Errorf("fetched %d bytes, expected %d", st.Size(), file.Size)
+	}
+	return nil
+}
+
+// fetch receives a sector file or cache directory into outname, resuming
+// what a previous attempt left there, and verifies the checksum computed by
+// the server. It returns the number of bytes received.
+func (r *Remote) fetch(ctx context.Context, storageID ID, url, outname string) (_ int64, err error) {
+	log.Infof("Fetch %s -> %s", url, outname)
+
+	freq, done, err := r.fetches.acquire(ctx, url, fetchSource(storageID, url))
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	start := time.Now()
+	body := &countingReader{}
+	defer func() {
+		result := "succeeded"
+		if err != nil {
+			result = "failed"
+		}
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.Result, result)},
+			metrics.FetchDuration.M(float64(time.Since(start).Milliseconds())))
+		stats.Record(ctx, metrics.FetchBytes.M(body.n))
+	}()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, xerrors.Errorf("request: %w", err)
+	}
+	req.Header = r.auth.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set(FetchChecksumHeader, "sha256")
+
+	// resume what's already received, its checksum is computed while
+	// receiving the rest
+	var offset int64
+	prefix := make(chan prefixHash, 1)
+	if st, err := os.Stat(outname); err == nil {
+		if st.IsDir() {
+			have, err := tarutil.HaveFiles(outname)
+			if err != nil {
+				return 0, xerrors.Errorf("listing received files: %w", err)
+			}
+			hb, err := json.Marshal(have)
+			if err != nil {
+				return 0, err
+			}
+			req.Header.Set(FetchResumeHeader, string(hb))
+		} else if st.Size() > 0 {
+			offset = st.Size()
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			go func() {
+				h, err := tarutil.HashFilePrefix(outname, offset, make([]byte, CopyBuf))
+				prefix <- prefixHash{h: h, err: err}
+			}()
+		}
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, xerrors.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close() // nolint
+	body.r = r.fetches.reader(ctx, freq, resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		offset = 0
+	case http.StatusPartialContent:
+		if offset == 0 || !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", offset)) {
+			return 0, xerrors.Errorf("unexpected content range %q resuming from %d", resp.Header.Get("Content-Range"), offset)
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset > 0 && resp.Header.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset) && resp.Header.Get(PrefixChecksumTrailer) != "" {
+			// the whole file was received already
+			p := <-prefix
+			if p.err != nil {
+				return 0, xerrors.Errorf("hashing received file: %w", p.err)
+			}
+			return 0, verifyFetchedFile(outname, resp.Header.Get(PrefixChecksumTrailer), p.h)
+		}
+
+		// the received file isn't a prefix of the remote one, start over
+		if err := os.RemoveAll(outname); err != nil {
+			return 0, xerrors.Errorf("removing dest: %w", err)
+		}
+		return 0, xerrors.Errorf("can't resume fetch from %d, starting over", offset)
+	default:
+		return 0, xerrors.Errorf("non-200 code: %d", resp.StatusCode)
 	}
 
 	/*bar := pb.New64(w.sizeForType(typ))
@@ -302,32 +572,121 @@ func (r *Remote) fetch(ctx context.Context, url, outname string) error {
 
 	mediatype, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
 	if err != nil {
-		return xerrors.Errorf("parse media type: %w", err)
-	}
-
-	if err := os.RemoveAll(outname); err != nil {
-		return xerrors.Errorf("removing dest: %w", err)
+		return 0, xerrors.Errorf("parse media type: %w", err)
 	}
 
+	buf := make([]byte, CopyBuf)
 	switch mediatype {
 	case "application/x-tar":
-		return tarutil.ExtractTar(resp.Body, outname, make([]byte, CopyBuf))
+		if _, err := os.Stat(outname); err == nil && req.Header.Get(FetchResumeHeader) == "" {
+			// not a directory, left by another fetch
+			if err := os.RemoveAll(outname); err != nil {
+				return 0, xerrors.Errorf("removing dest: %w", err)
+			}
+		}
+
+		manifest, err := tarutil.ExtractTarResume(body, outname, buf)
+		if err != nil {
+			return body.n, err
+		}
+		if manifest == nil {
+			log.Warnf("fetch %s: no manifest, the server doesn't support checksums", url)
+			return body.n, nil
+		}
+		return body.n, tarutil.VerifyManifest(outname, manifest, buf)
 	case "application/octet-stream":
-		f, err := os.Create(outname)
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if offset > 0 {
+			flags = os.O_WRONLY | os.O_APPEND
+		} else if err := os.RemoveAll(outname); err != nil {
+			return 0, xerrors.Errorf("removing dest: %w", err)
+		}
+
+		h := sha256.New()
+		_, newServer := resp.Trailer[PrefixChecksumTrailer]
+		if offset > 0 && !newServer {
+			// older servers send the checksum of the whole file
+			p := <-prefix
+			if p.err != nil {
+				return 0, xerrors.Errorf("hashing received part: %w", p.err)
+			}
+			h = p.h
+		}
+
+		f, err := os.OpenFile(outname, flags, 0644) // nolint
 		if err != nil {
-			return err
+			return 0, err
 		}
-		_, err = io.CopyBuffer(f, resp.Body, make([]byte, CopyBuf))
+		_, err = io.CopyBuffer(io.MultiWriter(f, h), body, buf)
 		if err != nil {
 			f.Close() // nolint
-			return err
+			return body.n, err
+		}
+		if err := f.Close(); err != nil {
+			return body.n, err
 		}
-		return f.Close()
+
+		if offset > 0 && newServer {
+			p := <-prefix
+			if p.err != nil {
+				return body.n, xerrors.Errorf("hashing received part: %w", p.err)
+			}
+			expected := resp.Trailer.Get(PrefixChecksumTrailer)
+			if expected == "" {
+				expected = "none" // the server couldn't hash it
+			}
+			if err := verifyFetchedFile(outname, expected, p.h); err != nil {
+				return body.n, xerrors.Errorf("verifying the part received before: %w", err)
+			}
+		}
+		return body.n, verifyFetchedFile(outname, resp.Trailer.Get(ChecksumTrailer), h)
 	default:
-		return xerrors.Errorf("unknown content type: '%s'", mediatype)
+		return 0, xerrors.Errorf("unknown content type: '%s'", mediatype)
 	}
 }
 
+// prefixHash is the checksum of the part of a file received before a fetch
+type prefixHash struct {
+	h   hash.Hash
+	err error
+}
+
+// verifyFetchedFile checks the checksum of a fetched file, computed while
+// receiving it, against the one sent by the server. The file is removed on
+// mismatch so that the next attempt starts over.
+func verifyFetchedFile(path, expected string, got hash.Hash) error {
+	if expected == "" {
+		log.Warnf("fetch %s: no checksum, the server doesn't support checksums", path)
+		return nil
+	}
+
+	if sum := hex.EncodeToString(got.Sum(nil)); sum != expected {
+		if err := os.Remove(path); err != nil {
+			log.Warnf("removing corrupted file %s: %v", path, err)
+		}
+		return xerrors.Errorf("checksum mismatch: got %s, expected %s", sum, expected)
+	}
+	return nil
+}
+
+// countingReader counts the bytes read from a fetch response for metrics
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// Fetches returns the running transfers, then the queued ones in the order
+// they will start.
+func (r *Remote) Fetches() []FetchInfo {
+	return r.fetches.list()
+}
+
 func (r *Remote) checkAllocated(ctx context.Context, url string, spt abi.RegisteredSealProof, offset, size abi.PaddedPieceSize) (bool, error) {
 	url = fmt.Sprintf("%s/%d/allocated/%d/%d", url, spt, offset.Unpadded(), size.Unpadded())
 	req, err := http.NewRequest("GET", url, nil)
@@ -486,20 +845,11 @@ func (r *Remote) FsStat(ctx context.Context, id ID) (fsutil.FsStat, error) {
 }
 
 func (r *Remote) readRemote(ctx context.Context, url string, offset, size abi.PaddedPieceSize) (io.ReadCloser, error) {
-	if len(r.limit) >= cap(r.limit) {
-		log.Infof("Throttling remote read, %d already running", len(r.limit))
-	}
-
-	// TODO: Smarter throttling
-	//  * Priority (just going sequentially is still pretty good)
-	//  * Per interface
-	//  * Aware of remote load
-	select {
-	case r.limit <- struct{}{}:
-		defer func() { <-r.limit }()
-	case <-ctx.Done():
-		return nil, xerrors.Errorf("context error while waiting for fetch limiter: %w", ctx.Err())
+	_, done, err := r.fetches.acquire(ctx, url, fetchSource("", url))
+	if err != nil {
+		return nil, err
 	}
+	defer done()
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
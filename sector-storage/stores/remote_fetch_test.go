@@ -0,0 +1,334 @@
+package stores
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/sector-storage/tarutil"
+)
+
+// fetchServer serves path the way remoteGetSector does for checksummed fetches
+func fetchServer(t *testing.T, path string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "sha256", r.Header.Get(FetchChecksumHeader))
+
+		st, err := os.Stat(path)
+		require.NoError(t, err)
+
+		if st.IsDir() {
+			var have map[string]int64
+			if resume := r.Header.Get(FetchResumeHeader); resume != "" {
+				require.NoError(t, json.Unmarshal([]byte(resume), &have))
+			}
+			w.Header().Set("Content-Type", "application/x-tar")
+			require.NoError(t, tarutil.TarDirectoryResume(path, w, make([]byte, CopyBuf), have))
+			return
+		}
+
+		offset, ok := openRangeStart(r.Header.Get("Range"))
+		require.True(t, ok)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		serveFileChecksummed(w, path, offset, st.Size())
+	}))
+}
+
+// manifestServer serves the directory dir the way remoteGetManifest and
+// remoteGetDirFile do, and records the files requested. Without manifest it
+// behaves like an older server.
+func manifestServer(t *testing.T, dir string, manifest bool) (*httptest.Server, func() []string) {
+	var lk sync.Mutex
+	var requested []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/manifest":
+			if !manifest {
+				w.WriteHeader(404)
+				return
+			}
+			serveManifest(w, dir)
+		case strings.HasPrefix(r.URL.Path, "/file/"):
+			name := strings.TrimPrefix(r.URL.Path, "/file/")
+			lk.Lock()
+			requested = append(requested, name)
+			lk.Unlock()
+			serveDirFile(w, r, dir, name)
+		default:
+			lk.Lock()
+			requested = append(requested, "tar")
+			lk.Unlock()
+			w.Header().Set("Content-Type", "application/x-tar")
+			require.NoError(t, tarutil.TarDirectoryResume(dir, w, make([]byte, CopyBuf), nil))
+		}
+	}))
+
+	return srv, func() []string {
+		lk.Lock()
+		defer lk.Unlock()
+		return append([]string{}, requested...)
+	}
+}
+
+func randBytes(t *testing.T, n int) []byte {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+	return b
+}
+
+func TestFetchResumeFile(t *testing.T) {
+	dir := t.TempDir()
+	data := randBytes(t, 1<<20)
+	src := filepath.Join(dir, "src")
+	require.NoError(t, ioutil.WriteFile(src, data, 0644))
+
+	srv := fetchServer(t, src)
+	defer srv.Close()
+
+	r := &Remote{fetches: newFetchScheduler(FetchLimits{Parallel: 1})}
+	ctx := context.Background()
+
+	// an interrupted fetch left the first half
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, ioutil.WriteFile(dest, data[:len(data)/2], 0644))
+
+	received, err := r.fetch(ctx, "", srv.URL, dest)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)-len(data)/2), received)
+
+	got, err := ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+
+	// a corrupted start is detected, and dropped for the next attempt
+	corrupted := append([]byte{}, data[:len(data)/2]...)
+	corrupted[0]++
+	require.NoError(t, ioutil.WriteFile(dest, corrupted, 0644))
+
+	_, err = r.fetch(ctx, "", srv.URL, dest)
+	require.Error(t, err)
+	_, err = os.Stat(dest)
+	require.True(t, os.IsNotExist(err))
+
+	_, err = r.fetch(ctx, "", srv.URL, dest)
+	require.NoError(t, err)
+	got, err = ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+}
+
+func TestFetchCompleteFile(t *testing.T) {
+	dir := t.TempDir()
+	data := randBytes(t, 1<<20)
+	src := filepath.Join(dir, "src")
+	require.NoError(t, ioutil.WriteFile(src, data, 0644))
+
+	srv := fetchServer(t, src)
+	defer srv.Close()
+
+	r := &Remote{fetches: newFetchScheduler(FetchLimits{Parallel: 1})}
+	ctx := context.Background()
+
+	// an interrupted fetch received the whole file but didn't verify it
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, ioutil.WriteFile(dest, data, 0644))
+
+	received, err := r.fetch(ctx, "", srv.URL, dest)
+	require.NoError(t, err)
+	require.Zero(t, received)
+
+	got, err := ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+
+	// a corrupted one is dropped for the next attempt
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-1]++
+	require.NoError(t, ioutil.WriteFile(dest, corrupted, 0644))
+
+	_, err = r.fetch(ctx, "", srv.URL, dest)
+	require.Error(t, err)
+	_, err = os.Stat(dest)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestFetchResumeFileOldServer(t *testing.T) {
+	dir := t.TempDir()
+	data := randBytes(t, 1<<20)
+
+	// older servers send the checksum of the whole file
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, ok := openRangeStart(r.Header.Get("Range"))
+		require.True(t, ok)
+
+		sum := sha256.Sum256(data)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Trailer", ChecksumTrailer)
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(data)-1, len(data)))
+		w.WriteHeader(http.StatusPartialContent)
+		_, err := w.Write(data[offset:])
+		require.NoError(t, err)
+		w.Header().Set(ChecksumTrailer, hex.EncodeToString(sum[:]))
+	}))
+	defer srv.Close()
+
+	r := &Remote{fetches: newFetchScheduler(FetchLimits{Parallel: 1})}
+
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, ioutil.WriteFile(dest, data[:len(data)/2], 0644))
+
+	_, err := r.fetch(context.Background(), "", srv.URL, dest)
+	require.NoError(t, err)
+	got, err := ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+}
+
+func TestFetchResumeDir(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	require.NoError(t, os.Mkdir(src, 0755))
+
+	files := map[string][]byte{
+		"p_aux":                  randBytes(t, 1<<10),
+		"sc-02-data-tree-r-last": randBytes(t, 1<<20),
+		"t_aux":                  randBytes(t, 1<<10),
+	}
+	for name, data := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(src, name), data, 0644))
+	}
+
+	srv := fetchServer(t, src)
+	defer srv.Close()
+
+	r := &Remote{fetches: newFetchScheduler(FetchLimits{Parallel: 1})}
+
+	// p_aux is received, the tree is half received and t_aux is corrupted
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, os.Mkdir(dest, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dest, "p_aux"), files["p_aux"], 0644))
+	tree := files["sc-02-data-tree-r-last"]
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dest, "sc-02-data-tree-r-last"), tree[:len(tree)/2], 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dest, "t_aux"), randBytes(t, 1<<10), 0644))
+
+	received, err := r.fetch(context.Background(), "", srv.URL, dest)
+	require.Error(t, err)
+	require.Less(t, received, int64(len(tree)))
+
+	// only t_aux is fetched again
+	_, err = r.fetch(context.Background(), "", srv.URL, dest)
+	require.NoError(t, err)
+
+	for name, data := range files {
+		got, err := ioutil.ReadFile(filepath.Join(dest, name))
+		require.NoError(t, err)
+		require.Equal(t, data, got, name)
+	}
+}
+
+func TestFetchDirManifest(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	require.NoError(t, os.Mkdir(src, 0755))
+
+	files := map[string][]byte{
+		"p_aux":                  randBytes(t, 1<<10),
+		"sc-02-data-tree-r-last": randBytes(t, 1<<20),
+		"t_aux":                  randBytes(t, 1<<10),
+		"sc-02-data-layer-1":     randBytes(t, 1<<16),
+	}
+	for name, data := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(src, name), data, 0644))
+	}
+
+	srv, requested := manifestServer(t, src, true)
+	defer srv.Close()
+
+	r := &Remote{fetches: newFetchScheduler(FetchLimits{Parallel: 2, DirFiles: 2})}
+
+	// p_aux is received, the tree is half received, t_aux is corrupted, the
+	// layer is missing and a stale file is left
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, os.Mkdir(dest, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dest, "p_aux"), files["p_aux"], 0644))
+	tree := files["sc-02-data-tree-r-last"]
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dest, "sc-02-data-tree-r-last"), tree[:len(tree)/2], 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dest, "t_aux"), randBytes(t, 1<<10), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dest, "stale"), randBytes(t, 1<<10), 0644))
+
+	received, err := r.fetchSector(context.Background(), "", storiface.FTCache, srv.URL, dest)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(tree)-len(tree)/2+1<<10+1<<16), received)
+	// the whole files are verified by the server, t_aux is fetched again
+	require.ElementsMatch(t, []string{"p_aux", "sc-02-data-tree-r-last", "t_aux", "t_aux", "sc-02-data-layer-1"}, requested())
+
+	got, err := ioutil.ReadDir(dest)
+	require.NoError(t, err)
+	require.Len(t, got, len(files))
+	for name, data := range files {
+		got, err := ioutil.ReadFile(filepath.Join(dest, name))
+		require.NoError(t, err)
+		require.Equal(t, data, got, name)
+	}
+}
+
+func TestFetchDirFallback(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	require.NoError(t, os.Mkdir(src, 0755))
+	data := randBytes(t, 1<<10)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(src, "p_aux"), data, 0644))
+
+	srv, requested := manifestServer(t, src, false)
+	defer srv.Close()
+
+	r := &Remote{fetches: newFetchScheduler(FetchLimits{Parallel: 1})}
+
+	dest := filepath.Join(dir, "dest")
+	_, err := r.fetchSector(context.Background(), "", storiface.FTCache, srv.URL, dest)
+	require.NoError(t, err)
+	require.Equal(t, []string{"tar"}, requested())
+
+	got, err := ioutil.ReadFile(filepath.Join(dest, "p_aux"))
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+}
+
+func TestServeDirFileName(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"..", ".", "../p_aux", ""} {
+		w := httptest.NewRecorder()
+		serveDirFile(w, httptest.NewRequest("GET", "/file/x", nil), dir, name)
+		require.Equal(t, 400, w.Code, name)
+	}
+}
+
+func TestServeManifest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "p_aux"), randBytes(t, 1<<10), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+
+	w := httptest.NewRecorder()
+	serveManifest(w, dir)
+	require.Equal(t, 200, w.Code)
+
+	// the files are not read to answer
+	var manifest tarutil.Manifest
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &manifest))
+	require.Equal(t, []tarutil.FileChecksum{{Name: "p_aux", Size: 1 << 10}}, manifest.Files)
+}
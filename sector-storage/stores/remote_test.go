@@ -340,7 +340,7 @@ func TestReader(t *testing.T) {
 				tc.indexFnc(index, tc.serverUrl)
 			}
 
-			remoteStore := stores.NewRemote(lstore, index, nil, 6000, pfhandler)
+			remoteStore := stores.NewRemote(lstore, index, nil, stores.FetchLimits{Parallel: 6000}, pfhandler)
 
 			rdg, err := remoteStore.Reader(ctx, sectorRef, offset, size)
 			var rd io.ReadCloser
@@ -643,7 +643,7 @@ func TestCheckIsUnsealed(t *testing.T) {
 				tc.indexFnc(index, tc.serverUrl)
 			}
 
-			remoteStore := stores.NewRemote(lstore, index, nil, 6000, pfhandler)
+			remoteStore := stores.NewRemote(lstore, index, nil, stores.FetchLimits{Parallel: 6000}, pfhandler)
 
 			isUnsealed, err := remoteStore.CheckIsUnsealed(ctx, sectorRef, offset, size)
 
@@ -0,0 +1,53 @@
+package storiface
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+)
+
+type SectorRedoParams struct {
+	SectorNumber abi.SectorNumber
+}
+
+// RedoState is the progress of rebuilding the sealed replica of a sector
+type RedoState string
+
+const (
+	RedoScheduled  RedoState = "Scheduled"
+	RedoAddPiece   RedoState = "AddPiece"
+	RedoPreCommit1 RedoState = "PreCommit1"
+	RedoPreCommit2 RedoState = "PreCommit2"
+	RedoFinalize   RedoState = "Finalize"
+	RedoDone       RedoState = "Done"
+	RedoFailed     RedoState = "Failed"
+	// RedoAborted is reported for a redo interrupted by a restart of the sealer
+	RedoAborted RedoState = "Aborted"
+)
+
+// Finished returns true if the redo isn't running anymore
+func (s RedoState) Finished() bool {
+	return s == RedoDone || s == RedoFailed || s == RedoAborted
+}
+
+type SectorRedoStatus struct {
+	SectorNumber abi.SectorNumber
+	State        RedoState
+	Error        string
+
+	StartTime  int64 // unix seconds
+	UpdateTime int64 // unix seconds
+}
+
+// SectorRecoverParams selects committed CC sectors whose sealed replica and
+// cache are rebuilt from the on-chain sector info.
+type SectorRecoverParams struct {
+	Sectors []abi.SectorNumber
+
+	// PreCommitMessages holds the precommit message of the sectors without a
+	// local sector info, the seal ticket is derived from it
+	PreCommitMessages map[abi.SectorNumber]cid.Cid
+
+	// Parallel limits the number of sectors sealed at the same time, 0 leaves
+	// it to the scheduler
+	Parallel int
+}
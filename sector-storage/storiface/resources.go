@@ -473,6 +473,7 @@ func init() {
 	ResourceTable[types.TTUnseal] = ResourceTable[types.TTPreCommit1] // TODO: measure accurately
 	ResourceTable[types.TTRegenSectorKey] = ResourceTable[types.TTReplicaUpdate]
 	ResourceTable[types.TTDataCid] = ResourceTable[types.TTAddPiece]
+	ResourceTable[types.TTGenerateWindowPoSt] = ResourceTable[types.TTPreCommit2] // reads the challenged nodes of the sectors and runs the partition SNARK on the GPU
 
 	// V1_1 is the same as V1
 	for _, m := range ResourceTable {
@@ -537,6 +538,9 @@ func ParseResourceEnv(lookup func(key, def string) (string, bool)) (map[types.Ta
 				default:
 					return nil, xerrors.Errorf("unknown resource field type")
 				}
+				if err != nil {
+					return nil, xerrors.Errorf("parsing %s: %w", taskType.Short()+"_"+shortSize+"_"+envname, err)
+				}
 			}
 
 			out[taskType][spt] = r
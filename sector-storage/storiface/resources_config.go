@@ -0,0 +1,224 @@
+package storiface
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// ResourceOverride overrides the fields of a ResourceTable entry, the nil ones
+// keep their default.
+type ResourceOverride struct {
+	MinMemory         *uint64
+	MaxMemory         *uint64
+	GPUUtilization    *float64
+	MaxParallelism    *int
+	MaxParallelismGPU *int
+	BaseMinMemory     *uint64
+}
+
+// ResourceOverrides are the resource profiles of a worker config, by task short
+// name then sector size, eg. [Resources.PC1.32G] holds what the
+// PC1_32G_<FIELD> env vars used to.
+type ResourceOverrides map[string]map[string]ResourceOverride
+
+// ResourceDiff is a resource of a worker which differs from the default table
+type ResourceDiff struct {
+	TaskType   types.TaskType
+	SectorSize abi.SectorSize
+	Field      string
+	Default    string
+	Value      string
+}
+
+func shortSectorSize(spt abi.RegisteredSealProof) (string, error) {
+	ssize, err := spt.SectorSize()
+	if err != nil {
+		return "", xerrors.Errorf("getting sector size: %w", err)
+	}
+	return strings.TrimSuffix(ssize.ShortString(), "iB"), nil
+}
+
+// Validate checks that the overrides only name the tasks and sector sizes of
+// the resource table.
+func (o ResourceOverrides) Validate() error {
+	_, err := o.Env()
+	return err
+}
+
+// Env returns the overrides as the resource env vars ParseResourceEnv looks up
+func (o ResourceOverrides) Env() (map[string]string, error) {
+	tasks := map[string]map[string]struct{}{}
+	for tt, byProof := range ResourceTable {
+		sizes := map[string]struct{}{}
+		for spt := range byProof {
+			size, err := shortSectorSize(spt)
+			if err != nil {
+				return nil, err
+			}
+			sizes[size] = struct{}{}
+		}
+		tasks[tt.Short()] = sizes
+	}
+
+	resType := reflect.TypeOf(Resources{})
+	env := map[string]string{}
+	for task, bySize := range o {
+		sizes, ok := tasks[task]
+		if !ok {
+			return nil, xerrors.Errorf("unknown task type %q in resource overrides", task)
+		}
+
+		for size, override := range bySize {
+			if _, ok := sizes[size]; !ok {
+				return nil, xerrors.Errorf("unknown sector size %q for %s in resource overrides", size, task)
+			}
+
+			ov := reflect.ValueOf(override)
+			for i := 0; i < ov.NumField(); i++ {
+				if ov.Field(i).IsNil() {
+					continue
+				}
+
+				name := ov.Type().Field(i).Name
+				f, ok := resType.FieldByName(name)
+				if !ok {
+					return nil, xerrors.Errorf("no resource field %s", name)
+				}
+				env[task+"_"+size+"_"+f.Tag.Get("envname")] = fmt.Sprint(ov.Field(i).Elem().Interface())
+			}
+		}
+	}
+	return env, nil
+}
+
+// ResourceLookup returns the lookup for ParseResourceEnv of a worker, the
+// overrides of its config take precedence over the env vars.
+func ResourceLookup(overrides ResourceOverrides, envLookup func(string) (string, bool)) (func(key, def string) (string, bool), error) {
+	env, err := overrides.Env()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(key, def string) (string, bool) {
+		if v, ok := env[key]; ok {
+			return v, true
+		}
+		return envLookup(key)
+	}, nil
+}
+
+// ResourceTableOverrides returns the entries of a table as a config snippet
+// holds them. With a base table, only the fields which differ from it are set.
+func ResourceTableOverrides(table, base map[types.TaskType]map[abi.RegisteredSealProof]Resources) (ResourceOverrides, error) {
+	out := ResourceOverrides{}
+	for tt, byProof := range table {
+		for spt, res := range byProof {
+			size, err := shortSectorSize(spt)
+			if err != nil {
+				return nil, err
+			}
+
+			def, hasDef := base[tt][spt]
+			rv, dv := reflect.ValueOf(res), reflect.ValueOf(def)
+
+			var override ResourceOverride
+			ov := reflect.ValueOf(&override).Elem()
+			set := false
+			for i := 0; i < rv.NumField(); i++ {
+				if hasDef && rv.Field(i).Interface() == dv.Field(i).Interface() {
+					continue
+				}
+
+				v := reflect.New(rv.Field(i).Type())
+				v.Elem().Set(rv.Field(i))
+				ov.FieldByName(rv.Type().Field(i).Name).Set(v)
+				set = true
+			}
+			if !set {
+				continue
+			}
+
+			if out[tt.Short()] == nil {
+				out[tt.Short()] = map[string]ResourceOverride{}
+			}
+			out[tt.Short()][size] = override
+		}
+	}
+	return out, nil
+}
+
+// DiffResourceTable returns the resources of table which differ from the
+// default ResourceTable, sorted by task, sector size and field.
+func DiffResourceTable(table map[types.TaskType]map[abi.RegisteredSealProof]Resources) ([]ResourceDiff, error) {
+	type key struct {
+		tt    types.TaskType
+		size  abi.SectorSize
+		field string
+	}
+	seen := map[key]struct{}{}
+
+	var out []ResourceDiff
+	for tt, byProof := range table {
+		for spt, res := range byProof {
+			def, ok := ResourceTable[tt][spt]
+			if !ok {
+				continue
+			}
+			size, err := spt.SectorSize()
+			if err != nil {
+				return nil, xerrors.Errorf("getting sector size: %w", err)
+			}
+
+			rv, dv := reflect.ValueOf(res), reflect.ValueOf(def)
+			for i := 0; i < rv.NumField(); i++ {
+				if rv.Field(i).Interface() == dv.Field(i).Interface() {
+					continue
+				}
+
+				k := key{tt: tt, size: size, field: rv.Type().Field(i).Name}
+				if _, ok := seen[k]; ok {
+					// V1 and V1_1 proofs share their resources
+					continue
+				}
+				seen[k] = struct{}{}
+
+				out = append(out, ResourceDiff{
+					TaskType:   tt,
+					SectorSize: size,
+					Field:      k.field,
+					Default:    fmt.Sprint(dv.Field(i).Interface()),
+					Value:      fmt.Sprint(rv.Field(i).Interface()),
+				})
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TaskType != out[j].TaskType {
+			return out[i].TaskType.Short() < out[j].TaskType.Short()
+		}
+		if out[i].SectorSize != out[j].SectorSize {
+			return out[i].SectorSize < out[j].SectorSize
+		}
+		return out[i].Field < out[j].Field
+	})
+	return out, nil
+}
+
+// EnvName is the name of the resource env var of the entry
+func (d ResourceDiff) EnvName() string {
+	f, _ := reflect.TypeOf(Resources{}).FieldByName(d.Field)
+	return d.TaskType.Short() + "_" + strings.TrimSuffix(d.SectorSize.ShortString(), "iB") + "_" + f.Tag.Get("envname")
+}
+
+func (d ResourceDiff) String() string {
+	return fmt.Sprintf("%s %s %s=%s (default %s)", d.TaskType.Short(), strings.TrimSuffix(d.SectorSize.ShortString(), "iB"), d.Field, d.Value, d.Default)
+}
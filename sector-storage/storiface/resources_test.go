@@ -75,3 +75,48 @@ func TestListResourceSDRMulticoreOverride(t *testing.T) {
 	require.Equal(t, 9001, rt[types.TTPreCommit1][stabi.RegisteredSealProof_StackedDrg2KiBV1_1].MaxParallelism)
 	require.Equal(t, 9001, rt[types.TTUnseal][stabi.RegisteredSealProof_StackedDrg2KiBV1_1].MaxParallelism)
 }
+
+func TestResourceOverridesConfig(t *testing.T) {
+	maxPar, maxMem := 2, uint64(2222)
+	overrides := ResourceOverrides{
+		"UNS": {"2K": {MaxParallelism: &maxPar}},
+		"PC2": {"2K": {MaxMemory: &maxMem}},
+	}
+
+	env, err := overrides.Env()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"UNS_2K_MAX_PARALLELISM": "2", "PC2_2K_MAX_MEMORY": "2222"}, env)
+
+	// the config takes precedence over the env
+	lookup, err := ResourceLookup(overrides, func(key string) (string, bool) {
+		switch key {
+		case "UNS_2K_MAX_PARALLELISM":
+			return "3", true
+		case "PC2_2K_GPU_UTILIZATION":
+			return "0.4", true
+		}
+		return "", false
+	})
+	require.NoError(t, err)
+	rt, err := ParseResourceEnv(lookup)
+	require.NoError(t, err)
+	require.Equal(t, 2, rt[types.TTUnseal][stabi.RegisteredSealProof_StackedDrg2KiBV1_1].MaxParallelism)
+	require.Equal(t, 0.4, rt[types.TTPreCommit2][stabi.RegisteredSealProof_StackedDrg2KiBV1_1].GPUUtilization)
+
+	diff, err := DiffResourceTable(rt)
+	require.NoError(t, err)
+	require.Len(t, diff, 3)
+	require.Equal(t, "PC2 2K GPUUtilization=0.4 (default 0)", diff[0].String())
+	require.Equal(t, "PC2_2K_MAX_MEMORY", diff[1].EnvName())
+	require.Equal(t, "UNS_2K_MAX_PARALLELISM", diff[2].EnvName())
+
+	out, err := ResourceTableOverrides(rt, ResourceTable)
+	require.NoError(t, err)
+	require.Len(t, out, 2)
+	require.Equal(t, maxPar, *out["UNS"]["2K"].MaxParallelism)
+	require.Nil(t, out["UNS"]["2K"].MaxMemory)
+	require.Equal(t, maxMem, *out["PC2"]["2K"].MaxMemory)
+
+	require.Error(t, ResourceOverrides{"XX": {"2K": {MaxParallelism: &maxPar}}}.Validate())
+	require.Error(t, ResourceOverrides{"PC1": {"3K": {MaxParallelism: &maxPar}}}.Validate())
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 
 	"github.com/filecoin-project/venus-sealer/types"
@@ -43,6 +46,9 @@ type WorkerResources struct {
 
 	// if nil use the default resource table
 	Resources map[types.TaskType]map[abi.RegisteredSealProof]Resources
+	// Overrides lists the entries of Resources which differ from the default
+	// resource table of the worker
+	Overrides []ResourceDiff
 }
 
 func (wr WorkerResources) ResourceSpec(spt abi.RegisteredSealProof, tt types.TaskType) Resources {
@@ -73,6 +79,59 @@ type WorkerStats struct {
 	MemUsedMax uint64
 	GpuUsed    float64 // nolint
 	CpuUse     uint64  // nolint
+
+	Capacity WorkerCapacity
+}
+
+// WorkerCapacity is the number of tasks running on a worker and the number of
+// tasks it accepts, in total and per task type. A negative Total means
+// unlimited and 0 accepts no task, per type limits <= 0 mean unlimited.
+type WorkerCapacity struct {
+	Running int64
+	Total   int64
+
+	RunningByType map[types.TaskType]int64
+	LimitByType   map[types.TaskType]int64
+}
+
+func (c WorkerCapacity) String() string {
+	limitStr := func(running, limit int64) string {
+		if limit <= 0 {
+			return fmt.Sprintf("%d", running)
+		}
+		return fmt.Sprintf("%d/%d", running, limit)
+	}
+
+	typs := make([]string, 0, len(c.LimitByType))
+	for tt, limit := range c.LimitByType {
+		typs = append(typs, fmt.Sprintf("%s: %s", tt.Short(), limitStr(c.RunningByType[tt], limit)))
+	}
+	for tt, running := range c.RunningByType {
+		if _, limited := c.LimitByType[tt]; !limited {
+			typs = append(typs, fmt.Sprintf("%s: %s", tt.Short(), limitStr(running, 0)))
+		}
+	}
+	sort.Strings(typs)
+
+	out := fmt.Sprintf("%d", c.Running)
+	if c.Total >= 0 {
+		out = fmt.Sprintf("%d/%d", c.Running, c.Total)
+	}
+	if len(typs) > 0 {
+		out += " (" + strings.Join(typs, ", ") + ")"
+	}
+	return out
+}
+
+// CanAccept returns true if the worker has room for one more task of the type
+func (c WorkerCapacity) CanAccept(tt types.TaskType) bool {
+	if c.Total >= 0 && c.Running >= c.Total {
+		return false
+	}
+	if limit := c.LimitByType[tt]; limit > 0 && c.RunningByType[tt] >= limit {
+		return false
+	}
+	return true
 }
 
 const (
@@ -116,7 +175,19 @@ type WorkerCalls interface {
 	GenerateSectorKeyFromData(ctx context.Context, sector storage.SectorRef, commD cid.Cid) (types.CallID, error)
 	MoveStorage(ctx context.Context, sector storage.SectorRef, types SectorFileType) (types.CallID, error)
 	UnsealPiece(context.Context, storage.SectorRef, UnpaddedByteIndex, abi.UnpaddedPieceSize, abi.SealRandomness, cid.Cid) (types.CallID, error)
-	Fetch(context.Context, storage.SectorRef, SectorFileType, PathType, AcquireMode) (types.CallID, error)
+	// Fetch takes the priority of the task it prepares, the fetches of the
+	// worker are run by priority
+	Fetch(ctx context.Context, sector storage.SectorRef, fileType SectorFileType, ptype PathType, am AcquireMode, priority int) (types.CallID, error)
+}
+
+// WindowPoStResult holds the proof of a window PoSt partition proven on a
+// worker
+type WindowPoStResult struct {
+	PoStProof proof.PoStProof
+
+	// Skipped are the sectors the worker couldn't read, no proof is returned
+	// when some sectors were skipped
+	Skipped []abi.SectorID
 }
 
 type ErrorCode int
@@ -130,6 +201,9 @@ const (
 	ErrTempUnknown ErrorCode = iota + 100
 	ErrTempWorkerRestart
 	ErrTempAllocateSpace
+	// ErrTempOOM is returned when the task was killed for going over the
+	// memory limit of its cgroup, it can be scheduled again
+	ErrTempOOM
 )
 
 type CallError struct {
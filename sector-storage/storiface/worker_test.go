@@ -0,0 +1,40 @@
+package storiface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestWorkerCapacity(t *testing.T) {
+	c := WorkerCapacity{
+		Running: 3,
+		Total:   4,
+		RunningByType: map[types.TaskType]int64{
+			types.TTPreCommit1: 2,
+			types.TTPreCommit2: 1,
+		},
+		LimitByType: map[types.TaskType]int64{
+			types.TTPreCommit1: 2,
+			types.TTCommit2:    1,
+		},
+	}
+
+	require.False(t, c.CanAccept(types.TTPreCommit1))
+	require.True(t, c.CanAccept(types.TTPreCommit2))
+	require.True(t, c.CanAccept(types.TTCommit2))
+	require.Equal(t, "3/4 (C2: 0/1, PC1: 2/2, PC2: 1)", c.String())
+
+	c.Running = 4
+	require.False(t, c.CanAccept(types.TTPreCommit2))
+
+	// no limits
+	require.True(t, WorkerCapacity{Running: 100, Total: -1}.CanAccept(types.TTPreCommit1))
+	require.Equal(t, "100", WorkerCapacity{Running: 100, Total: -1}.String())
+
+	// no task at all
+	require.False(t, WorkerCapacity{}.CanAccept(types.TTPreCommit1))
+	require.Equal(t, "0/0", WorkerCapacity{}.String())
+}
@@ -0,0 +1,304 @@
+package tarutil
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/xerrors"
+)
+
+// ManifestName is the last entry of a resumable tar stream, it holds the
+// checksums of every file of the directory.
+const ManifestName = ".fetch-manifest.json"
+
+// paxOffset is the PAX record of an entry holding the end of a file, from
+// the given offset.
+const paxOffset = "VENUS.offset"
+
+type FileChecksum struct {
+	Name string
+	Size int64
+	// Sha256 is empty in the manifests of DirManifest
+	Sha256 string
+}
+
+type Manifest struct {
+	Files []FileChecksum
+}
+
+// TarDirectoryResume writes the directory as TarDirectory does, skipping the
+// part of the files the receiver already has: have maps a file name to the
+// number of bytes received. The checksums of the whole files are computed
+// while streaming and written in a ManifestName entry at the end.
+func TarDirectoryResume(dir string, w io.Writer, buf []byte, have map[string]int64) error {
+	tw := tar.NewWriter(w)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var manifest Manifest
+	for _, file := range files {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+
+		sum, err := tarFileFrom(tw, dir, file, have[file.Name()], buf)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, FileChecksum{
+			Name:   file.Name(),
+			Size:   file.Size(),
+			Sha256: sum,
+		})
+	}
+
+	mb, err := json.Marshal(&manifest)
+	if err != nil {
+		return xerrors.Errorf("marshaling manifest: %w", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     ManifestName,
+		Mode:     0644,
+		Size:     int64(len(mb)),
+	}); err != nil {
+		return xerrors.Errorf("writing manifest header: %w", err)
+	}
+	if _, err := tw.Write(mb); err != nil {
+		return xerrors.Errorf("writing manifest: %w", err)
+	}
+
+	return tw.Close()
+}
+
+// tarFileFrom writes the file from offset, or nothing when the receiver has it
+// whole, and returns the checksum of the whole file.
+func tarFileFrom(tw *tar.Writer, dir string, file os.FileInfo, offset int64, buf []byte) (string, error) {
+	f, err := os.Open(filepath.Join(dir, file.Name()))
+	if err != nil {
+		return "", xerrors.Errorf("opening %s for reading: %w", file.Name(), err)
+	}
+	defer f.Close() // nolint
+
+	size := file.Size()
+	if offset > size {
+		// not a prefix of this file, send it all again
+		offset = 0
+	}
+
+	h := sha256.New()
+	if _, err := io.CopyBuffer(h, io.LimitReader(f, offset), buf); err != nil {
+		return "", xerrors.Errorf("hashing start of %s: %w", file.Name(), err)
+	}
+
+	if offset < size {
+		hdr, err := tar.FileInfoHeader(file, "")
+		if err != nil {
+			return "", xerrors.Errorf("getting header for file %s: %w", file.Name(), err)
+		}
+		hdr.Size = size - offset
+		if offset > 0 {
+			hdr.Format = tar.FormatPAX
+			hdr.PAXRecords = map[string]string{paxOffset: strconv.FormatInt(offset, 10)}
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return "", xerrors.Errorf("wiritng header for file %s: %w", file.Name(), err)
+		}
+		if _, err := io.CopyBuffer(io.MultiWriter(tw, h), io.LimitReader(f, hdr.Size), buf); err != nil {
+			return "", xerrors.Errorf("copy data for file %s: %w", file.Name(), err)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ExtractTarResume extracts a stream written by TarDirectoryResume, or by
+// TarDirectory, into dir, appending to the files sent from an offset. It
+// returns the manifest of the stream, nil when it has none.
+func ExtractTarResume(body io.Reader, dir string, buf []byte) (*Manifest, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil { // nolint
+		return nil, xerrors.Errorf("mkdir: %w", err)
+	}
+
+	var manifest *Manifest
+	tr := tar.NewReader(body)
+	for {
+		header, err := tr.Next()
+		switch err {
+		default:
+			return nil, err
+		case io.EOF:
+			return manifest, nil
+
+		case nil:
+		}
+
+		if header.Name == ManifestName {
+			var mb bytes.Buffer
+			if _, err := io.CopyBuffer(&mb, tr, buf); err != nil {
+				return nil, xerrors.Errorf("reading manifest: %w", err)
+			}
+			manifest = new(Manifest)
+			if err := json.Unmarshal(mb.Bytes(), manifest); err != nil {
+				return nil, xerrors.Errorf("decoding manifest: %w", err)
+			}
+			continue
+		}
+
+		if err := extractFile(tr, header, filepath.Join(dir, header.Name), buf); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func extractFile(tr *tar.Reader, header *tar.Header, path string, buf []byte) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	var offset int64
+	if off, ok := header.PAXRecords[paxOffset]; ok {
+		var err error
+		offset, err = strconv.ParseInt(off, 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing offset of %s: %w", header.Name, err)
+		}
+		flags = os.O_WRONLY
+	}
+
+	f, err := os.OpenFile(path, flags, 0644) // nolint
+	if err != nil {
+		return xerrors.Errorf("creating file %s: %w", path, err)
+	}
+	if offset > 0 {
+		if err := f.Truncate(offset); err != nil {
+			f.Close() // nolint
+			return xerrors.Errorf("truncating %s: %w", path, err)
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close() // nolint
+			return xerrors.Errorf("seeking %s: %w", path, err)
+		}
+	}
+
+	// This data is coming from a trusted source, no need to check the size.
+	//nolint:gosec
+	if _, err := io.CopyBuffer(f, tr, buf); err != nil {
+		f.Close() // nolint
+		return err
+	}
+
+	return f.Close()
+}
+
+// HaveFiles returns the size of the files of a partially received directory,
+// for TarDirectoryResume.
+func HaveFiles(dir string) (map[string]int64, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	have := map[string]int64{}
+	for _, file := range files {
+		if file.Mode().IsRegular() {
+			have[file.Name()] = file.Size()
+		}
+	}
+	return have, nil
+}
+
+// VerifyManifest checks the files of dir against the manifest. The files which
+// don't match are removed so that they are fetched again.
+func VerifyManifest(dir string, manifest *Manifest, buf []byte) error {
+	var bad []string
+	for _, file := range manifest.Files {
+		path := filepath.Join(dir, file.Name)
+		sum, size, err := HashFile(path, buf)
+		if err == nil && size == file.Size && sum == file.Sha256 {
+			continue
+		}
+
+		bad = append(bad, file.Name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Warnf("removing corrupted file %s: %v", path, err)
+		}
+	}
+
+	if len(bad) > 0 {
+		return xerrors.Errorf("checksum mismatch for %v", bad)
+	}
+	return nil
+}
+
+// DirManifest returns the regular files of dir, the files a tar stream of the
+// directory holds. The checksums are left empty, reading the whole directory
+// for them would be too slow, the files are verified when they're fetched.
+func DirManifest(dir string) (*Manifest, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{Files: []FileChecksum{}}
+	for _, file := range files {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+
+		manifest.Files = append(manifest.Files, FileChecksum{
+			Name: file.Name(),
+			Size: file.Size(),
+		})
+	}
+	return manifest, nil
+}
+
+// HashFile returns the hex sha256 and the size of a file.
+func HashFile(path string, buf []byte) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close() // nolint
+
+	h := sha256.New()
+	n, err := io.CopyBuffer(h, f, buf)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// HashFilePrefix returns the sha256 of the first n bytes of a file, more bytes
+// can be written to it to hash a longer part of the file.
+func HashFilePrefix(path string, n int64, buf []byte) (hash.Hash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint
+
+	h := sha256.New()
+	read, err := io.CopyBuffer(h, io.LimitReader(f, n), buf)
+	if err != nil {
+		return nil, err
+	}
+	if read != n {
+		return nil, xerrors.Errorf("%s is shorter than %d bytes", path, n)
+	}
+	return h, nil
+}
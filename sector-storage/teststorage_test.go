@@ -117,4 +117,12 @@ func (t *testExec) GenerateWindowPoStWithVanilla(ctx context.Context, proofType
 	panic("implement me")
 }
 
+func (t *testExec) GenerateWindowPoStPartition(ctx context.Context, minerID abi.ActorID, sectorInfo []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (proof.PoStProof, []abi.SectorID, error) {
+	panic("implement me")
+}
+
+func (t *testExec) MergeWindowPoStPartitionProofs(proofType abi.RegisteredPoStProof, partitionProofs []proof.PoStProof) (proof.PoStProof, error) {
+	panic("implement me")
+}
+
 var _ ffiwrapper.Storage = &testExec{}
@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/proof"
 	"github.com/filecoin-project/specs-storage/storage"
 	"github.com/google/uuid"
 
@@ -85,7 +86,7 @@ func (t *testWorker) SealPreCommit1(ctx context.Context, sector storage.SectorRe
 	})
 }
 
-func (t *testWorker) Fetch(ctx context.Context, sector storage.SectorRef, fileType storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode) (
+func (t *testWorker) Fetch(ctx context.Context, sector storage.SectorRef, fileType storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode, priority int) (
 	types.CallID, error) {
 	return t.asyncCall(sector, func(ci types.CallID) {
 		if err := t.ret.ReturnFetch(ctx, ci, nil); err != nil {
@@ -94,12 +95,20 @@ func (t *testWorker) Fetch(ctx context.Context, sector storage.SectorRef, fileTy
 	})
 }
 
+func (t *testWorker) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (storiface.WindowPoStResult, error) {
+	out, skipped, err := t.mockSeal.GenerateWindowPoStPartition(ctx, minerID, sectors, partitionIdx, randomness)
+	if len(skipped) > 0 {
+		return storiface.WindowPoStResult{Skipped: skipped}, nil
+	}
+	return storiface.WindowPoStResult{PoStProof: out}, err
+}
+
 func (t *testWorker) TaskTypes(ctx context.Context) (map[types.TaskType]struct{}, error) {
 	return t.acceptTasks, nil
 }
 
-func (t *testWorker) TaskNumbers(ctx context.Context) (string, error) {
-	return "0-0", nil
+func (t *testWorker) Capacity(ctx context.Context) (storiface.WorkerCapacity, error) {
+	return storiface.WorkerCapacity{Total: -1}, nil
 }
 
 func (t *testWorker) Paths(ctx context.Context) ([]stores.StoragePath, error) {
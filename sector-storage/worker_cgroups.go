@@ -0,0 +1,235 @@
+package sectorstorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	ffi "github.com/filecoin-project/filecoin-ffi"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// TaskChildCommand is the command of the worker binary running the ffi work of
+// a task in a child process, see RunTaskChild
+const TaskChildCommand = "ffi-task"
+
+// taskCall is the work sent to a task child process
+type taskCall struct {
+	Task   types.TaskType
+	Sector storage.SectorRef
+	Paths  storiface.SectorPaths
+
+	Ticket        abi.SealRandomness
+	Pieces        []abi.PieceInfo
+	PreCommit1Out storage.PreCommit1Out
+	Commit1Out    storage.Commit1Out
+
+	SectorKey, NewSealed, NewUnsealed cid.Cid
+	VanillaProofs                     storage.ReplicaVanillaProofs
+}
+
+type taskResult struct {
+	Out json.RawMessage
+	Err string
+}
+
+// taskPaths are the sector files acquired for the tasks running in a child
+// process, the same way the ffiwrapper sealer acquires them
+var taskPaths = map[types.TaskType]struct{ existing, allocate storiface.SectorFileType }{
+	types.TTPreCommit1:    {storiface.FTNone, storiface.FTUnsealed | storiface.FTSealed | storiface.FTCache},
+	types.TTPreCommit2:    {storiface.FTSealed | storiface.FTCache, storiface.FTNone},
+	types.TTReplicaUpdate: {storiface.FTUnsealed | storiface.FTSealed | storiface.FTCache, storiface.FTUpdate | storiface.FTUpdateCache},
+}
+
+// cgroupSealer runs the tasks using most of the memory and cpus of a worker in
+// a child process, in their own cgroup. The other ones run in the worker.
+type cgroupSealer struct {
+	ffiwrapper.Storage
+	w *LocalWorker
+}
+
+func (s *cgroupSealer) SealPreCommit1(ctx context.Context, sector storage.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) (out storage.PreCommit1Out, err error) {
+	err = s.w.runTaskChild(ctx, taskCall{Task: types.TTPreCommit1, Sector: sector, Ticket: ticket, Pieces: pieces}, &out)
+	return out, err
+}
+
+func (s *cgroupSealer) SealPreCommit2(ctx context.Context, sector storage.SectorRef, phase1Out storage.PreCommit1Out) (out storage.SectorCids, err error) {
+	err = s.w.runTaskChild(ctx, taskCall{Task: types.TTPreCommit2, Sector: sector, PreCommit1Out: phase1Out}, &out)
+	return out, err
+}
+
+func (s *cgroupSealer) SealCommit2(ctx context.Context, sector storage.SectorRef, phase1Out storage.Commit1Out) (out storage.Proof, err error) {
+	err = s.w.runTaskChild(ctx, taskCall{Task: types.TTCommit2, Sector: sector, Commit1Out: phase1Out}, &out)
+	return out, err
+}
+
+func (s *cgroupSealer) ReplicaUpdate(ctx context.Context, sector storage.SectorRef, pieces []abi.PieceInfo) (out storage.ReplicaUpdateOut, err error) {
+	err = s.w.runTaskChild(ctx, taskCall{Task: types.TTReplicaUpdate, Sector: sector, Pieces: pieces}, &out)
+	return out, err
+}
+
+func (s *cgroupSealer) ProveReplicaUpdate2(ctx context.Context, sector storage.SectorRef, sectorKey, newSealed, newUnsealed cid.Cid, vanillaProofs storage.ReplicaVanillaProofs) (out storage.ReplicaUpdateProof, err error) {
+	err = s.w.runTaskChild(ctx, taskCall{
+		Task:          types.TTProveReplicaUpdate2,
+		Sector:        sector,
+		SectorKey:     sectorKey,
+		NewSealed:     newSealed,
+		NewUnsealed:   newUnsealed,
+		VanillaProofs: vanillaProofs,
+	}, &out)
+	return out, err
+}
+
+// runTaskChild runs a task in a child process, in a cgroup limited to the
+// memory of the task and pinned to as many CPUs as it has threads, and
+// decodes its result into out. A task
+// killed by the OOM killer returns an ErrTempOOM error.
+func (l *LocalWorker) runTaskChild(ctx context.Context, call taskCall, out interface{}) error {
+	table, err := l.resourceTable()
+	if err != nil {
+		return err
+	}
+	res, ok := table[call.Task][call.Sector.ProofType]
+	if !ok {
+		return xerrors.Errorf("no resources for %s of proof type %d", call.Task, call.Sector.ProofType)
+	}
+
+	if tp, ok := taskPaths[call.Task]; ok {
+		paths, done, err := (&localWorkerPathProvider{w: l}).AcquireSector(ctx, call.Sector, tp.existing, tp.allocate, storiface.PathSealing)
+		if err != nil {
+			return xerrors.Errorf("acquiring sector paths: %w", err)
+		}
+		defer done()
+		call.Paths = paths
+	}
+
+	gpus, err := ffi.GetGPUDevices()
+	if err != nil {
+		log.Errorf("getting gpu devices failed: %+v", err)
+	}
+	threads := res.Threads(uint64(runtime.NumCPU()), len(gpus))
+
+	name := fmt.Sprintf("task-%s-%d-%d-%s", call.Task.Short(), call.Sector.ID.Miner, call.Sector.ID.Number, uuid.New().String()[:8])
+	cg, err := l.cgroups.newTask(name, res.MaxMemory, threads)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := cg.remove(); err != nil {
+			log.Errorf("removing task cgroup %s: %+v", name, err)
+		}
+	}()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return xerrors.Errorf("getting the worker executable: %w", err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, exe, TaskChildCommand)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return xerrors.Errorf("starting task child process: %w", err)
+	}
+
+	// the child waits for the call before doing any work, all of it is
+	// accounted to the task group
+	if err := cg.add(cmd.Process.Pid); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return xerrors.Errorf("adding task child process to cgroup %s: %w", name, err)
+	}
+	serr := json.NewEncoder(stdin).Encode(&call)
+	if err := stdin.Close(); err != nil && serr == nil {
+		serr = err
+	}
+	werr := cmd.Wait()
+
+	if kills, err := cg.oomKills(); err != nil {
+		log.Errorf("reading oom kills of task cgroup %s: %+v", name, err)
+	} else if kills > 0 {
+		return storiface.Err(storiface.ErrTempOOM, xerrors.Errorf("%s of sector %d killed by the OOM killer, its memory is limited to %d bytes", call.Task, call.Sector.ID.Number, res.MaxMemory))
+	}
+	if serr != nil {
+		return xerrors.Errorf("sending the call to the task child process: %w", serr)
+	}
+	if werr != nil {
+		return xerrors.Errorf("task child process: %w", werr)
+	}
+
+	var tr taskResult
+	if err := json.Unmarshal(stdout.Bytes(), &tr); err != nil {
+		return xerrors.Errorf("decoding task child process result: %w", err)
+	}
+	if tr.Err != "" {
+		return xerrors.New(tr.Err)
+	}
+	return json.Unmarshal(tr.Out, out)
+}
+
+// RunTaskChild runs the ffi work of a task sent by a worker with task cgroups,
+// the call is read from in and its result is written to out.
+func RunTaskChild(ctx context.Context, in io.Reader, out io.Writer) error {
+	var call taskCall
+	if err := json.NewDecoder(in).Decode(&call); err != nil {
+		return xerrors.Errorf("decoding task call: %w", err)
+	}
+
+	sb, err := ffiwrapper.New(&taskPathProvider{paths: call.Paths})
+	if err != nil {
+		return err
+	}
+
+	var res interface{}
+	switch call.Task {
+	case types.TTPreCommit1:
+		res, err = sb.SealPreCommit1(ctx, call.Sector, call.Ticket, call.Pieces)
+	case types.TTPreCommit2:
+		res, err = sb.SealPreCommit2(ctx, call.Sector, call.PreCommit1Out)
+	case types.TTCommit2:
+		res, err = sb.SealCommit2(ctx, call.Sector, call.Commit1Out)
+	case types.TTReplicaUpdate:
+		res, err = sb.ReplicaUpdate(ctx, call.Sector, call.Pieces)
+	case types.TTProveReplicaUpdate2:
+		res, err = sb.ProveReplicaUpdate2(ctx, call.Sector, call.SectorKey, call.NewSealed, call.NewUnsealed, call.VanillaProofs)
+	default:
+		return xerrors.Errorf("task %s can't run in a child process", call.Task)
+	}
+
+	var tr taskResult
+	if err != nil {
+		tr.Err = err.Error()
+	} else if tr.Out, err = json.Marshal(res); err != nil {
+		return xerrors.Errorf("encoding task result: %w", err)
+	}
+	return json.NewEncoder(out).Encode(&tr)
+}
+
+// taskPathProvider hands out the sector paths acquired by the worker for a task
+// child process
+type taskPathProvider struct {
+	paths storiface.SectorPaths
+}
+
+func (p *taskPathProvider) AcquireSector(ctx context.Context, id storage.SectorRef, existing storiface.SectorFileType, allocate storiface.SectorFileType, ptype storiface.PathType) (storiface.SectorPaths, func(), error) {
+	return p.paths, func() {}, nil
+}
@@ -0,0 +1,63 @@
+package sectorstorage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestRetryOOM(t *testing.T) {
+	m := &Manager{}
+	ctx := context.Background()
+	sector := storage.SectorRef{ID: abi.SectorID{Miner: 1000, Number: 1}}
+	oom := xerrors.Errorf("waiting: %w", storiface.Err(storiface.ErrTempOOM, xerrors.New("killed")))
+
+	// killed twice, then done on the third run
+	runs := 0
+	err := m.retryOOM(ctx, types.TTPreCommit1, sector, func() error {
+		runs++
+		if runs < 3 {
+			return oom
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, runs)
+
+	// the error is returned once the retries are exhausted
+	runs = 0
+	err = m.retryOOM(ctx, types.TTPreCommit1, sector, func() error {
+		runs++
+		return oom
+	})
+	require.ErrorIs(t, err, oom)
+	require.Equal(t, OOMRetries+1, runs)
+
+	// other errors aren't retried
+	runs = 0
+	err = m.retryOOM(ctx, types.TTPreCommit1, sector, func() error {
+		runs++
+		return storiface.Err(storiface.ErrTempWorkerRestart, xerrors.New("restarted"))
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, runs)
+}
+
+func TestRunTaskChildUnknownTask(t *testing.T) {
+	var in bytes.Buffer
+	require.NoError(t, json.NewEncoder(&in).Encode(&taskCall{Task: types.TTFetch}))
+
+	var out bytes.Buffer
+	require.Error(t, RunTaskChild(context.Background(), &in, &out))
+	require.Zero(t, out.Len())
+}
@@ -3,7 +3,6 @@ package sectorstorage
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"os"
 	"reflect"
@@ -16,13 +15,16 @@ import (
 	"github.com/google/uuid"
 	"github.com/hashicorp/go-multierror"
 	"github.com/ipfs/go-cid"
+	"go.opencensus.io/stats"
 	"golang.org/x/xerrors"
 
 	ffi "github.com/filecoin-project/filecoin-ffi"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/proof"
 	"github.com/filecoin-project/go-statestore"
 	"github.com/filecoin-project/specs-storage/storage"
 
+	"github.com/filecoin-project/venus-sealer/metrics"
 	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
 	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
@@ -40,7 +42,21 @@ type WorkerConfig struct {
 	// with the local worker.
 	IgnoreResourceFiltering bool
 
+	// TaskTotal limits the number of tasks running at the same time, 0 accepts
+	// no task and a negative value means unlimited
 	TaskTotal int64
+	// TaskLimits limits the number of running tasks per task type, missing or 0 means unlimited
+	TaskLimits map[types.TaskType]int64
+
+	// Resources overrides the resource table, it takes precedence over the
+	// resource env vars
+	Resources storiface.ResourceOverrides
+
+	// TaskCgroups, when set, runs the ffi work of the PC1, PC2, C2, replica
+	// update and prove replica update 2 tasks in a child process, in a cgroup
+	// limited to the memory of the task and pinned to as many CPUs of the
+	// worker CPU set as the task has threads
+	TaskCgroups *TaskCgroups
 }
 
 // used do provide custom proofs impl (mostly used in testing)
@@ -55,6 +71,11 @@ type LocalWorker struct {
 	executor   ExecutorFunc
 	noSwap     bool
 	envLookup  EnvFunc
+	resources  storiface.ResourceOverrides
+	cgroups    *TaskCgroups
+
+	// executor of window PoSt tasks, reading sectors without fetching them
+	postExecutor ExecutorFunc
 
 	// see equivalent field on WorkerConfig.
 	ignoreResources bool
@@ -63,8 +84,12 @@ type LocalWorker struct {
 	acceptTasks map[types.TaskType]struct{}
 	running     sync.WaitGroup
 	taskLk      sync.Mutex
-	taskNumber  int64
-	taskTotal   int64
+
+	taskNumLk    sync.Mutex
+	taskNumber   int64
+	taskTotal    int64
+	taskLimits   map[types.TaskType]int64
+	runningTasks map[types.TaskType]int64
 
 	session     uuid.UUID
 	testDisable int64
@@ -88,9 +113,13 @@ func newLocalWorker(executor ExecutorFunc, wcfg WorkerConfig, envLookup EnvFunc,
 		},
 		acceptTasks:     acceptTasks,
 		taskTotal:       wcfg.TaskTotal,
+		taskLimits:      map[types.TaskType]int64{},
+		runningTasks:    map[types.TaskType]int64{},
 		executor:        executor,
 		noSwap:          wcfg.NoSwap,
 		envLookup:       envLookup,
+		resources:       wcfg.Resources,
+		cgroups:         wcfg.TaskCgroups,
 		ignoreResources: wcfg.IgnoreResourceFiltering,
 		session:         uuid.New(),
 		closing:         make(chan struct{}),
@@ -98,8 +127,19 @@ func newLocalWorker(executor ExecutorFunc, wcfg WorkerConfig, envLookup EnvFunc,
 
 	if w.executor == nil {
 		w.executor = w.ffiExec
+		w.postExecutor = w.ffiPoStExec
+	} else {
+		w.postExecutor = w.executor
+	}
+
+	for tt, limit := range wcfg.TaskLimits {
+		if limit > 0 {
+			w.taskLimits[tt] = limit
+		}
 	}
 
+	stats.Record(context.TODO(), metrics.WorkerTaskTotal.M(w.taskTotal))
+
 	unfinished, err := w.ct.unfinished()
 	if err != nil {
 		log.Errorf("reading unfinished tasks: %+v", err)
@@ -168,7 +208,20 @@ func (l *localWorkerPathProvider) AcquireSector(ctx context.Context, sector stor
 }
 
 func (l *LocalWorker) ffiExec() (ffiwrapper.Storage, error) {
-	return ffiwrapper.New(&localWorkerPathProvider{w: l})
+	sb, err := ffiwrapper.New(&localWorkerPathProvider{w: l})
+	if err != nil {
+		return nil, err
+	}
+	if l.cgroups != nil {
+		return &cgroupSealer{Storage: sb, w: l}, nil
+	}
+	return sb, nil
+}
+
+// ffiPoStExec reads the sectors from the local storage only, sectors missing
+// there are skipped instead of being fetched
+func (l *LocalWorker) ffiPoStExec() (ffiwrapper.Storage, error) {
+	return ffiwrapper.New(&readonlyProvider{stor: l.localStore, index: l.sindex})
 }
 
 // in: func(WorkerReturn, context.Context, CallID, err string)
@@ -225,11 +278,10 @@ var returnFunc = map[types.ReturnType]func(context.Context, types.CallID, storif
 }
 
 func (l *LocalWorker) asyncCall(ctx context.Context, sector storage.SectorRef, rt types.ReturnType, work func(ctx context.Context, ci types.CallID) (interface{}, error)) (types.CallID, error) {
-	taskNumber := atomic.AddInt64(&l.taskNumber, 1)
-	if l.taskTotal >= 0 && taskNumber > l.taskTotal {
-		atomic.AddInt64(&l.taskNumber, -1)
-		log.Errorf("task number [%d-%d]", taskNumber-1, l.taskTotal)
-		return types.CallID{}, xerrors.Errorf("The number of tasks has reached the upper limit [%d-%d] ", taskNumber-1, l.taskTotal)
+	tt := taskTypeOf(rt)
+	if err := l.acquireTask(tt); err != nil {
+		log.Error(err)
+		return types.CallID{}, err
 	}
 
 	ci := types.CallID{
@@ -242,11 +294,14 @@ func (l *LocalWorker) asyncCall(ctx context.Context, sector storage.SectorRef, r
 	}
 
 	l.running.Add(1)
+	start := time.Now()
 
 	go func() {
+		var err error
 		defer func() {
 			log.Infof("task [%s] complete for sector %d", rt, sector.ID.Number)
-			atomic.AddInt64(&l.taskNumber, -1)
+			l.releaseTask(tt)
+			recordTaskDuration(tt, start, err)
 			l.running.Done()
 		}()
 
@@ -255,7 +310,8 @@ func (l *LocalWorker) asyncCall(ctx context.Context, sector storage.SectorRef, r
 			closing: l.closing,
 		}
 
-		res, err := work(ctx, ci)
+		var res interface{}
+		res, err = work(ctx, ci)
 		if err != nil {
 			rb, err := json.Marshal(res)
 			if err != nil {
@@ -350,8 +406,8 @@ func (l *LocalWorker) AddPiece(ctx context.Context, sector storage.SectorRef, ep
 	})
 }
 
-func (l *LocalWorker) Fetch(ctx context.Context, sector storage.SectorRef, fileType storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode) (types.CallID, error) {
-	return l.asyncCall(ctx, sector, types.ReturnFetch, func(ctx context.Context, ci types.CallID) (interface{}, error) {
+func (l *LocalWorker) Fetch(ctx context.Context, sector storage.SectorRef, fileType storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode, priority int) (types.CallID, error) {
+	return l.asyncCall(types.WithPriority(ctx, priority), sector, types.ReturnFetch, func(ctx context.Context, ci types.CallID) (interface{}, error) {
 		_, done, err := (&localWorkerPathProvider{w: l, op: am}).AcquireSector(ctx, sector, fileType, storiface.FTNone, ptype)
 		if err == nil {
 			done()
@@ -568,6 +624,37 @@ func (l *LocalWorker) UnsealPiece(ctx context.Context, sector storage.SectorRef,
 	})
 }
 
+// GenerateWindowPoSt proves the sectors of the partition at partitionIdx of a
+// window PoSt batch, the sealed sectors are read through the storage paths of
+// the worker.
+func (l *LocalWorker) GenerateWindowPoSt(ctx context.Context, minerID abi.ActorID, sectors []proof.ExtendedSectorInfo, partitionIdx int, randomness abi.PoStRandomness) (storiface.WindowPoStResult, error) {
+	sb, err := l.postExecutor()
+	if err != nil {
+		return storiface.WindowPoStResult{}, err
+	}
+
+	if err := l.acquireTask(types.TTGenerateWindowPoSt); err != nil {
+		return storiface.WindowPoStResult{}, err
+	}
+	start := time.Now()
+	defer func() {
+		l.releaseTask(types.TTGenerateWindowPoSt)
+		recordTaskDuration(types.TTGenerateWindowPoSt, start, err)
+	}()
+
+	log.Infof("worker will generate window post of partition %d, %d sectors", partitionIdx, len(sectors))
+	out, skipped, err := sb.GenerateWindowPoStPartition(ctx, minerID, sectors, partitionIdx, randomness)
+	if len(skipped) > 0 {
+		log.Warnw("window post skipped sectors", "sectors", skipped, "error", err)
+		return storiface.WindowPoStResult{Skipped: skipped}, nil
+	}
+	if err != nil {
+		return storiface.WindowPoStResult{}, xerrors.Errorf("generating window post: %w", err)
+	}
+
+	return storiface.WindowPoStResult{PoStProof: out}, nil
+}
+
 func (l *LocalWorker) TaskTypes(context.Context) (map[types.TaskType]struct{}, error) {
 	l.taskLk.Lock()
 	defer l.taskLk.Unlock()
@@ -595,9 +682,56 @@ func (l *LocalWorker) Paths(ctx context.Context) ([]stores.StoragePath, error) {
 	return l.localStore.Local(ctx)
 }
 
-func (l *LocalWorker) TaskNumbers(context.Context) (string, error) {
-	str := fmt.Sprintf("%d-%d", l.taskNumber, l.taskTotal)
-	return str, nil
+func (l *LocalWorker) Capacity(context.Context) (storiface.WorkerCapacity, error) {
+	l.taskNumLk.Lock()
+	defer l.taskNumLk.Unlock()
+
+	return l.capacity(), nil
+}
+
+// capacity must be called with taskNumLk held
+func (l *LocalWorker) capacity() storiface.WorkerCapacity {
+	out := storiface.WorkerCapacity{
+		Running:       l.taskNumber,
+		Total:         l.taskTotal,
+		RunningByType: make(map[types.TaskType]int64, len(l.runningTasks)),
+		LimitByType:   make(map[types.TaskType]int64, len(l.taskLimits)),
+	}
+	for tt, n := range l.runningTasks {
+		if n > 0 {
+			out.RunningByType[tt] = n
+		}
+	}
+	for tt, limit := range l.taskLimits {
+		out.LimitByType[tt] = limit
+	}
+	return out
+}
+
+// acquireTask counts a new task of the type, it fails when the worker already
+// runs as many tasks as it accepts
+func (l *LocalWorker) acquireTask(tt types.TaskType) error {
+	l.taskNumLk.Lock()
+	defer l.taskNumLk.Unlock()
+
+	if !l.capacity().CanAccept(tt) {
+		return xerrors.Errorf("the number of tasks has reached the upper limit, %s: [%d-%d], total: [%d-%d]",
+			tt, l.runningTasks[tt], l.taskLimits[tt], l.taskNumber, l.taskTotal)
+	}
+
+	l.taskNumber++
+	l.runningTasks[tt]++
+	recordTaskNumbers(tt, l.runningTasks[tt], l.taskNumber)
+	return nil
+}
+
+func (l *LocalWorker) releaseTask(tt types.TaskType) {
+	l.taskNumLk.Lock()
+	defer l.taskNumLk.Unlock()
+
+	l.taskNumber--
+	l.runningTasks[tt]--
+	recordTaskNumbers(tt, l.runningTasks[tt], l.taskNumber)
 }
 
 func (l *LocalWorker) memInfo() (memPhysical, memUsed, memSwap, memSwapUsed uint64, err error) {
@@ -646,6 +780,20 @@ func (l *LocalWorker) memInfo() (memPhysical, memUsed, memSwap, memSwapUsed uint
 	return memPhysical, memUsed, memSwap, memSwapUsed, nil
 }
 
+// resourceTable returns the resource table of the worker, with the overrides of
+// its config and the resource env vars
+func (l *LocalWorker) resourceTable() (map[types.TaskType]map[abi.RegisteredSealProof]storiface.Resources, error) {
+	lookup, err := storiface.ResourceLookup(l.resources, l.envLookup)
+	if err != nil {
+		return nil, xerrors.Errorf("interpreting resource overrides: %w", err)
+	}
+	resEnv, err := storiface.ParseResourceEnv(lookup)
+	if err != nil {
+		return nil, xerrors.Errorf("interpreting resource env vars: %w", err)
+	}
+	return resEnv, nil
+}
+
 func (l *LocalWorker) Info(context.Context) (storiface.WorkerInfo, error) {
 	hostname, err := os.Hostname() // TODO: allow overriding from config
 	if err != nil {
@@ -662,11 +810,13 @@ func (l *LocalWorker) Info(context.Context) (storiface.WorkerInfo, error) {
 		return storiface.WorkerInfo{}, xerrors.Errorf("getting memory info: %w", err)
 	}
 
-	resEnv, err := storiface.ParseResourceEnv(func(key, def string) (string, bool) {
-		return l.envLookup(key)
-	})
+	resEnv, err := l.resourceTable()
+	if err != nil {
+		return storiface.WorkerInfo{}, err
+	}
+	overrides, err := storiface.DiffResourceTable(resEnv)
 	if err != nil {
-		return storiface.WorkerInfo{}, xerrors.Errorf("interpreting resource env vars: %w", err)
+		return storiface.WorkerInfo{}, xerrors.Errorf("comparing resources with the defaults: %w", err)
 	}
 
 	return storiface.WorkerInfo{
@@ -680,6 +830,7 @@ func (l *LocalWorker) Info(context.Context) (storiface.WorkerInfo, error) {
 			CPUs:        uint64(runtime.NumCPU()),
 			GPUs:        gpus,
 			Resources:   resEnv,
+			Overrides:   overrides,
 		},
 	}, nil
 }
@@ -0,0 +1,56 @@
+package sectorstorage
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/filecoin-project/venus-sealer/metrics"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// returnTaskTypes maps the return of an async call to the task type the
+// scheduler assigned it with
+var returnTaskTypes = map[types.ReturnType]types.TaskType{
+	types.ReturnDataCid:               types.TTDataCid,
+	types.ReturnAddPiece:              types.TTAddPiece,
+	types.ReturnSealPreCommit1:        types.TTPreCommit1,
+	types.ReturnSealPreCommit2:        types.TTPreCommit2,
+	types.ReturnSealCommit1:           types.TTCommit1,
+	types.ReturnSealCommit2:           types.TTCommit2,
+	types.ReturnFinalizeSector:        types.TTFinalize,
+	types.ReturnFinalizeReplicaUpdate: types.TTFinalizeReplicaUpdate,
+	types.ReturnReplicaUpdate:         types.TTReplicaUpdate,
+	types.ReturnProveReplicaUpdate1:   types.TTProveReplicaUpdate1,
+	types.ReturnProveReplicaUpdate2:   types.TTProveReplicaUpdate2,
+	types.ReturnGenerateSectorKey:     types.TTRegenSectorKey,
+	types.ReturnMoveStorage:           types.TTFetch,
+	types.ReturnUnsealPiece:           types.TTUnseal,
+	types.ReturnFetch:                 types.TTFetch,
+}
+
+func taskTypeOf(rt types.ReturnType) types.TaskType {
+	if tt, ok := returnTaskTypes[rt]; ok {
+		return tt
+	}
+	return types.TaskType(rt)
+}
+
+func recordTaskNumbers(tt types.TaskType, running, total int64) {
+	_ = stats.RecordWithTags(context.TODO(), []tag.Mutator{tag.Upsert(metrics.TaskType, string(tt))},
+		metrics.WorkerTasksRunning.M(running),
+		metrics.WorkerTaskNumber.M(total))
+}
+
+func recordTaskDuration(tt types.TaskType, start time.Time, err error) {
+	result := "succeeded"
+	if err != nil {
+		result = "failed"
+	}
+	_ = stats.RecordWithTags(context.TODO(), []tag.Mutator{
+		tag.Upsert(metrics.TaskType, string(tt)),
+		tag.Upsert(metrics.Result, result),
+	}, metrics.WorkerTaskDuration.M(time.Since(start).Seconds()))
+}
@@ -177,8 +177,8 @@ func (t *trackedWorker) AddPiece(ctx context.Context, sector storage.SectorRef,
 	})
 }
 
-func (t *trackedWorker) Fetch(ctx context.Context, s storage.SectorRef, ft storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode) (types.CallID, error) {
-	return t.tracker.track(ctx, t.execute, t.wid, t.workerInfo, s, types.TTFetch, func() (types.CallID, error) { return t.Worker.Fetch(ctx, s, ft, ptype, am) })
+func (t *trackedWorker) Fetch(ctx context.Context, s storage.SectorRef, ft storiface.SectorFileType, ptype storiface.PathType, am storiface.AcquireMode, priority int) (types.CallID, error) {
+	return t.tracker.track(ctx, t.execute, t.wid, t.workerInfo, s, types.TTFetch, func() (types.CallID, error) { return t.Worker.Fetch(ctx, s, ft, ptype, am, priority) })
 }
 
 func (t *trackedWorker) UnsealPiece(ctx context.Context, id storage.SectorRef, index storiface.UnpaddedByteIndex, size abi.UnpaddedPieceSize, randomness abi.SealRandomness, cid cid.Cid) (types.CallID, error) {
@@ -0,0 +1,11 @@
+package service
+
+import "github.com/filecoin-project/venus-sealer/models/repo"
+
+type DealAssignmentService struct {
+	repo.DealAssignmentRepo
+}
+
+func NewDealAssignmentService(repo repo.Repo) *DealAssignmentService {
+	return &DealAssignmentService{DealAssignmentRepo: repo.DealAssignmentRepo()}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
+	"github.com/filecoin-project/venus-sealer/sector-storage/stores"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+var _ stores.IndexStore = (*StorageIndexService)(nil)
+
+type StorageIndexService struct {
+	repo.StorageIndexRepo
+}
+
+func NewStorageIndexService(repo repo.Repo) *StorageIndexService {
+	return &StorageIndexService{StorageIndexRepo: repo.StorageIndexRepo()}
+}
+
+func (s *StorageIndexService) ListStorage(ctx context.Context) ([]stores.StorageRecord, error) {
+	paths, err := s.StorageIndexRepo.GetAllStoragePaths()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]stores.StorageRecord, len(paths))
+	for index, path := range paths {
+		out[index] = stores.StorageRecord{
+			Info: stores.StorageInfo{
+				ID:         stores.ID(path.ID),
+				URLs:       path.URLs,
+				Weight:     path.Weight,
+				MaxStorage: path.MaxStorage,
+				CanSeal:    path.CanSeal,
+				CanStore:   path.CanStore,
+				Groups:     path.Groups,
+				AllowTo:    path.AllowTo,
+			},
+			Stat: path.Stat,
+		}
+	}
+	return out, nil
+}
+
+func (s *StorageIndexService) ListDecls(ctx context.Context) ([]stores.DeclRecord, error) {
+	decls, err := s.StorageIndexRepo.GetAllSectorDecls()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]stores.DeclRecord, len(decls))
+	for index, decl := range decls {
+		out[index] = stores.DeclRecord{
+			Decl: stores.Decl{
+				SectorID:       decl.Sector,
+				SectorFileType: storiface.SectorFileType(decl.FileType),
+			},
+			Storage: stores.ID(decl.StorageID),
+			Primary: decl.Primary,
+		}
+	}
+	return out, nil
+}
+
+func (s *StorageIndexService) SaveStorage(ctx context.Context, si stores.StorageInfo, st fsutil.FsStat) error {
+	return s.StorageIndexRepo.SaveStoragePath(toStoragePath(si, st))
+}
+
+func (s *StorageIndexService) ResetStorage(ctx context.Context, si stores.StorageInfo, st fsutil.FsStat) error {
+	return s.StorageIndexRepo.ResetStoragePath(toStoragePath(si, st))
+}
+
+func (s *StorageIndexService) DeclareSector(ctx context.Context, storageID stores.ID, d stores.Decl, primary bool) error {
+	return s.StorageIndexRepo.SaveSectorDecl(&types.SectorDecl{
+		StorageID: string(storageID),
+		Sector:    d.SectorID,
+		FileType:  int(d.SectorFileType),
+		Primary:   primary,
+	})
+}
+
+func (s *StorageIndexService) DropSector(ctx context.Context, storageID stores.ID, d stores.Decl) error {
+	return s.StorageIndexRepo.DeleteSectorDecl(&types.SectorDecl{
+		StorageID: string(storageID),
+		Sector:    d.SectorID,
+		FileType:  int(d.SectorFileType),
+	})
+}
+
+func toStoragePath(si stores.StorageInfo, st fsutil.FsStat) *types.StoragePath {
+	return &types.StoragePath{
+		ID:         string(si.ID),
+		URLs:       si.URLs,
+		Weight:     si.Weight,
+		MaxStorage: si.MaxStorage,
+		CanSeal:    si.CanSeal,
+		CanStore:   si.CanStore,
+		Groups:     si.Groups,
+		AllowTo:    si.AllowTo,
+		Stat:       st,
+	}
+}
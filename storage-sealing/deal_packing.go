@@ -0,0 +1,164 @@
+package sealing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus/venus-shared/types/market"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/venus-sealer/storage-sealing/sealiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type packingBin struct {
+	sector *types.PlannedSector
+	urgent bool
+}
+
+// planDealPacking assigns the unpacked deals to sectors of size ssize holding
+// at most maxDeals deals each:
+//   - deals which can't be sealed before they start, or don't fit a sector are deferred
+//   - deals are placed by start epoch, the most urgent first
+//   - a sector only holds the deals of one group, see packingGroup, with end
+//     epochs at most MaxEndEpochSpread apart so that its expiration is tight
+//   - sectors filled below MinUtilisation are deferred to wait for more deals,
+//     unless they hold a deal starting within UrgentStartEpochs
+func planDealPacking(deals []*market.DealInfoIncludePath, ssize abi.SectorSize, maxDeals int, head, sealingBuffer abi.ChainEpoch, cfg sealiface.DealPackingConfig) *types.DealPackingPlan {
+	plan := &types.DealPackingPlan{SectorSize: ssize, Head: head}
+	capacity := abi.PaddedPieceSize(ssize)
+
+	candidates := make([]*market.DealInfoIncludePath, 0, len(deals))
+	for _, deal := range deals {
+		switch {
+		case head+sealingBuffer > deal.StartEpoch:
+			plan.Deferred = append(plan.Deferred, deferDeal(deal, "deal starts before it can be sealed"))
+		case deal.PieceSize > capacity:
+			plan.Deferred = append(plan.Deferred, deferDeal(deal, "piece doesn't fit into a sector"))
+		default:
+			candidates = append(candidates, deal)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].StartEpoch != candidates[j].StartEpoch {
+			return candidates[i].StartEpoch < candidates[j].StartEpoch
+		}
+		return candidates[i].DealID < candidates[j].DealID
+	})
+
+	var bins []*packingBin
+	for _, deal := range candidates {
+		group := packingGroup(deal, cfg)
+
+		var bin *packingBin
+		for _, b := range bins {
+			if b.sector.Group == group && fitsBin(b.sector, deal, capacity, maxDeals, cfg.MaxEndEpochSpread) {
+				bin = b
+				break
+			}
+		}
+		if bin == nil {
+			bin = &packingBin{sector: &types.PlannedSector{
+				Group:      group,
+				StartEpoch: deal.StartEpoch,
+				EndEpoch:   deal.EndEpoch,
+			}}
+			bins = append(bins, bin)
+		}
+
+		_, padLength := ffiwrapper.GetRequiredPadding(bin.sector.Used, deal.PieceSize)
+		bin.sector.Used += padLength + deal.PieceSize
+		bin.sector.Deals = append(bin.sector.Deals, plannedDeal(deal))
+		if deal.StartEpoch < bin.sector.StartEpoch {
+			bin.sector.StartEpoch = deal.StartEpoch
+		}
+		if deal.EndEpoch > bin.sector.EndEpoch {
+			bin.sector.EndEpoch = deal.EndEpoch
+		}
+		if deal.StartEpoch <= head+sealingBuffer+cfg.UrgentStartEpochs {
+			bin.urgent = true
+		}
+	}
+
+	for _, bin := range bins {
+		sector := bin.sector
+		sector.Utilisation = float64(sector.Used) / float64(capacity)
+
+		if sector.Utilisation < cfg.MinUtilisation && !bin.urgent {
+			reason := fmt.Sprintf("sector %.1f%% full, waiting for %.1f%%", sector.Utilisation*100, cfg.MinUtilisation*100)
+			for _, deal := range sector.Deals {
+				plan.Deferred = append(plan.Deferred, &types.DeferredDeal{PlannedDeal: *deal, Reason: reason})
+			}
+			continue
+		}
+		plan.Sectors = append(plan.Sectors, sector)
+	}
+
+	return plan
+}
+
+// packingGroup returns the key of the deals which can share a sector.
+func packingGroup(deal *market.DealInfoIncludePath, cfg sealiface.DealPackingConfig) string {
+	var key []string
+	if cfg.GroupByClient {
+		key = append(key, deal.Client.String())
+	}
+	if cfg.SeparateVerified {
+		if deal.VerifiedDeal {
+			key = append(key, "verified")
+		} else {
+			key = append(key, "unverified")
+		}
+	}
+	if len(key) == 0 {
+		return "any"
+	}
+	return strings.Join(key, "/")
+}
+
+func fitsBin(sector *types.PlannedSector, deal *market.DealInfoIncludePath, capacity abi.PaddedPieceSize, maxDeals int, maxSpread abi.ChainEpoch) bool {
+	if len(sector.Deals) >= maxDeals {
+		return false
+	}
+
+	_, padLength := ffiwrapper.GetRequiredPadding(sector.Used, deal.PieceSize)
+	if sector.Used+padLength+deal.PieceSize > capacity {
+		return false
+	}
+
+	if maxSpread > 0 {
+		minEnd, maxEnd := deal.EndEpoch, deal.EndEpoch
+		for _, d := range sector.Deals {
+			if d.EndEpoch < minEnd {
+				minEnd = d.EndEpoch
+			}
+			if d.EndEpoch > maxEnd {
+				maxEnd = d.EndEpoch
+			}
+		}
+		if maxEnd-minEnd > maxSpread {
+			return false
+		}
+	}
+
+	return true
+}
+
+func plannedDeal(deal *market.DealInfoIncludePath) *types.PlannedDeal {
+	return &types.PlannedDeal{
+		DealID:     deal.DealID,
+		PieceCID:   deal.PieceCID,
+		Size:       deal.PieceSize,
+		Client:     deal.Client,
+		Verified:   deal.VerifiedDeal,
+		StartEpoch: deal.StartEpoch,
+		EndEpoch:   deal.EndEpoch,
+	}
+}
+
+func deferDeal(deal *market.DealInfoIncludePath, reason string) *types.DeferredDeal {
+	return &types.DeferredDeal{PlannedDeal: *plannedDeal(deal), Reason: reason}
+}
@@ -0,0 +1,161 @@
+package sealing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/market"
+
+	tutils "github.com/filecoin-project/specs-actors/v2/support/testing"
+
+	mtypes "github.com/filecoin-project/venus/venus-shared/types/market"
+
+	"github.com/filecoin-project/venus-sealer/storage-sealing/sealiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func testDeal(id abi.DealID, client address.Address, verified bool, size abi.PaddedPieceSize, start, end abi.ChainEpoch) *mtypes.DealInfoIncludePath {
+	return &mtypes.DealInfoIncludePath{
+		DealProposal: market.DealProposal{
+			PieceSize:    size,
+			Client:       client,
+			VerifiedDeal: verified,
+			StartEpoch:   start,
+			EndEpoch:     end,
+		},
+		Length: size,
+		DealID: id,
+	}
+}
+
+func plannedIDs(sector *types.PlannedSector) []abi.DealID {
+	var ids []abi.DealID
+	for _, deal := range sector.Deals {
+		ids = append(ids, deal.DealID)
+	}
+	return ids
+}
+
+func TestPlanDealPacking(t *testing.T) {
+	clientA := tutils.NewIDAddr(t, 1001)
+	clientB := tutils.NewIDAddr(t, 1002)
+
+	const (
+		ssize  = abi.SectorSize(2048)
+		head   = abi.ChainEpoch(100)
+		buffer = abi.ChainEpoch(10)
+	)
+
+	cfg := sealiface.DealPackingConfig{
+		GroupByClient:     true,
+		SeparateVerified:  true,
+		MaxEndEpochSpread: 1000,
+		UrgentStartEpochs: 100,
+	}
+
+	deals := []*mtypes.DealInfoIncludePath{
+		testDeal(1, clientA, false, 512, 500, 5000),
+		testDeal(2, clientA, false, 512, 300, 5200),
+		testDeal(3, clientB, false, 512, 400, 5000),
+		testDeal(4, clientA, true, 512, 600, 5000),
+		testDeal(5, clientA, false, 512, 700, 9000),  // expires too late for the sector of 1 and 2
+		testDeal(6, clientA, false, 512, 105, 5000),  // can't be sealed in time
+		testDeal(7, clientA, false, 4096, 500, 5000), // bigger than a sector
+	}
+
+	t.Run("grouping", func(t *testing.T) {
+		plan := planDealPacking(deals, ssize, 4, head, buffer, cfg)
+
+		require.Len(t, plan.Deferred, 2)
+		require.Equal(t, abi.DealID(6), plan.Deferred[0].DealID)
+		require.Equal(t, abi.DealID(7), plan.Deferred[1].DealID)
+
+		// sectors are ordered by the start of their most urgent deal
+		require.Len(t, plan.Sectors, 4)
+		require.Equal(t, []abi.DealID{2, 1}, plannedIDs(plan.Sectors[0]))
+		require.Equal(t, abi.ChainEpoch(300), plan.Sectors[0].StartEpoch)
+		require.Equal(t, abi.ChainEpoch(5200), plan.Sectors[0].EndEpoch)
+		require.Equal(t, 0.5, plan.Sectors[0].Utilisation)
+		require.Equal(t, []abi.DealID{3}, plannedIDs(plan.Sectors[1]))
+		require.Equal(t, []abi.DealID{4}, plannedIDs(plan.Sectors[2]))
+		require.Equal(t, clientA.String()+"/verified", plan.Sectors[2].Group)
+		require.Equal(t, []abi.DealID{5}, plannedIDs(plan.Sectors[3]))
+	})
+
+	t.Run("deal limit", func(t *testing.T) {
+		plan := planDealPacking(deals[:2], ssize, 1, head, buffer, cfg)
+		require.Len(t, plan.Sectors, 2)
+		require.Equal(t, []abi.DealID{2}, plannedIDs(plan.Sectors[0]))
+		require.Equal(t, []abi.DealID{1}, plannedIDs(plan.Sectors[1]))
+	})
+
+	t.Run("min utilisation", func(t *testing.T) {
+		cfg := cfg
+		cfg.MinUtilisation = 0.5
+
+		plan := planDealPacking(deals[:5], ssize, 4, head, buffer, cfg)
+
+		// 3, 4 and 5 fill a quarter of their sectors and don't start soon
+		require.Len(t, plan.Sectors, 1)
+		require.Equal(t, []abi.DealID{2, 1}, plannedIDs(plan.Sectors[0]))
+		require.Len(t, plan.Deferred, 3)
+
+		// an urgent deal is packed anyway
+		plan = planDealPacking([]*mtypes.DealInfoIncludePath{testDeal(8, clientB, false, 512, 150, 5000)}, ssize, 4, head, buffer, cfg)
+		require.Len(t, plan.Sectors, 1)
+		require.Empty(t, plan.Deferred)
+	})
+}
+
+func TestUpdateInputDealBins(t *testing.T) {
+	binComm, anyComm := [32]byte{1}, [32]byte{2}
+	binPiece, err := commcid.PieceCommitmentV1ToCID(binComm[:])
+	require.NoError(t, err)
+	anyPiece, err := commcid.PieceCommitmentV1ToCID(anyComm[:])
+	require.NoError(t, err)
+
+	accepted := map[abi.SectorNumber][]cid.Cid{}
+	open := func(sn abi.SectorNumber, used abi.UnpaddedPieceSize) *openSector {
+		return &openSector{
+			number: sn,
+			used:   used,
+			maybeAccept: func(c cid.Cid) error {
+				accepted[sn] = append(accepted[sn], c)
+				return nil
+			},
+		}
+	}
+
+	bin := abi.SectorNumber(3)
+	m := &Sealing{
+		maddr: tutils.NewIDAddr(t, 1000),
+		openSectors: map[abi.SectorID]*openSector{
+			{Miner: 1000, Number: 1}: open(1, 0),
+			{Miner: 1000, Number: 2}: open(2, 0),
+			{Miner: 1000, Number: 3}: open(3, 508),
+		},
+		pendingPieces: map[cid.Cid]*pendingPiece{
+			binPiece: {size: 508, deal: types.PieceDealInfo{DealProposal: &market.DealProposal{}}, sector: &bin},
+			anyPiece: {size: 508, deal: types.PieceDealInfo{DealProposal: &market.DealProposal{}}},
+		},
+		dealBins: map[abi.SectorID]int{
+			{Miner: 1000, Number: 2}: 1,
+			{Miner: 1000, Number: 3}: 2,
+		},
+	}
+
+	require.NoError(t, m.updateInput(context.Background(), abi.RegisteredSealProof_StackedDrg2KiBV1))
+
+	// the bin piece goes to its partly filled sector, the other piece to the
+	// only open sector which isn't reserved to a bin
+	require.Equal(t, map[abi.SectorNumber][]cid.Cid{
+		1: {anyPiece},
+		3: {binPiece},
+	}, accepted)
+}
@@ -0,0 +1,109 @@
+package sealing
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus-sealer/storage-sealing/sealiface"
+)
+
+// how often the config is checked while polling is disabled
+const dealPollConfigCheck = time.Minute
+
+// runDealPolling packs the unpacked deals of venus-market every PollInterval,
+// as `sectors deal` does, see pollDeals.
+func (m *Sealing) runDealPolling(ctx context.Context) {
+	if m.pieceStorageMrg == nil {
+		return
+	}
+	m.startupWait.Wait()
+
+	var backoff time.Duration
+	for {
+		wait := dealPollConfigCheck
+		cfg, err := m.getConfig()
+		if err != nil {
+			log.Errorf("deal polling: getting config: %v", err)
+		} else if cfg.DealPacking.PollInterval > 0 {
+			wait = cfg.DealPacking.PollInterval
+			if backoff > 0 {
+				wait = backoff
+			}
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return
+		}
+
+		cfg, err = m.getConfig()
+		if err != nil || cfg.DealPacking.PollInterval <= 0 {
+			backoff = 0
+			continue
+		}
+
+		backoff = m.pollDeals(ctx, cfg, backoff)
+	}
+}
+
+// pollDeals runs a packing of the deals, which creates sectors for them as long
+// as MaxWaitDealsSectors and MaxSealingSectorsForDeals allow it, see
+// checkDealSectorLimits. It returns the delay before the next run when
+// venus-market or piece storage can't be reached, 0 otherwise.
+func (m *Sealing) pollDeals(ctx context.Context, cfg sealiface.Config, backoff time.Duration) time.Duration {
+	if retried := m.retryDealAssignments(ctx); len(retried) > 0 {
+		log.Infof("deal polling: reported %d packed deals to venus-market", len(retried))
+	}
+
+	if err := m.checkDealSectorLimits(cfg); err != nil {
+		log.Debugf("deal polling: %v", err)
+		return 0
+	}
+
+	assigned, unreachable, err := m.dealSector(ctx)
+	switch {
+	case xerrors.Is(err, errDealSectorLimit):
+		log.Debugf("deal polling: %v", err)
+		return 0
+	case err != nil:
+		log.Errorf("deal polling: %v", err)
+	case unreachable > 0 && len(assigned) == 0:
+		log.Warnf("deal polling: %d pieces unreachable in piece storage", unreachable)
+	default:
+		if len(assigned) > 0 {
+			log.Infof("deal polling: packed %d deals", len(assigned))
+		}
+		return 0
+	}
+
+	backoff *= 2
+	if backoff < 2*cfg.DealPacking.PollInterval {
+		backoff = 2 * cfg.DealPacking.PollInterval
+	}
+	if cfg.DealPacking.PollMaxBackoff > 0 && backoff > cfg.DealPacking.PollMaxBackoff {
+		backoff = cfg.DealPacking.PollMaxBackoff
+	}
+	log.Infof("deal polling: next run in %s", backoff)
+	return backoff
+}
+
+// errDealSectorLimit is wrapped by the errors of checkDealSectorLimits
+var errDealSectorLimit = xerrors.New("no sector can be created for deals")
+
+// checkDealSectorLimits returns why no sector can be created for deals now,
+// nil when one can.
+func (m *Sealing) checkDealSectorLimits(cfg sealiface.Config) error {
+	if !cfg.MakeNewSectorForDeals {
+		return xerrors.Errorf("%w: MakeNewSectorForDeals is disabled, the deals are only packed into new sectors", errDealSectorLimit)
+	}
+	if staging := m.stats.CurStaging(); cfg.MaxWaitDealsSectors > 0 && staging >= cfg.MaxWaitDealsSectors {
+		return xerrors.Errorf("%w: %d sectors waiting for deals, MaxWaitDealsSectors is %d", errDealSectorLimit, staging, cfg.MaxWaitDealsSectors)
+	}
+	if sealing := m.stats.CurSealing(); cfg.MaxSealingSectorsForDeals > 0 && sealing >= cfg.MaxSealingSectorsForDeals {
+		return xerrors.Errorf("%w: %d sectors sealing, MaxSealingSectorsForDeals is %d", errDealSectorLimit, sealing, cfg.MaxSealingSectorsForDeals)
+	}
+	return nil
+}
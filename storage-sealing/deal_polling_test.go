@@ -0,0 +1,49 @@
+package sealing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/storage-sealing/sealiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestCheckDealSectorLimits(t *testing.T) {
+	m := &Sealing{
+		stats: types.SectorStats{
+			BySector: map[abi.SectorID]types.SectorState{},
+			ByState:  map[types.SectorState]int64{},
+		},
+	}
+	cfg := sealiface.Config{
+		MakeNewSectorForDeals:     true,
+		MaxWaitDealsSectors:       2,
+		MaxSealingSectorsForDeals: 3, // staging sectors count as sealing too
+	}
+
+	err := m.checkDealSectorLimits(sealiface.Config{})
+	require.True(t, xerrors.Is(err, errDealSectorLimit))
+	require.Contains(t, err.Error(), "MakeNewSectorForDeals is disabled")
+	require.NoError(t, m.checkDealSectorLimits(cfg))
+
+	m.stats.UpdateSector(cfg, abi.SectorID{Miner: 1000, Number: 1}, types.WaitDeals)
+	m.stats.UpdateSector(cfg, abi.SectorID{Miner: 1000, Number: 2}, types.AddPiece)
+	err = m.checkDealSectorLimits(cfg)
+	require.True(t, xerrors.Is(err, errDealSectorLimit))
+	require.Contains(t, err.Error(), "MaxWaitDealsSectors is 2")
+
+	m.stats.UpdateSector(cfg, abi.SectorID{Miner: 1000, Number: 2}, types.PreCommit1)
+	require.NoError(t, m.checkDealSectorLimits(cfg))
+
+	m.stats.UpdateSector(cfg, abi.SectorID{Miner: 1000, Number: 3}, types.PreCommit2)
+	err = m.checkDealSectorLimits(cfg)
+	require.True(t, xerrors.Is(err, errDealSectorLimit))
+	require.Contains(t, err.Error(), "MaxSealingSectorsForDeals is 3")
+
+	cfg.MaxSealingSectorsForDeals = 0
+	require.NoError(t, m.checkDealSectorLimits(cfg))
+}
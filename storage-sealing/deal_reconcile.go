@@ -0,0 +1,115 @@
+package sealing
+
+import (
+	"context"
+	"math"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus/venus-shared/types/market"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type dealLocation struct {
+	sector abi.SectorNumber
+	offset abi.PaddedPieceSize
+}
+
+// ReconcileDeals compares the deals of venus-market with the deal pieces of the
+// local sectors and returns the deals they disagree on. With fix, the sector
+// pieces are taken as the truth and venus-market is updated to match them.
+func (m *Sealing) ReconcileDeals(ctx context.Context, fix bool) ([]*types.DealMismatch, error) {
+	m.startupWait.Wait()
+
+	sectors, err := m.ListSectors()
+	if err != nil {
+		return nil, xerrors.Errorf("listing sectors: %w", err)
+	}
+	local := map[abi.DealID]dealLocation{}
+	for _, sector := range sectors {
+		if sector.State == types.Removed || sector.State == types.Removing {
+			continue
+		}
+		for dealID, offset := range sectorDealOffsets(sector) {
+			local[dealID] = dealLocation{sector: sector.SectorNumber, offset: offset}
+		}
+	}
+
+	// pieces being added aren't in the sector pieces yet
+	adding := map[abi.DealID]struct{}{}
+	m.inputLk.Lock()
+	for _, piece := range m.pendingPieces {
+		adding[piece.deal.DealID] = struct{}{}
+	}
+	m.inputLk.Unlock()
+
+	deals, err := m.api.GetDeals(ctx, m.maddr, 0, math.MaxInt32)
+	if err != nil {
+		return nil, xerrors.Errorf("getting deals from venus-market: %w", err)
+	}
+
+	var mismatches []*types.DealMismatch
+	for _, deal := range deals {
+		if _, ok := adding[deal.DealID]; ok {
+			continue
+		}
+
+		mismatch := reconcileDeal(deal, local)
+		if mismatch == nil {
+			continue
+		}
+		mismatches = append(mismatches, mismatch)
+
+		if !fix {
+			continue
+		}
+
+		switch mismatch.Kind {
+		case types.DealUnreported, types.DealMisplaced:
+			err = m.api.UpdateDealOnPacking(ctx, m.maddr, deal.DealID, mismatch.SectorNumber, mismatch.Offset)
+		case types.DealMissing:
+			err = m.api.UpdateDealStatus(ctx, m.maddr, deal.DealID, market.Undefine)
+		}
+		if err != nil {
+			mismatch.Error = err.Error()
+			continue
+		}
+		mismatch.Fixed = true
+
+		if err := m.assignmentService.DeleteDealAssignment(deal.DealID); err != nil {
+			log.Errorf("dropping assignment of deal %d: %v", deal.DealID, err)
+		}
+	}
+
+	return mismatches, nil
+}
+
+// reconcileDeal compares a venus-market deal with where the sector pieces hold
+// it, nil when they agree.
+func reconcileDeal(deal *market.DealInfo, local map[abi.DealID]dealLocation) *types.DealMismatch {
+	mismatch := &types.DealMismatch{
+		DealID:       deal.DealID,
+		MarketStatus: string(deal.Status),
+		MarketSector: deal.SectorID,
+		MarketOffset: deal.Offset,
+	}
+
+	loc, ok := local[deal.DealID]
+	switch {
+	case ok && deal.Status == market.Undefine:
+		mismatch.Kind = types.DealUnreported
+	case ok && (deal.SectorID != loc.sector || deal.Offset != loc.offset):
+		mismatch.Kind = types.DealMisplaced
+	case !ok && (deal.Status == market.Assigned || deal.Status == market.Packing):
+		mismatch.Kind = types.DealMissing
+		return mismatch
+	default:
+		return nil
+	}
+
+	mismatch.SectorNumber = loc.sector
+	mismatch.Offset = loc.offset
+	return mismatch
+}
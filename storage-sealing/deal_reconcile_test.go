@@ -0,0 +1,117 @@
+package sealing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/failstore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-fil-markets/piecestore"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-statemachine"
+	"github.com/filecoin-project/go-statestore"
+	"github.com/filecoin-project/venus/venus-shared/types/market"
+
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestSectorDealOffsets(t *testing.T) {
+	info := types.SectorInfo{
+		Pieces: []types.Piece{
+			{Piece: abi.PieceInfo{Size: 512}, DealInfo: &types.PieceDealInfo{DealID: 1}},
+			{Piece: abi.PieceInfo{Size: 512}}, // filler
+			{Piece: abi.PieceInfo{Size: 1024}, DealInfo: &types.PieceDealInfo{DealID: 2}},
+		},
+	}
+
+	require.Equal(t, map[abi.DealID]abi.PaddedPieceSize{1: 0, 2: 1024}, sectorDealOffsets(info))
+}
+
+func TestReconcileDeal(t *testing.T) {
+	local := map[abi.DealID]dealLocation{
+		1: {sector: 10, offset: 0},
+		2: {sector: 10, offset: 1024},
+		3: {sector: 11, offset: 0},
+	}
+
+	marketDeal := func(id abi.DealID, status market.PieceStatus, sector abi.SectorNumber, offset abi.PaddedPieceSize) *market.DealInfo {
+		return &market.DealInfo{
+			DealInfo: piecestore.DealInfo{DealID: id, SectorID: sector, Offset: offset},
+			Status:   status,
+		}
+	}
+
+	// agree
+	require.Nil(t, reconcileDeal(marketDeal(1, market.Assigned, 10, 0), local))
+	require.Nil(t, reconcileDeal(marketDeal(4, market.Undefine, 0, 0), local))
+	require.Nil(t, reconcileDeal(marketDeal(5, market.Proving, 12, 0), local))
+
+	mismatch := reconcileDeal(marketDeal(2, market.Undefine, 0, 0), local)
+	require.Equal(t, types.DealUnreported, mismatch.Kind)
+	require.Equal(t, abi.SectorNumber(10), mismatch.SectorNumber)
+	require.Equal(t, abi.PaddedPieceSize(1024), mismatch.Offset)
+
+	mismatch = reconcileDeal(marketDeal(3, market.Assigned, 12, 0), local)
+	require.Equal(t, types.DealMisplaced, mismatch.Kind)
+	require.Equal(t, abi.SectorNumber(11), mismatch.SectorNumber)
+	require.Equal(t, abi.SectorNumber(12), mismatch.MarketSector)
+
+	mismatch = reconcileDeal(marketDeal(6, market.Packing, 12, 2048), local)
+	require.Equal(t, types.DealMissing, mismatch.Kind)
+}
+
+func TestSectorHoldsDeal(t *testing.T) {
+	var failOp string
+	ds := failstore.NewFailstore(datastore.NewMapDatastore(), func(op string) error {
+		if op == failOp {
+			return errors.New("database is down")
+		}
+		return nil
+	})
+	store := statestore.NewDsStateStore(ds)
+	m := &Sealing{sectors: statemachine.NewFromStateStore(store, nil, types.SectorInfo{})}
+
+	deal := &types.PieceDealInfo{DealID: 1}
+	pieceCid, _ := cid.Parse("bafkqaaa")
+	piece := abi.PieceInfo{Size: 512, PieceCID: pieceCid}
+	require.NoError(t, store.Begin(uint64(10), &types.SectorInfo{
+		SectorNumber: 10,
+		State:        types.PreCommit1,
+		Pieces:       []types.Piece{{Piece: piece, DealInfo: deal}},
+	}))
+	require.NoError(t, store.Begin(uint64(11), &types.SectorInfo{
+		SectorNumber: 11,
+		State:        types.Removed,
+		Pieces:       []types.Piece{{Piece: piece, DealInfo: deal}},
+	}))
+
+	held, err := m.sectorHoldsDeal(10, 1)
+	require.NoError(t, err)
+	require.True(t, held)
+
+	// the sector was loaded without the piece
+	held, err = m.sectorHoldsDeal(10, 2)
+	require.NoError(t, err)
+	require.False(t, held)
+
+	held, err = m.sectorHoldsDeal(11, 1)
+	require.NoError(t, err)
+	require.False(t, held)
+
+	// the sector definitely doesn't exist
+	held, err = m.sectorHoldsDeal(12, 1)
+	require.NoError(t, err)
+	require.False(t, held)
+
+	// failing to load the sector must not be taken for a missing deal
+	failOp = "has"
+	_, err = m.sectorHoldsDeal(10, 1)
+	require.Error(t, err)
+
+	failOp = "get"
+	_, err = m.sectorHoldsDeal(10, 1)
+	require.Error(t, err)
+}
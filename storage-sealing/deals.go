@@ -3,70 +3,314 @@ package sealing
 import (
 	"context"
 	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-padreader"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/specs-storage/storage"
 	"github.com/filecoin-project/venus/venus-shared/types/market"
 
 	"github.com/filecoin-project/venus-sealer/types"
 )
 
+// DealPlan returns the assignment of the unpacked deals to sectors DealSector
+// would make, without adding any piece.
+func (m *Sealing) DealPlan(ctx context.Context) (*types.DealPackingPlan, error) {
+	plan, _, err := m.planDeals(ctx)
+	return plan, err
+}
+
+func (m *Sealing) planDeals(ctx context.Context) (*types.DealPackingPlan, map[abi.DealID]*market.DealInfoIncludePath, error) {
+	cfg, err := m.getConfig()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("getting config: %w", err)
+	}
+
+	maxPiece := cfg.DealPacking.MaxDeals
+	if maxPiece <= 0 {
+		maxPiece = 50
+	}
+	deals, err := m.api.GetUnPackedDeals(ctx, m.maddr, &market.GetDealSpec{MaxPiece: maxPiece})
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Infof("got %d deals from venus-market", len(deals))
+
+	sp, err := m.currentSealProof(ctx)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("getting current seal proof type: %w", err)
+	}
+	ssize, err := sp.SectorSize()
+	if err != nil {
+		return nil, nil, err
+	}
+	maxDeals, err := getDealPerSectorLimit(ssize)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("getting per-sector deal limit: %w", err)
+	}
+
+	_, head, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("couldn't get chain head: %w", err)
+	}
+
+	assignments, err := m.assignmentService.ListDealAssignments()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("listing deal assignments: %w", err)
+	}
+	pending := make(map[abi.DealID]struct{}, len(assignments))
+	for _, a := range assignments {
+		pending[a.DealID] = struct{}{}
+	}
+
+	byID := make(map[abi.DealID]*market.DealInfoIncludePath, len(deals))
+	unpacked := make([]*market.DealInfoIncludePath, 0, len(deals))
+	for _, deal := range deals {
+		if _, ok := pending[deal.DealID]; ok {
+			// already in a sector, venus-market doesn't know yet
+			continue
+		}
+		byID[deal.DealID] = deal
+		unpacked = append(unpacked, deal)
+	}
+
+	return planDealPacking(unpacked, ssize, maxDeals, head, cfg.StartEpochSealingBuffer, cfg.DealPacking), byID, nil
+}
+
+// DealSector adds the unpacked deals to sectors following the packing plan,
+// see planDealPacking. Every planned sector gets a new sector holding only its
+// deals, so nothing is packed while MakeNewSectorForDeals is disabled.
 func (m *Sealing) DealSector(ctx context.Context) ([]types.DealAssign, error) {
 	if m.pieceStorageMrg == nil {
 		return nil, fmt.Errorf("havn't configured piece storage")
 	}
 	m.startupWait.Wait()
 
-	deals, err := m.api.GetUnPackedDeals(ctx, m.maddr, &market.GetDealSpec{MaxPiece: 50})
+	assigned := m.retryDealAssignments(ctx)
+	packed, _, err := m.dealSector(ctx)
+	return append(assigned, packed...), err
+}
+
+// dealSector packs the deals of the planned sectors, each in a sector created
+// for it. It stops early when the deal sectors limits don't allow more
+// sectors, the deals left are packed by a later run. When the limits don't
+// allow any sector, the error explains why nothing was packed. It also returns
+// the number of pieces which couldn't be read from piece storage.
+func (m *Sealing) dealSector(ctx context.Context) ([]types.DealAssign, int, error) {
+	plan, deals, err := m.planDeals(ctx)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	log.Infof("got %d deals from venus-market", len(deals))
-	//read from file
+	log.Infof("deal packing plan: %d sectors, %d deals deferred", len(plan.Sectors), len(plan.Deferred))
 
-	var assigned []types.DealAssign
-	for _, deal := range deals {
+	sectors := plan.Sectors
+	var (
+		assigned    []types.DealAssign
+		unreachable int
+		created     int
+	)
+	for i, sector := range sectors {
+		pieces, missing := m.openDealPieces(ctx, sector, deals)
+		unreachable += missing
+		if len(pieces) == 0 {
+			continue
+		}
+
+		sn, err := m.createDealBinSector(ctx, len(pieces))
+		if err != nil {
+			closeDealPieces(pieces)
+			if !xerrors.Is(err, errDealSectorLimit) {
+				return assigned, unreachable, xerrors.Errorf("creating sector for planned deals: %w", err)
+			}
+			if created == 0 {
+				return assigned, unreachable, xerrors.Errorf("none of the %d planned sectors packed: %w", len(sectors)-i, err)
+			}
+			log.Infof("%v, %d planned sectors left for the next run", err, len(sectors)-i)
+			break
+		}
+		created++
+
+		for _, piece := range pieces {
+			deal := piece.deal
+			so, err := m.addPieceToSector(ctx, sn, deal.Length.Unpadded(), piece.data, types.PieceDealInfo{
+				PublishCid:   &deal.PublishCid,
+				DealID:       deal.DealID,
+				DealProposal: &deal.DealProposal,
+				DealSchedule: types.DealSchedule{StartEpoch: deal.StartEpoch, EndEpoch: deal.EndEpoch},
+				KeepUnsealed: deal.FastRetrieval,
+			})
+			_ = piece.closer.Close()
+			if err != nil {
+				log.Errorf("add piece to sector %d: %v", sn, err)
+				continue
+			}
+
+			now := time.Now().Unix()
+			assignment := &types.DealAssignment{
+				DealID:       deal.DealID,
+				SectorNumber: so.Sector,
+				Offset:       so.Offset,
+				PieceCID:     deal.PieceCID,
+				Size:         deal.PieceSize,
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			}
+			if err := m.assignmentService.SaveDealAssignment(assignment); err != nil {
+				log.Errorf("recording assignment of deal %d: %v", deal.DealID, err)
+			}
+			if err := m.reportDealAssignment(ctx, assignment); err != nil {
+				log.Errorf("update deal %d on packing, retrying later: %v", deal.DealID, err)
+				continue
+			}
+			assigned = append(assigned, assignment.DealAssign())
+		}
+	}
+	return assigned, unreachable, nil
+}
+
+type dealPiece struct {
+	deal   *market.DealInfoIncludePath
+	data   storage.Data
+	closer io.Closer
+}
+
+// openDealPieces opens the pieces of the planned sector in piece storage. It
+// also returns the number of pieces which couldn't be opened.
+func (m *Sealing) openDealPieces(ctx context.Context, sector *types.PlannedSector, deals map[abi.DealID]*market.DealInfoIncludePath) ([]dealPiece, int) {
+	var (
+		pieces      []dealPiece
+		unreachable int
+	)
+	for _, planned := range sector.Deals {
+		deal := deals[planned.DealID]
 		pieceStorage, err := m.pieceStorageMrg.FindStorageForRead(ctx, deal.PieceCID.String())
 		if err != nil {
 			log.Errorf("failed to found piece storage %v", err)
+			unreachable++
 			continue
 		}
 		r, err := pieceStorage.GetReaderCloser(ctx, deal.PieceCID.String())
 		if err != nil {
 			log.Errorf("read piece from piece storage %v", err)
+			unreachable++
 			continue
 		}
 
 		padR, err := padreader.NewInflator(r, uint64(deal.PayloadSize), deal.PieceSize.Unpadded())
 		if err != nil {
-			return nil, err
+			_ = r.Close()
+			log.Errorf("padding piece of deal %d: %v", deal.DealID, err)
+			unreachable++
+			continue
 		}
+		pieces = append(pieces, dealPiece{deal: deal, data: padR, closer: r})
+	}
+	return pieces, unreachable
+}
+
+func closeDealPieces(pieces []dealPiece) {
+	for _, piece := range pieces {
+		_ = piece.closer.Close()
+	}
+}
+
+// reportDealAssignment tells venus-market the deal is packed in the sector of
+// the assignment, which is dropped on success. A failure is recorded for the
+// next retry.
+func (m *Sealing) reportDealAssignment(ctx context.Context, assignment *types.DealAssignment) error {
+	err := m.api.UpdateDealOnPacking(ctx, m.maddr, assignment.DealID, assignment.SectorNumber, assignment.Offset)
+	if err != nil {
+		assignment.Attempts++
+		assignment.LastError = err.Error()
+		assignment.UpdatedAt = time.Now().Unix()
+		if serr := m.assignmentService.SaveDealAssignment(assignment); serr != nil {
+			log.Errorf("recording assignment of deal %d: %v", assignment.DealID, serr)
+		}
+		return err
+	}
+
+	if err := m.assignmentService.DeleteDealAssignment(assignment.DealID); err != nil {
+		log.Errorf("dropping assignment of deal %d: %v", assignment.DealID, err)
+	}
+	return nil
+}
+
+// retryDealAssignments reports the pending deal assignments to venus-market
+// again and returns the ones which succeeded. An assignment whose sector is
+// missing, removed or doesn't hold the deal anymore is compensated instead:
+// the deal is marked unpacked in venus-market so that it's packed again. If
+// the sector can't be loaded the assignment is left for the next round.
+func (m *Sealing) retryDealAssignments(ctx context.Context) []types.DealAssign {
+	assignments, err := m.assignmentService.ListDealAssignments()
+	if err != nil {
+		log.Errorf("listing deal assignments: %v", err)
+		return nil
+	}
 
-		so, err := m.SectorAddPieceToAny(ctx, deal.Length.Unpadded(), padR, types.PieceDealInfo{
-			PublishCid:   &deal.PublishCid,
-			DealID:       deal.DealID,
-			DealProposal: &deal.DealProposal,
-			DealSchedule: types.DealSchedule{StartEpoch: deal.StartEpoch, EndEpoch: deal.EndEpoch},
-			KeepUnsealed: deal.FastRetrieval,
-		})
-		_ = r.Close()
+	var done []types.DealAssign
+	for _, assignment := range assignments {
+		held, err := m.sectorHoldsDeal(assignment.SectorNumber, assignment.DealID)
 		if err != nil {
-			log.Errorf("add piece to sector %v", err)
+			log.Warnf("loading sector %d of deal %d assignment, retrying later: %v", assignment.SectorNumber, assignment.DealID, err)
 			continue
 		}
 
-		err = m.api.UpdateDealOnPacking(ctx, m.maddr, deal.DealID, so.Sector, so.Offset)
-		if err != nil {
-			log.Errorf("update deal status on chain ", err)
-			//if error how to fix this problems
+		if held {
+			if err := m.reportDealAssignment(ctx, assignment); err != nil {
+				log.Warnf("retrying update of deal %d on packing: %v", assignment.DealID, err)
+				continue
+			}
+			done = append(done, assignment.DealAssign())
+			continue
+		}
+
+		log.Warnf("sector %d doesn't hold deal %d anymore, releasing the deal", assignment.SectorNumber, assignment.DealID)
+		if err := m.api.UpdateDealStatus(ctx, m.maddr, assignment.DealID, market.Undefine); err != nil {
+			log.Warnf("releasing deal %d: %v", assignment.DealID, err)
 			continue
 		}
-		assigned = append(assigned, types.DealAssign{
-			DealId:   deal.DealID,
-			SectorId: so.Sector,
-			PieceCid: deal.PieceCID,
-			Offset:   so.Offset,
-			Size:     deal.PieceSize,
-		})
-	}
-	return assigned, err
+		if err := m.assignmentService.DeleteDealAssignment(assignment.DealID); err != nil {
+			log.Errorf("dropping assignment of deal %d: %v", assignment.DealID, err)
+		}
+	}
+	return done
+}
+
+// sectorHoldsDeal tells whether the sector still carries the deal piece. It
+// only returns false when the sector is known to be gone or was loaded
+// without the piece, any failure to find out is returned as an error.
+func (m *Sealing) sectorHoldsDeal(sid abi.SectorNumber, dealID abi.DealID) (bool, error) {
+	has, err := m.sectors.Has(uint64(sid))
+	if err != nil {
+		return false, xerrors.Errorf("checking sector existence: %w", err)
+	}
+	if !has {
+		return false, nil
+	}
+
+	info, err := m.GetSectorInfo(sid)
+	if err != nil {
+		return false, xerrors.Errorf("getting sector info: %w", err)
+	}
+	if info.State == types.Removed || info.State == types.Removing {
+		return false, nil
+	}
+	_, ok := sectorDealOffsets(info)[dealID]
+	return ok, nil
+}
+
+// sectorDealOffsets returns the offset of the deal pieces in the sector.
+func sectorDealOffsets(info types.SectorInfo) map[abi.DealID]abi.PaddedPieceSize {
+	offsets := map[abi.DealID]abi.PaddedPieceSize{}
+	var offset abi.PaddedPieceSize
+	for _, piece := range info.Pieces {
+		if piece.DealInfo != nil {
+			offsets[piece.DealInfo.DealID] = offset
+		}
+		offset += piece.Piece.Size
+	}
+	return offsets
 }
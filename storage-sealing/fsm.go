@@ -390,10 +390,12 @@ func (m *Sealing) plan(events []statemachine.Event, state *types.SectorInfo) (fu
 		}
 	}
 
+	before := state.State
 	processed, err := p(events, state)
 	if err != nil {
 		return nil, processed, xerrors.Errorf("running planner for state %s failed: %w", state.State, err)
 	}
+	m.observeState(state.SectorNumber, before, state.State)
 
 	/////
 	// Now decide what to do next
@@ -604,6 +606,7 @@ func (m *Sealing) onUpdateSector(ctx context.Context, state *types.SectorInfo) e
 	}
 
 	shouldUpdateInput := m.stats.UpdateSector(cfg, m.minerSectorID(state.SectorNumber), state.State)
+	m.recordSectorStates(ctx)
 
 	// trigger more input processing when we've dipped below max sealing limits
 	if shouldUpdateInput {
@@ -50,6 +50,7 @@ func (m *Sealing) handleWaitDeals(ctx statemachine.Context, sector types.SectorI
 	started, err := m.maybeStartSealing(ctx, sector, used)
 	if err != nil || started {
 		delete(m.openSectors, m.minerSectorID(sector.SectorNumber))
+		delete(m.dealBins, m.minerSectorID(sector.SectorNumber))
 
 		m.inputLk.Unlock()
 
@@ -118,6 +119,12 @@ func (m *Sealing) maybeStartSealing(ctx statemachine.Context, sector types.Secto
 		return true, ctx.Send(SectorStartPacking{})
 	}
 
+	if planned, ok := m.dealBins[m.minerSectorID(sector.SectorNumber)]; ok && len(sector.DealIDs()) >= planned {
+		// got all the deals of its packing plan bin
+		log.Infow("starting to seal deal sector", "sector", sector.SectorNumber, "trigger", "deal-bin")
+		return true, ctx.Send(SectorStartPacking{})
+	}
+
 	if sector.CreationTime != 0 {
 		cfg, err := m.getConfig()
 		if err != nil {
@@ -269,6 +276,17 @@ func (m *Sealing) handleAddPieceFailed(ctx statemachine.Context, sector types.Se
 }
 
 func (m *Sealing) SectorAddPieceToAny(ctx context.Context, size abi.UnpaddedPieceSize, data storage.Data, deal types.PieceDealInfo) (api.SectorOffset, error) {
+	return m.addPiece(ctx, nil, size, data, deal)
+}
+
+// addPieceToSector adds the piece to a sector created by createDealBinSector.
+func (m *Sealing) addPieceToSector(ctx context.Context, sector abi.SectorNumber, size abi.UnpaddedPieceSize, data storage.Data, deal types.PieceDealInfo) (api.SectorOffset, error) {
+	return m.addPiece(ctx, &sector, size, data, deal)
+}
+
+// addPiece adds the piece to the sector when one is given, to any open sector
+// otherwise.
+func (m *Sealing) addPiece(ctx context.Context, sector *abi.SectorNumber, size abi.UnpaddedPieceSize, data storage.Data, deal types.PieceDealInfo) (api.SectorOffset, error) {
 	log.Infof("Adding piece for deal %d (publish msg: %s)", deal.DealID, deal.PublishCid)
 	if (padreader.PaddedSize(uint64(size))) != size {
 		return api.SectorOffset{}, xerrors.Errorf("cannot allocate unpadded piece")
@@ -325,7 +343,7 @@ func (m *Sealing) SectorAddPieceToAny(ctx context.Context, size abi.UnpaddedPiec
 	}
 
 	// addPendingPiece takes over m.inputLk
-	pp := m.addPendingPiece(ctx, size, data, deal, sp)
+	pp := m.addPendingPiece(ctx, sector, size, data, deal, sp)
 
 	res, err := pp.waitAddPieceResp(ctx)
 	if err != nil {
@@ -335,13 +353,14 @@ func (m *Sealing) SectorAddPieceToAny(ctx context.Context, size abi.UnpaddedPiec
 }
 
 // called with m.inputLk; transfers the lock to another goroutine!
-func (m *Sealing) addPendingPiece(ctx context.Context, size abi.UnpaddedPieceSize, data storage.Data, deal types.PieceDealInfo, sp abi.RegisteredSealProof) *pendingPiece {
+func (m *Sealing) addPendingPiece(ctx context.Context, sector *abi.SectorNumber, size abi.UnpaddedPieceSize, data storage.Data, deal types.PieceDealInfo, sp abi.RegisteredSealProof) *pendingPiece {
 	doneCh := make(chan struct{})
 	pp := &pendingPiece{
 		doneCh:   doneCh,
 		size:     size,
 		deal:     deal,
 		data:     data,
+		sector:   sector,
 		assigned: false,
 	}
 	pp.accepted = func(sn abi.SectorNumber, offset abi.UnpaddedPieceSize, err error) {
@@ -417,9 +436,20 @@ func (m *Sealing) updateInput(ctx context.Context, sp abi.RegisteredSealProof) e
 			continue // already assigned to a sector, skip
 		}
 
-		toAssign[proposalCid] = struct{}{}
+		if piece.sector == nil {
+			toAssign[proposalCid] = struct{}{}
+		}
 
 		for id, sector := range m.openSectors {
+			// the sectors of deal bins only take the pieces of their bin
+			if piece.sector != nil {
+				if id.Number != *piece.sector {
+					continue
+				}
+			} else if _, bin := m.dealBins[id]; bin {
+				continue
+			}
+
 			avail := abi.PaddedPieceSize(ssize).Unpadded() - sector.used
 			// check that sector lifetime is long enough to fit deal using latest expiration from on chain
 
@@ -434,7 +464,7 @@ func (m *Sealing) updateInput(ctx context.Context, sp abi.RegisteredSealProof) e
 				continue
 			}
 			//one sector one file, thus a sector can add piece when it is empty.
-			if sector.used == 0 && piece.size <= avail { // (note: if we have enough space for the piece, we also have enough space for inter-piece padding)
+			if (sector.used == 0 || piece.sector != nil) && piece.size <= avail { // (note: if we have enough space for the piece, we also have enough space for inter-piece padding)
 				matches = append(matches, match{
 					sector: id,
 					deal:   proposalCid,
@@ -510,7 +540,7 @@ func (m *Sealing) calcTargetExpiration(ctx context.Context, ssize abi.SectorSize
 	var candidates []*pendingPiece
 
 	for _, piece := range m.pendingPieces {
-		if piece.assigned {
+		if piece.assigned || piece.sector != nil {
 			continue // already assigned to a sector, skip
 		}
 		candidates = append(candidates, piece)
@@ -680,6 +710,47 @@ func (m *Sealing) tryGetDealSector(ctx context.Context, sp abi.RegisteredSealPro
 	return nil
 }
 
+// createDealBinSector creates a sector for the deals of a packing plan bin,
+// which starts sealing once it got them all. The error wraps
+// errDealSectorLimit when the deal sectors limits don't allow a new sector
+// right now.
+func (m *Sealing) createDealBinSector(ctx context.Context, deals int) (abi.SectorNumber, error) {
+	m.startupWait.Wait()
+
+	sp, err := m.currentSealProof(ctx)
+	if err != nil {
+		return 0, xerrors.Errorf("getting current seal proof type: %w", err)
+	}
+
+	cfg, err := m.getConfig()
+	if err != nil {
+		return 0, xerrors.Errorf("getting storage config: %w", err)
+	}
+
+	m.inputLk.Lock()
+	defer m.inputLk.Unlock()
+
+	if err := m.checkDealSectorLimits(cfg); err != nil {
+		return 0, err
+	}
+
+	sid, err := m.createSector(ctx, cfg, sp)
+	if err != nil {
+		return 0, err
+	}
+	m.dealBins[m.minerSectorID(sid)] = deals
+
+	log.Infow("Creating sector", "number", sid, "type", "deal-bin", "deals", deals, "proofType", sp)
+	if err := m.sectors.Send(uint64(sid), SectorStart{
+		ID:         sid,
+		SectorType: sp,
+	}); err != nil {
+		delete(m.dealBins, m.minerSectorID(sid))
+		return 0, err
+	}
+	return sid, nil
+}
+
 // call with m.inputLk
 func (m *Sealing) createSector(ctx context.Context, cfg sealiface.Config, sp abi.RegisteredSealProof) (abi.SectorNumber, error) {
 	// Now actually create a new sector
@@ -0,0 +1,48 @@
+package sealing
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/metrics"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// recordSectorStates exports the number of sectors in every state seen so far
+func (m *Sealing) recordSectorStates(ctx context.Context) {
+	for st, n := range m.stats.StateCounts() {
+		_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(metrics.SectorState, string(st))}, metrics.SectorStates.M(n))
+	}
+}
+
+// observeState records the time spent in a state when a sector leaves it. The
+// time a sector entered its state is only known in memory, so the first state
+// of each sector after a restart is not measured.
+func (m *Sealing) observeState(sn abi.SectorNumber, before, after types.SectorState) {
+	m.stateLk.Lock()
+	defer m.stateLk.Unlock()
+
+	if m.stateSince == nil {
+		m.stateSince = map[abi.SectorNumber]time.Time{} // tests
+	}
+
+	now := time.Now()
+	since, found := m.stateSince[sn]
+	switch {
+	case !found:
+		m.stateSince[sn] = now
+	case before != after:
+		_ = stats.RecordWithTags(context.TODO(), []tag.Mutator{tag.Upsert(metrics.SectorState, string(before))},
+			metrics.SectorStateDuration.M(now.Sub(since).Seconds()))
+		m.stateSince[sn] = now
+	}
+
+	if after == types.Removed {
+		delete(m.stateSince, sn)
+	}
+}
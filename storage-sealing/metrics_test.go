@@ -0,0 +1,37 @@
+package sealing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+
+	"github.com/filecoin-project/venus-sealer/metrics"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+func TestObserveStateDuration(t *testing.T) {
+	require.NoError(t, view.Register(metrics.SectorStateDurationView))
+	defer view.Unregister(metrics.SectorStateDurationView)
+
+	m := &Sealing{}
+
+	// first observation only starts tracking
+	m.observeState(1, types.Packing, types.Packing)
+	rows, err := view.RetrieveData(metrics.SectorStateDurationView.Name)
+	require.NoError(t, err)
+	require.Len(t, rows, 0)
+
+	m.observeState(1, types.Packing, types.Packing)
+	m.observeState(1, types.Packing, types.PreCommit1)
+	m.observeState(1, types.PreCommit1, types.Removed)
+
+	rows, err = view.RetrieveData(metrics.SectorStateDurationView.Name)
+	require.NoError(t, err)
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.Tags[0].Value] = row.Data.(*view.DistributionData).Count
+	}
+	require.Equal(t, map[string]int64{string(types.Packing): 1, string(types.PreCommit1): 1}, counts)
+	require.Empty(t, m.stateSince)
+}
@@ -108,6 +108,21 @@ func (mr *MockSealingAPIMockRecorder) ChainReadObj(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChainReadObj", reflect.TypeOf((*MockSealingAPI)(nil).ChainReadObj), arg0, arg1)
 }
 
+// GetDeals mocks base method.
+func (m *MockSealingAPI) GetDeals(arg0 context.Context, arg1 address.Address, arg2, arg3 int) ([]*market0.DealInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeals", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*market0.DealInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeals indicates an expected call of GetDeals.
+func (mr *MockSealingAPIMockRecorder) GetDeals(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeals", reflect.TypeOf((*MockSealingAPI)(nil).GetDeals), arg0, arg1, arg2, arg3)
+}
+
 // GetUnPackedDeals mocks base method.
 func (m *MockSealingAPI) GetUnPackedDeals(arg0 context.Context, arg1 address.Address, arg2 *market0.GetDealSpec) ([]*market0.DealInfoIncludePath, error) {
 	m.ctrl.T.Helper()
@@ -525,3 +540,17 @@ func (mr *MockSealingAPIMockRecorder) UpdateDealOnPacking(arg0, arg1, arg2, arg3
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDealOnPacking", reflect.TypeOf((*MockSealingAPI)(nil).UpdateDealOnPacking), arg0, arg1, arg2, arg3, arg4)
 }
+
+// UpdateDealStatus mocks base method.
+func (m *MockSealingAPI) UpdateDealStatus(arg0 context.Context, arg1 address.Address, arg2 abi.DealID, arg3 market0.PieceStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDealStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDealStatus indicates an expected call of UpdateDealStatus.
+func (mr *MockSealingAPIMockRecorder) UpdateDealStatus(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDealStatus", reflect.TypeOf((*MockSealingAPI)(nil).UpdateDealStatus), arg0, arg1, arg2, arg3)
+}
@@ -0,0 +1,255 @@
+package sealing
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-commp-utils/zerocomm"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/specs-actors/v8/actors/builtin"
+	"github.com/filecoin-project/specs-storage/storage"
+	types2 "github.com/filecoin-project/venus/venus-shared/types"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// recoverInput holds what is needed to seal a sector again
+type recoverInput struct {
+	sector storage.SectorRef
+	ticket abi.SealRandomness
+	piece  abi.PieceInfo
+	commR  cid.Cid
+}
+
+// RecoverSectors rebuilds the sealed replica and cache of committed CC sectors,
+// e.g. after the loss of a storage path. The seal inputs are taken from the
+// local sector info when there is one, otherwise from the on-chain sector info
+// and the precommit message of the sector. The sectors are sealed on the
+// workers, the resulting CommR is checked against the chain and the files are
+// finalized into long-term storage, which declares them in the index again.
+//
+// The returned statuses tell which sectors were scheduled, the progress of each
+// one can then be followed with RedoStatus.
+func (m *Sealing) RecoverSectors(ctx context.Context, params storiface.SectorRecoverParams) ([]storiface.SectorRedoStatus, error) {
+	if len(params.Sectors) == 0 {
+		return nil, xerrors.Errorf("no sectors to recover")
+	}
+
+	tok, _, err := m.api.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	out := make([]storiface.SectorRedoStatus, 0, len(params.Sectors))
+	inputs := make([]*recoverInput, 0, len(params.Sectors))
+	for _, sn := range params.Sectors {
+		in, err := m.recoverInput(ctx, sn, params.PreCommitMessages, tok)
+		if err == nil {
+			err = m.startRedo(sn, "recover requested")
+		}
+		if err != nil {
+			log.Warnf("recover sector %d: %s", sn, err)
+			out = append(out, storiface.SectorRedoStatus{SectorNumber: sn, State: storiface.RedoFailed, Error: err.Error()})
+			continue
+		}
+
+		st, _ := m.RedoStatus(ctx, sn)
+		out = append(out, st)
+		inputs = append(inputs, in)
+	}
+
+	go m.recoverSectors(inputs, params.Parallel)
+
+	return out, nil
+}
+
+func (m *Sealing) recoverSectors(inputs []*recoverInput, parallel int) {
+	var throttle chan struct{}
+	if parallel > 0 {
+		throttle = make(chan struct{}, parallel)
+	}
+
+	var wg sync.WaitGroup
+	for _, in := range inputs {
+		if throttle != nil {
+			select {
+			case throttle <- struct{}{}:
+			case <-m.redoCtx.Done():
+				return
+			}
+		}
+
+		wg.Add(1)
+		go func(in *recoverInput) {
+			defer wg.Done()
+			if throttle != nil {
+				defer func() { <-throttle }()
+			}
+
+			sn := in.sector.ID.Number
+			if err := m.recoverSector(m.redoCtx, in); err != nil {
+				log.Errorf("recover sector %d: %+v", sn, err)
+				m.setRedoState(sn, storiface.RedoFailed, err)
+				return
+			}
+			log.Infof("recover sector %d done", sn)
+			m.setRedoState(sn, storiface.RedoDone, nil)
+		}(in)
+	}
+	wg.Wait()
+}
+
+func (m *Sealing) recoverSector(ctx context.Context, in *recoverInput) error {
+	sn := in.sector.ID.Number
+
+	m.setRedoState(sn, storiface.RedoAddPiece, nil)
+	ppi, err := m.sealer.AddPiece(ctx, in.sector, nil, in.piece.Size.Unpadded(), NewNullReader(in.piece.Size.Unpadded()))
+	if err != nil {
+		return xerrors.Errorf("writing padding piece: %w", err)
+	}
+	if !ppi.PieceCID.Equals(in.piece.PieceCID) {
+		return xerrors.Errorf("got unexpected padding piece CID: expected:%s, got:%s", in.piece.PieceCID, ppi.PieceCID)
+	}
+
+	m.setRedoState(sn, storiface.RedoPreCommit1, nil)
+	pc1o, err := m.sealer.SealPreCommit1(ctx, in.sector, in.ticket, []abi.PieceInfo{in.piece})
+	if err != nil {
+		return xerrors.Errorf("seal pre commit(1): %w", err)
+	}
+
+	m.setRedoState(sn, storiface.RedoPreCommit2, nil)
+	cids, err := m.sealer.SealPreCommit2(ctx, in.sector, pc1o)
+	if err != nil {
+		return xerrors.Errorf("seal pre commit(2): %w", err)
+	}
+	// a single piece filling the sector has the CommD of the sector
+	if !cids.Unsealed.Equals(in.piece.PieceCID) {
+		return xerrors.Errorf("unsealed CID mismatch, expected %s, got %s", in.piece.PieceCID, cids.Unsealed)
+	}
+	if !cids.Sealed.Equals(in.commR) {
+		return xerrors.Errorf("sealed CID mismatch with the chain, expected %s, got %s", in.commR, cids.Sealed)
+	}
+
+	m.setRedoState(sn, storiface.RedoFinalize, nil)
+	if err := m.sealer.FinalizeSector(ctx, in.sector, nil); err != nil {
+		return xerrors.Errorf("finalize sector: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Sealing) recoverInput(ctx context.Context, sn abi.SectorNumber, msgs map[abi.SectorNumber]cid.Cid, tok types.TipSetToken) (*recoverInput, error) {
+	onChain, err := m.api.StateSectorGetInfo(ctx, m.maddr, sn, tok)
+	if err != nil {
+		return nil, xerrors.Errorf("getting on-chain sector info: %w", err)
+	}
+	if onChain == nil {
+		return nil, xerrors.Errorf("sector %d not found on chain", sn)
+	}
+	if len(onChain.DealIDs) > 0 {
+		return nil, xerrors.Errorf("sector %d has deals, only CC sectors can be recovered", sn)
+	}
+
+	ssize, err := onChain.SealProof.SectorSize()
+	if err != nil {
+		return nil, err
+	}
+	size := abi.PaddedPieceSize(ssize).Unpadded()
+
+	in := &recoverInput{
+		sector: m.minerSector(onChain.SealProof, sn),
+		piece: abi.PieceInfo{
+			Size:     size.Padded(),
+			PieceCID: zerocomm.ZeroPieceCommitment(size),
+		},
+		commR: onChain.SealedCID,
+	}
+
+	if si, err := m.GetSectorInfo(sn); err == nil && len(si.TicketValue) > 0 {
+		if si.CommR != nil && !si.CommR.Equals(onChain.SealedCID) {
+			return nil, xerrors.Errorf("local CommR %s doesn't match the chain %s", si.CommR, onChain.SealedCID)
+		}
+		in.ticket = si.TicketValue
+		return in, nil
+	}
+
+	msgCid, ok := msgs[sn]
+	if !ok {
+		return nil, xerrors.Errorf("no local info for sector %d, its precommit message is needed", sn)
+	}
+
+	pci, err := findPreCommit(ctx, m.api, m.maddr, msgCid, sn)
+	if err != nil {
+		return nil, err
+	}
+	if !pci.SealedCID.Equals(onChain.SealedCID) {
+		return nil, xerrors.Errorf("precommit message %s has CommR %s, the chain has %s", msgCid, pci.SealedCID, onChain.SealedCID)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := m.maddr.MarshalCBOR(buf); err != nil {
+		return nil, err
+	}
+	rand, err := m.api.StateGetRandomnessFromTickets(ctx, crypto.DomainSeparationTag_SealRandomness, pci.SealRandEpoch, buf.Bytes(), tok)
+	if err != nil {
+		return nil, xerrors.Errorf("getting seal ticket: %w", err)
+	}
+	in.ticket = abi.SealRandomness(rand)
+
+	return in, nil
+}
+
+// findPreCommit returns the precommit info of the sector in a PreCommitSector
+// or PreCommitSectorBatch message
+func findPreCommit(ctx context.Context, api interface {
+	ChainGetMessage(ctx context.Context, mc cid.Cid) (*types2.Message, error)
+	StateLookupID(context.Context, address.Address, types.TipSetToken) (address.Address, error)
+}, maddr address.Address, msgCid cid.Cid, sn abi.SectorNumber) (*miner.SectorPreCommitInfo, error) {
+	msg, err := api.ChainGetMessage(ctx, msgCid)
+	if err != nil {
+		return nil, xerrors.Errorf("getting precommit message %s: %w", msgCid, err)
+	}
+	if msg.To != maddr {
+		maddrID, err := api.StateLookupID(ctx, maddr, nil)
+		if err != nil {
+			return nil, xerrors.Errorf("looking up miner id: %w", err)
+		}
+		toID, err := api.StateLookupID(ctx, msg.To, nil)
+		if err != nil || toID != maddrID {
+			return nil, xerrors.Errorf("message %s isn't sent to miner %s", msgCid, maddr)
+		}
+	}
+
+	var infos []miner.SectorPreCommitInfo
+	switch msg.Method {
+	case builtin.MethodsMiner.PreCommitSector:
+		var params miner.SectorPreCommitInfo
+		if err := params.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+			return nil, xerrors.Errorf("decoding precommit params: %w", err)
+		}
+		infos = append(infos, params)
+	case builtin.MethodsMiner.PreCommitSectorBatch:
+		var params miner.PreCommitSectorBatchParams
+		if err := params.UnmarshalCBOR(bytes.NewReader(msg.Params)); err != nil {
+			return nil, xerrors.Errorf("decoding precommit batch params: %w", err)
+		}
+		infos = params.Sectors
+	default:
+		return nil, xerrors.Errorf("message %s isn't a precommit, method: %d", msgCid, msg.Method)
+	}
+
+	for i := range infos {
+		if infos[i].SectorNumber == sn {
+			return &infos[i], nil
+		}
+	}
+	return nil, xerrors.Errorf("sector %d isn't precommitted by message %s", sn, msgCid)
+}
@@ -0,0 +1,79 @@
+package sealing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	tutils "github.com/filecoin-project/specs-actors/v2/support/testing"
+	"github.com/filecoin-project/specs-actors/v8/actors/builtin"
+
+	"github.com/filecoin-project/venus/venus-shared/types"
+
+	types2 "github.com/filecoin-project/venus-sealer/types"
+)
+
+type fakeMsgAPI map[cid.Cid]*types.Message
+
+func (f fakeMsgAPI) ChainGetMessage(ctx context.Context, mc cid.Cid) (*types.Message, error) {
+	msg, ok := f[mc]
+	if !ok {
+		return nil, errNotFound
+	}
+	return msg, nil
+}
+
+func (f fakeMsgAPI) StateLookupID(ctx context.Context, addr address.Address, tok types2.TipSetToken) (address.Address, error) {
+	return addr, nil
+}
+
+func TestFindPreCommit(t *testing.T) {
+	ctx := context.Background()
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	pci := func(sn abi.SectorNumber, epoch abi.ChainEpoch) miner.SectorPreCommitInfo {
+		return miner.SectorPreCommitInfo{
+			SealProof:     abi.RegisteredSealProof_StackedDrg32GiBV1_1,
+			SectorNumber:  sn,
+			SealedCID:     tutils.MakeCID("sealed", nil),
+			SealRandEpoch: epoch,
+		}
+	}
+
+	single := pci(10, 100)
+	buf := new(bytes.Buffer)
+	require.NoError(t, single.MarshalCBOR(buf))
+	singleMsg := &types.Message{To: maddr, Method: builtin.MethodsMiner.PreCommitSector, Params: buf.Bytes()}
+
+	batch := miner.PreCommitSectorBatchParams{Sectors: []miner.SectorPreCommitInfo{pci(11, 200), pci(12, 300)}}
+	buf = new(bytes.Buffer)
+	require.NoError(t, batch.MarshalCBOR(buf))
+	batchMsg := &types.Message{To: maddr, Method: builtin.MethodsMiner.PreCommitSectorBatch, Params: buf.Bytes()}
+
+	singleCid := tutils.MakeCID("single", nil)
+	batchCid := tutils.MakeCID("batch", nil)
+	api := fakeMsgAPI{singleCid: singleMsg, batchCid: batchMsg}
+
+	info, err := findPreCommit(ctx, api, maddr, singleCid, 10)
+	require.NoError(t, err)
+	require.Equal(t, abi.ChainEpoch(100), info.SealRandEpoch)
+
+	info, err = findPreCommit(ctx, api, maddr, batchCid, 12)
+	require.NoError(t, err)
+	require.Equal(t, abi.ChainEpoch(300), info.SealRandEpoch)
+
+	_, err = findPreCommit(ctx, api, maddr, singleCid, 11)
+	require.Error(t, err)
+
+	other, err := address.NewIDAddress(1001)
+	require.NoError(t, err)
+	_, err = findPreCommit(ctx, api, other, singleCid, 10)
+	require.Error(t, err)
+}
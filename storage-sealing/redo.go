@@ -0,0 +1,185 @@
+package sealing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+// redoLogKind prefixes the kind of the sector log entries written by a redo
+const redoLogKind = "redo;"
+
+// RedoSector rebuilds the sealed replica and cache of a sector which already
+// went through PreCommit2. PC1, PC2 and finalize are scheduled on the workers
+// like for any other sector, the progress is recorded in the sector log and
+// can be queried with RedoStatus.
+func (m *Sealing) RedoSector(ctx context.Context, rsi storiface.SectorRedoParams) error {
+	var si types.SectorInfo
+	if err := m.sectors.Get(uint64(rsi.SectorNumber)).Get(&si); err != nil {
+		return xerrors.Errorf("getting sector info: %w", err)
+	}
+	if si.CommR == nil {
+		return xerrors.Errorf("sector %d has no sealed CID yet, nothing to redo", rsi.SectorNumber)
+	}
+
+	if err := m.startRedo(si.SectorNumber, "redo requested"); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := m.redoSector(m.redoCtx, si); err != nil {
+			log.Errorf("redo sector %d: %+v", si.SectorNumber, err)
+			m.setRedoState(si.SectorNumber, storiface.RedoFailed, err)
+			return
+		}
+		log.Infof("redo sector %d done", si.SectorNumber)
+		m.setRedoState(si.SectorNumber, storiface.RedoDone, nil)
+	}()
+
+	return nil
+}
+
+func (m *Sealing) redoSector(ctx context.Context, si types.SectorInfo) error {
+	if err := checkPieces(ctx, m.maddr, si, m.api, false); err != nil { // Sanity check state
+		switch err.(type) {
+		case *ErrApi, *ErrInvalidDeals:
+			return xerrors.Errorf("checking pieces: %w", err)
+		default:
+			// the deals may have expired since the sector was sealed, the replica is still needed
+			log.Warnf("redo sector %d: checkPieces: %s", si.SectorNumber, err)
+		}
+	}
+
+	cfg, err := m.getConfig()
+	if err != nil {
+		return xerrors.Errorf("getting sealing config: %w", err)
+	}
+
+	sector := m.minerSector(si.SectorType, si.SectorNumber)
+
+	m.setRedoState(si.SectorNumber, storiface.RedoPreCommit1, nil)
+	pc1o, err := m.sealer.SealPreCommit1(ctx, sector, si.TicketValue, si.PieceInfos())
+	if err != nil {
+		return xerrors.Errorf("seal pre commit(1): %w", err)
+	}
+
+	m.setRedoState(si.SectorNumber, storiface.RedoPreCommit2, nil)
+	cids, err := m.sealer.SealPreCommit2(ctx, sector, pc1o)
+	if err != nil {
+		return xerrors.Errorf("seal pre commit(2): %w", err)
+	}
+	if !cids.Sealed.Equals(*si.CommR) {
+		return xerrors.Errorf("sealed CID mismatch, expected %s, got %s", *si.CommR, cids.Sealed)
+	}
+	if si.CommD != nil && !cids.Unsealed.Equals(*si.CommD) {
+		return xerrors.Errorf("unsealed CID mismatch, expected %s, got %s", *si.CommD, cids.Unsealed)
+	}
+
+	m.setRedoState(si.SectorNumber, storiface.RedoFinalize, nil)
+	if err := m.sealer.FinalizeSector(ctx, sector, si.KeepUnsealedRanges(si.Pieces, false, cfg.AlwaysKeepUnsealedCopy)); err != nil {
+		return xerrors.Errorf("finalize sector: %w", err)
+	}
+
+	return nil
+}
+
+// startRedo marks the sector as scheduled for a redo, it fails if a redo of
+// the sector is already running.
+func (m *Sealing) startRedo(sn abi.SectorNumber, msg string) error {
+	now := time.Now().Unix()
+	m.redoLk.Lock()
+	if st, ok := m.redo[sn]; ok && !st.State.Finished() {
+		m.redoLk.Unlock()
+		return xerrors.Errorf("redo of sector %d already running, state: %s", sn, st.State)
+	}
+	m.redo[sn] = &storiface.SectorRedoStatus{
+		SectorNumber: sn,
+		State:        storiface.RedoScheduled,
+		StartTime:    now,
+		UpdateTime:   now,
+	}
+	m.redoLk.Unlock()
+
+	m.logRedo(sn, storiface.RedoScheduled, msg)
+	return nil
+}
+
+func (m *Sealing) setRedoState(sn abi.SectorNumber, state storiface.RedoState, err error) {
+	m.redoLk.Lock()
+	st, ok := m.redo[sn]
+	if ok {
+		st.State = state
+		st.UpdateTime = time.Now().Unix()
+		if err != nil {
+			st.Error = err.Error()
+		}
+	}
+	m.redoLk.Unlock()
+
+	msg := fmt.Sprintf("redo %s", strings.ToLower(string(state)))
+	if err != nil {
+		msg = fmt.Sprintf("redo failed: %s", err)
+	}
+	m.logRedo(sn, state, msg)
+}
+
+func (m *Sealing) logRedo(sn abi.SectorNumber, state storiface.RedoState, msg string) {
+	if err := m.logService.Append(&types.Log{
+		SectorNumber: sn,
+		Timestamp:    uint64(time.Now().Unix()),
+		Message:      msg,
+		Kind:         redoLogKind + string(state),
+	}); err != nil {
+		log.Errorf("appending redo log of sector %d: %s", sn, err)
+	}
+}
+
+// RedoStatus returns the progress of the last redo of the sector. After a
+// restart of the sealer the status is read back from the sector log.
+func (m *Sealing) RedoStatus(ctx context.Context, sn abi.SectorNumber) (storiface.SectorRedoStatus, error) {
+	m.redoLk.Lock()
+	st, ok := m.redo[sn]
+	if ok {
+		out := *st
+		m.redoLk.Unlock()
+		return out, nil
+	}
+	m.redoLk.Unlock()
+
+	logs, err := m.logService.List(sn)
+	if err != nil {
+		return storiface.SectorRedoStatus{}, xerrors.Errorf("listing sector logs: %w", err)
+	}
+
+	out := storiface.SectorRedoStatus{SectorNumber: sn}
+	for _, l := range logs {
+		if !strings.HasPrefix(l.Kind, redoLogKind) {
+			continue
+		}
+		state := storiface.RedoState(strings.TrimPrefix(l.Kind, redoLogKind))
+		if state == storiface.RedoScheduled {
+			out = storiface.SectorRedoStatus{SectorNumber: sn, StartTime: int64(l.Timestamp)}
+		}
+		out.State = state
+		out.UpdateTime = int64(l.Timestamp)
+		if state == storiface.RedoFailed {
+			out.Error = strings.TrimPrefix(l.Message, "redo failed: ")
+		}
+	}
+	if out.State == "" {
+		return storiface.SectorRedoStatus{}, xerrors.Errorf("no redo of sector %d found", sn)
+	}
+	if !out.State.Finished() {
+		// the sealer restarted while the redo was running
+		out.State = storiface.RedoAborted
+	}
+	return out, nil
+}
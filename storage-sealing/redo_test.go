@@ -0,0 +1,252 @@
+package sealing
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-statemachine"
+	"github.com/filecoin-project/go-statestore"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	"github.com/filecoin-project/venus-sealer/service"
+	"github.com/filecoin-project/venus-sealer/storage-sealing/sealiface"
+	"github.com/filecoin-project/venus-sealer/types"
+)
+
+type redoTestAPI struct {
+	SealingAPI
+}
+
+func (redoTestAPI) ChainHead(ctx context.Context) (types.TipSetToken, abi.ChainEpoch, error) {
+	return nil, 100, nil
+}
+
+type redoTestLogs struct {
+	lk   sync.Mutex
+	logs map[abi.SectorNumber][]*types.Log
+}
+
+func (l *redoTestLogs) Count(sectorNumber abi.SectorNumber) (int64, error) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	return int64(len(l.logs[sectorNumber])), nil
+}
+
+func (l *redoTestLogs) TruncateAppend(log *types.Log) error {
+	return l.Append(log)
+}
+
+func (l *redoTestLogs) Truncate(sectorNumber abi.SectorNumber) error {
+	return nil
+}
+
+func (l *redoTestLogs) Append(log *types.Log) error {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	l.logs[log.SectorNumber] = append(l.logs[log.SectorNumber], log)
+	return nil
+}
+
+func (l *redoTestLogs) List(sectorNumber abi.SectorNumber) ([]*types.Log, error) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	return append([]*types.Log(nil), l.logs[sectorNumber]...), nil
+}
+
+func (l *redoTestLogs) DelLogs(sectorNumber uint64) error {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	delete(l.logs, abi.SectorNumber(sectorNumber))
+	return nil
+}
+
+func (l *redoTestLogs) LatestLog(sectorNumber uint64) (*types.Log, error) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	logs := l.logs[abi.SectorNumber(sectorNumber)]
+	if len(logs) == 0 {
+		return nil, nil
+	}
+	return logs[len(logs)-1], nil
+}
+
+// redoTestSealer records the sealing calls of a redo, PC1 blocks until
+// release is closed.
+type redoTestSealer struct {
+	sectorstorage.SectorManager
+
+	release chan struct{}
+	sealed  cid.Cid
+
+	lk    sync.Mutex
+	calls []string
+}
+
+func (s *redoTestSealer) call(name string) {
+	s.lk.Lock()
+	s.calls = append(s.calls, name)
+	s.lk.Unlock()
+}
+
+func (s *redoTestSealer) SealPreCommit1(ctx context.Context, sector storage.SectorRef, ticket abi.SealRandomness, pieces []abi.PieceInfo) (storage.PreCommit1Out, error) {
+	s.call("PC1")
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return storage.PreCommit1Out("pc1"), nil
+}
+
+func (s *redoTestSealer) SealPreCommit2(ctx context.Context, sector storage.SectorRef, pc1o storage.PreCommit1Out) (storage.SectorCids, error) {
+	s.call("PC2")
+	return storage.SectorCids{Sealed: s.sealed}, nil
+}
+
+func (s *redoTestSealer) FinalizeSector(ctx context.Context, sector storage.SectorRef, keepUnsealed []storage.Range) error {
+	s.call("Finalize")
+	return nil
+}
+
+func (s *redoTestSealer) Calls() []string {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func redoTestCid(t *testing.T, b byte) cid.Cid {
+	c, err := commcid.ReplicaCommitmentV1ToCID(append([]byte{b}, make([]byte, 31)...))
+	require.NoError(t, err)
+	return c
+}
+
+func newRedoTestSealing(t *testing.T, ctx context.Context, store statestore.StateStore, logs *redoTestLogs, sealer *redoTestSealer) *Sealing {
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	return &Sealing{
+		api:        redoTestAPI{},
+		maddr:      maddr,
+		sealer:     sealer,
+		sectors:    statemachine.NewFromStateStore(store, nil, types.SectorInfo{}),
+		logService: &service.LogService{LogRepo: logs},
+		getConfig: func() (sealiface.Config, error) {
+			return sealiface.Config{}, nil
+		},
+		redoCtx: ctx,
+		redo:    map[abi.SectorNumber]*storiface.SectorRedoStatus{},
+	}
+}
+
+func waitRedoState(t *testing.T, m *Sealing, sn abi.SectorNumber, state storiface.RedoState) storiface.SectorRedoStatus {
+	var st storiface.SectorRedoStatus
+	require.Eventually(t, func() bool {
+		var err error
+		st, err = m.RedoStatus(context.Background(), sn)
+		require.NoError(t, err)
+		return st.State == state
+	}, 5*time.Second, 10*time.Millisecond, "waiting for redo state %s", state)
+	return st
+}
+
+func TestRedoSector(t *testing.T) {
+	commR := redoTestCid(t, 1)
+
+	store := statestore.NewDsStateStore(datastore.NewMapDatastore())
+	require.NoError(t, store.Begin(uint64(10), &types.SectorInfo{
+		SectorNumber: 10,
+		SectorType:   abi.RegisteredSealProof_StackedDrg2KiBV1,
+		CommR:        &commR,
+	}))
+	require.NoError(t, store.Begin(uint64(11), &types.SectorInfo{
+		SectorNumber: 11,
+		SectorType:   abi.RegisteredSealProof_StackedDrg2KiBV1,
+	}))
+
+	t.Run("done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sealer := &redoTestSealer{release: make(chan struct{}), sealed: commR}
+		m := newRedoTestSealing(t, ctx, store, &redoTestLogs{logs: map[abi.SectorNumber][]*types.Log{}}, sealer)
+
+		require.NoError(t, m.RedoSector(ctx, storiface.SectorRedoParams{SectorNumber: 10}))
+		waitRedoState(t, m, 10, storiface.RedoPreCommit1)
+
+		// only one redo of a sector at a time
+		err := m.RedoSector(ctx, storiface.SectorRedoParams{SectorNumber: 10})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already running")
+
+		close(sealer.release)
+		st := waitRedoState(t, m, 10, storiface.RedoDone)
+		require.Empty(t, st.Error)
+		require.Equal(t, []string{"PC1", "PC2", "Finalize"}, sealer.Calls())
+
+		// a finished redo can be started again
+		require.NoError(t, m.RedoSector(ctx, storiface.SectorRedoParams{SectorNumber: 10}))
+		waitRedoState(t, m, 10, storiface.RedoDone)
+	})
+
+	t.Run("commr-mismatch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sealer := &redoTestSealer{release: make(chan struct{}), sealed: redoTestCid(t, 2)}
+		close(sealer.release)
+		m := newRedoTestSealing(t, ctx, store, &redoTestLogs{logs: map[abi.SectorNumber][]*types.Log{}}, sealer)
+
+		require.NoError(t, m.RedoSector(ctx, storiface.SectorRedoParams{SectorNumber: 10}))
+		st := waitRedoState(t, m, 10, storiface.RedoFailed)
+		require.Contains(t, st.Error, "sealed CID mismatch")
+		// the sector isn't finalized with a wrong replica
+		require.Equal(t, []string{"PC1", "PC2"}, sealer.Calls())
+	})
+
+	t.Run("not-sealed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sealer := &redoTestSealer{release: make(chan struct{}), sealed: commR}
+		m := newRedoTestSealing(t, ctx, store, &redoTestLogs{logs: map[abi.SectorNumber][]*types.Log{}}, sealer)
+
+		require.Error(t, m.RedoSector(ctx, storiface.SectorRedoParams{SectorNumber: 11}))
+		require.Empty(t, sealer.Calls())
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		logs := &redoTestLogs{logs: map[abi.SectorNumber][]*types.Log{}}
+		sealer := &redoTestSealer{release: make(chan struct{}), sealed: commR}
+		m := newRedoTestSealing(t, ctx, store, logs, sealer)
+
+		require.NoError(t, m.RedoSector(ctx, storiface.SectorRedoParams{SectorNumber: 10}))
+		waitRedoState(t, m, 10, storiface.RedoPreCommit1)
+
+		// a restarted sealer only has the sector log left
+		restarted := newRedoTestSealing(t, ctx, store, logs, &redoTestSealer{release: make(chan struct{}), sealed: commR})
+		st, err := restarted.RedoStatus(ctx, 10)
+		require.NoError(t, err)
+		require.Equal(t, storiface.RedoAborted, st.State)
+		require.NotZero(t, st.StartTime)
+
+		_, err = restarted.RedoStatus(ctx, 11)
+		require.Error(t, err)
+
+		// the aborted redo doesn't block a new one
+		require.NoError(t, restarted.RedoSector(ctx, storiface.SectorRedoParams{SectorNumber: 10}))
+	})
+}
@@ -62,4 +62,19 @@ type Config struct {
 	TerminateBatchMax  uint64
 	TerminateBatchMin  uint64
 	TerminateBatchWait time.Duration
+
+	DealPacking DealPackingConfig
+}
+
+// DealPackingConfig is the policy assigning the unpacked deals to sectors
+type DealPackingConfig struct {
+	MaxDeals          int
+	GroupByClient     bool
+	SeparateVerified  bool
+	MaxEndEpochSpread abi.ChainEpoch
+	MinUtilisation    float64
+	UrgentStartEpochs abi.ChainEpoch
+
+	PollInterval   time.Duration
+	PollMaxBackoff time.Duration
 }
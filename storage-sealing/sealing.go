@@ -31,6 +31,7 @@ import (
 	"github.com/filecoin-project/venus-sealer/config"
 	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 	"github.com/filecoin-project/venus-sealer/service"
 	"github.com/filecoin-project/venus-sealer/storage-sealing/sealiface"
 	types2 "github.com/filecoin-project/venus-sealer/types"
@@ -84,6 +85,8 @@ type SealingAPI interface {
 	GetUnPackedDeals(ctx context.Context, miner address.Address, spec *market2.GetDealSpec) ([]*market2.DealInfoIncludePath, error)                 //perm:read
 	MarkDealsAsPacking(ctx context.Context, miner address.Address, deals []abi.DealID) error                                                        //perm:write
 	UpdateDealOnPacking(ctx context.Context, miner address.Address, dealId abi.DealID, sectorid abi.SectorNumber, offset abi.PaddedPieceSize) error //perm:write
+	UpdateDealStatus(ctx context.Context, miner address.Address, dealId abi.DealID, status market2.PieceStatus) error                               //perm:write
+	GetDeals(ctx context.Context, miner address.Address, pageIndex, pageSize int) ([]*market2.DealInfo, error)                                      //perm:read
 }
 
 type SectorStateNotifee func(before, after types2.SectorInfo)
@@ -116,20 +119,31 @@ type Sealing struct {
 
 	available map[abi.SectorID]struct{}
 
+	dealBins map[abi.SectorID]int // sectors created for a deal packing plan bin, to the number of deals of the bin
+
 	networkParams *config.NetParamsConfig
 	notifee       SectorStateNotifee
 	addrSel       AddrSel
 
 	stats types2.SectorStats
 
+	stateLk    sync.Mutex
+	stateSince map[abi.SectorNumber]time.Time // when a sector entered its current state, for metrics
+
+	redoCtx context.Context
+	redoLk  sync.Mutex
+	redo    map[abi.SectorNumber]*storiface.SectorRedoStatus
+
 	terminator  *TerminateBatcher
 	precommiter *PreCommitBatcher
 	commiter    *CommitBatcher
 
 	getConfig       types2.GetSealingConfigFunc
 	pieceStorageMrg *piecestorage.PieceStorageManager
+
 	//service
-	logService *service.LogService
+	logService        *service.LogService
+	assignmentService *service.DealAssignmentService // deals added to a sector, not reported to venus-market yet
 }
 
 type openSector struct {
@@ -166,7 +180,8 @@ type pendingPiece struct {
 
 	data storage.Data
 
-	assigned bool // assigned to a sector?
+	sector   *abi.SectorNumber // the only sector the piece may go to, any open sector when nil
+	assigned bool              // assigned to a sector?
 	accepted func(abi.SectorNumber, abi.UnpaddedPieceSize, error)
 }
 
@@ -188,6 +203,7 @@ func New(mctx context.Context,
 	metaDataService *service.MetadataService,
 	sectorInfoService *service.SectorInfoService,
 	logService *service.LogService,
+	assignmentService *service.DealAssignmentService,
 	sealer sectorstorage.SectorManager,
 	sc types2.SectorIDCounter,
 	verif ffiwrapper.Verifier,
@@ -213,10 +229,13 @@ func New(mctx context.Context,
 		pcp:             pcp,
 		logService:      logService,
 
+		assignmentService: assignmentService,
+
 		openSectors:    map[abi.SectorID]*openSector{},
 		sectorTimers:   map[abi.SectorID]*time.Timer{},
 		pendingPieces:  map[cid.Cid]*pendingPiece{},
 		assignedPieces: map[abi.SectorID][]cid.Cid{},
+		dealBins:       map[abi.SectorID]int{},
 
 		available: map[abi.SectorID]struct{}{},
 
@@ -233,6 +252,10 @@ func New(mctx context.Context,
 			BySector: map[abi.SectorID]types2.SectorState{},
 			ByState:  map[types2.SectorState]int64{},
 		},
+		stateSince: map[abi.SectorNumber]time.Time{},
+
+		redoCtx: mctx,
+		redo:    map[abi.SectorNumber]*storiface.SectorRedoStatus{},
 	}
 	s.startupWait.Add(1)
 
@@ -247,6 +270,8 @@ func (m *Sealing) Run(ctx context.Context) error {
 		return xerrors.Errorf("failed load sector states: %w", err)
 	}
 
+	go m.runDealPolling(ctx)
+
 	return nil
 }
 
@@ -290,6 +290,9 @@ func (m *Sealing) handlePreCommit1(ctx statemachine.Context, sector types.Sector
 		return nil
 	}
 
+	// the precommit has to land before the ticket expires, or the sector be
+	// proven before the precommit expires
+	deadline := sector.TicketEpoch + types.MaxTicketAge
 	if checkTicketExpired(sector.TicketEpoch, height) {
 		pci, err := m.api.StateSectorPreCommitInfo(ctx.Context(), m.maddr, sector.SectorNumber, tok)
 		if err != nil {
@@ -321,9 +324,11 @@ func (m *Sealing) handlePreCommit1(ctx statemachine.Context, sector types.Sector
 		if checkProveCommitExpired(pci.PreCommitEpoch, msd, height) {
 			return ctx.Send(SectorOldTicket{}) // will be removed
 		}
+		deadline = pci.PreCommitEpoch + msd
 	}
 
-	pc1o, err := m.sealer.SealPreCommit1(sector.SealingCtx(ctx.Context()), m.minerSector(sector.SectorType, sector.SectorNumber), sector.TicketValue, sector.PieceInfos())
+	sealingCtx := types.WithSectorDeadline(sector.SealingCtx(ctx.Context()), deadline-height)
+	pc1o, err := m.sealer.SealPreCommit1(sealingCtx, m.minerSector(sector.SectorType, sector.SectorNumber), sector.TicketValue, sector.PieceInfos())
 	if err != nil {
 		return ctx.Send(SectorSealPreCommit1Failed{xerrors.Errorf("seal pre commit(1) failed: %w", err)})
 	}
@@ -341,6 +346,9 @@ func (m *Sealing) handlePreCommit2(ctx statemachine.Context, sector types.Sector
 		return nil
 	}
 
+	// the precommit has to land before the ticket expires, or the sector be
+	// proven before the precommit expires
+	deadline := sector.TicketEpoch + types.MaxTicketAge
 	if checkTicketExpired(sector.TicketEpoch, height) {
 		pci, err := m.api.StateSectorPreCommitInfo(ctx.Context(), m.maddr, sector.SectorNumber, tok)
 		if err != nil {
@@ -372,9 +380,11 @@ func (m *Sealing) handlePreCommit2(ctx statemachine.Context, sector types.Sector
 		if checkProveCommitExpired(pci.PreCommitEpoch, msd, height) {
 			return ctx.Send(SectorOldTicket{}) // will be removed
 		}
+		deadline = pci.PreCommitEpoch + msd
 	}
 
-	cids, err := m.sealer.SealPreCommit2(sector.SealingCtx(ctx.Context()), m.minerSector(sector.SectorType, sector.SectorNumber), sector.PreCommit1Out)
+	sealingCtx := types.WithSectorDeadline(sector.SealingCtx(ctx.Context()), deadline-height)
+	cids, err := m.sealer.SealPreCommit2(sealingCtx, m.minerSector(sector.SectorType, sector.SectorNumber), sector.PreCommit1Out)
 	if err != nil {
 		return ctx.Send(SectorSealPreCommit2Failed{xerrors.Errorf("seal pre commit(2) failed: %w", err)})
 	}
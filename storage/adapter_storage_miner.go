@@ -503,3 +503,11 @@ func (s SealingAPIAdapter) MarkDealsAsPacking(ctx context.Context, miner address
 func (s SealingAPIAdapter) UpdateDealOnPacking(ctx context.Context, miner address.Address, dealId abi.DealID, sectorid abi.SectorNumber, offset abi.PaddedPieceSize) error {
 	return s.marketAPI.UpdateDealOnPacking(ctx, miner, dealId, sectorid, offset)
 }
+
+func (s SealingAPIAdapter) UpdateDealStatus(ctx context.Context, miner address.Address, dealId abi.DealID, status market3.PieceStatus) error {
+	return s.marketAPI.UpdateDealStatus(ctx, miner, dealId, status)
+}
+
+func (s SealingAPIAdapter) GetDeals(ctx context.Context, miner address.Address, pageIndex, pageSize int) ([]*market3.DealInfo, error) {
+	return s.marketAPI.GetDeals(ctx, miner, pageIndex, pageSize)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/filecoin-project/venus-sealer/config"
 	"github.com/filecoin-project/venus-sealer/constants"
 	"github.com/filecoin-project/venus-sealer/journal"
+	"github.com/filecoin-project/venus-sealer/notify"
 	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
 	"github.com/filecoin-project/venus-sealer/service"
@@ -56,6 +57,7 @@ type Miner struct {
 	metadataService   *service.MetadataService
 	sectorInfoService *service.SectorInfoService
 	logService        *service.LogService
+	assignmentService *service.DealAssignmentService
 	networkParams     *config.NetParamsConfig
 
 	api    fullNodeFilteredAPI
@@ -74,7 +76,8 @@ type Miner struct {
 
 	sealingEvtType journal.EventType
 
-	journal journal.Journal
+	journal  journal.Journal
+	notifier notify.Notifier
 }
 
 // SealingStateEvt is a journal event that records a sector state transition.
@@ -148,6 +151,7 @@ func NewMiner(api fullNodeFilteredAPI,
 	metaService *service.MetadataService,
 	sectorInfoService *service.SectorInfoService,
 	logService *service.LogService,
+	assignmentService *service.DealAssignmentService,
 	sealer sectorstorage.SectorManager,
 	sc types2.SectorIDCounter,
 	verif ffiwrapper.Verifier,
@@ -155,6 +159,7 @@ func NewMiner(api fullNodeFilteredAPI,
 	gsd types2.GetSealingConfigFunc,
 	feeCfg config.MinerFeeConfig,
 	journal journal.Journal,
+	notifier notify.Notifier,
 	as *AddressSelector,
 	networkParams *config.NetParamsConfig) (*Miner, error) {
 	m := &Miner{
@@ -173,7 +178,9 @@ func NewMiner(api fullNodeFilteredAPI,
 		maddr:             maddr,
 		getSealConfig:     gsd,
 		journal:           journal,
+		notifier:          notifier,
 		logService:        logService,
+		assignmentService: assignmentService,
 		pieceStorageMrg:   pieceStorageMgr,
 		sealingEvtType:    journal.RegisterEventType("storage", "sealing_states"),
 	}
@@ -216,7 +223,7 @@ func (m *Miner) Run(ctx context.Context) error {
 	}
 
 	// Instantiate the sealing FSM.
-	m.sealing = sealing.New(ctx, adaptedAPI, m.feeCfg, evtsAdapter, m.maddr, m.metadataService, m.sectorInfoService, m.logService, m.sealer, m.sc, m.verif, m.prover,
+	m.sealing = sealing.New(ctx, adaptedAPI, m.feeCfg, evtsAdapter, m.maddr, m.metadataService, m.sectorInfoService, m.logService, m.assignmentService, m.sealer, m.sc, m.verif, m.prover,
 		&pcp, cfg, m.handleSealingNotifications, as, m.networkParams, m.pieceStorageMrg)
 
 	// Run the sealing FSM.
@@ -235,6 +242,16 @@ func (m *Miner) handleSealingNotifications(before, after types2.SectorInfo) {
 			Error:        after.LastErr,
 		}
 	})
+
+	if before.State != after.State {
+		m.notifier.Notify(notify.SectorTopic(after.State), notify.SectorStateEvent{
+			SectorNumber: before.SectorNumber,
+			SectorType:   before.SectorType,
+			From:         before.State,
+			To:           after.State,
+			Error:        after.LastErr,
+		})
+	}
 }
 
 func (m *Miner) Stop(ctx context.Context) error {
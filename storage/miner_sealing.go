@@ -54,10 +54,26 @@ func (m *Miner) DealSector(ctx context.Context) ([]types.DealAssign, error) {
 	return m.sealing.DealSector(ctx)
 }
 
+func (m *Miner) DealPlan(ctx context.Context) (*types.DealPackingPlan, error) {
+	return m.sealing.DealPlan(ctx)
+}
+
+func (m *Miner) DealReconcile(ctx context.Context, fix bool) ([]*types.DealMismatch, error) {
+	return m.sealing.ReconcileDeals(ctx, fix)
+}
+
 func (m *Miner) RedoSector(ctx context.Context, rsi storiface.SectorRedoParams) error {
 	return m.sealing.RedoSector(ctx, rsi)
 }
 
+func (m *Miner) RecoverSectors(ctx context.Context, params storiface.SectorRecoverParams) ([]storiface.SectorRedoStatus, error) {
+	return m.sealing.RecoverSectors(ctx, params)
+}
+
+func (m *Miner) RedoStatus(ctx context.Context, sid abi.SectorNumber) (storiface.SectorRedoStatus, error) {
+	return m.sealing.RedoStatus(ctx, sid)
+}
+
 func (m *Miner) ForceSectorState(ctx context.Context, id abi.SectorNumber, state types.SectorState) error {
 	return m.sealing.ForceSectorState(ctx, id, state)
 }
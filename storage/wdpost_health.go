@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/venus-sealer/notify"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+
+	types3 "github.com/filecoin-project/venus/venus-shared/types/messager"
+
+	"github.com/filecoin-project/venus/venus-shared/types"
+)
+
+// runHealthSweeps checks the sector files of every deadline
+// HealthSweepLeadEpochs before it opens, see sweepDeadline.
+func (s *WindowPoStScheduler) runHealthSweeps(ctx context.Context) {
+	lead := abi.ChainEpoch(s.provingCfg.HealthSweepLeadEpochs)
+	if lead == 0 || s.healthRepo == nil {
+		return
+	}
+
+	// deadline index -> open epoch of its last sweep
+	swept := map[uint64]abi.ChainEpoch{}
+
+	tm := time.NewTicker(time.Duration(s.networkParams.BlockDelaySecs) * time.Second)
+	defer tm.Stop()
+
+	for {
+		select {
+		case <-tm.C:
+		case <-ctx.Done():
+			return
+		}
+
+		ts, err := s.api.ChainHead(ctx)
+		if err != nil {
+			log.Errorf("health sweep: get chain head: %v", err)
+			continue
+		}
+
+		di, err := s.api.StateMinerProvingDeadline(ctx, s.actor, ts.Key())
+		if err != nil {
+			log.Errorf("health sweep: get proving deadline: %v", err)
+			continue
+		}
+
+		for _, dl := range upcomingDeadlines(di, lead) {
+			if open, ok := swept[dl.Index]; ok && open == dl.Open {
+				continue
+			}
+			if err := s.sweepDeadline(ctx, dl, ts); err != nil {
+				log.Errorf("health sweep of deadline %d: %v", dl.Index, err)
+				continue
+			}
+			swept[dl.Index] = dl.Open
+		}
+	}
+}
+
+// upcomingDeadlines returns the deadlines after di opening within the next
+// lead epochs.
+func upcomingDeadlines(di *dline.Info, lead abi.ChainEpoch) []*dline.Info {
+	var out []*dline.Info
+	for k := uint64(1); k < di.WPoStPeriodDeadlines; k++ {
+		idx := di.Index + k
+		start := di.PeriodStart
+		if idx >= di.WPoStPeriodDeadlines {
+			idx -= di.WPoStPeriodDeadlines
+			start += di.WPoStProvingPeriod
+		}
+
+		dl := dline.NewInfo(start, idx, di.CurrentEpoch, di.WPoStPeriodDeadlines, di.WPoStProvingPeriod,
+			di.WPoStChallengeWindow, di.WPoStChallengeLookback, di.FaultDeclarationCutoff)
+		if dl.Open > di.CurrentEpoch+lead {
+			break
+		}
+		out = append(out, dl)
+	}
+	return out
+}
+
+// declarationClaims makes sure the faults and the recoveries of a deadline are
+// declared by only one of asyncFaultRecover and the health sweeps, whichever
+// gets to the deadline first.
+type declarationClaims struct {
+	lk      sync.Mutex
+	claimed map[declarationClaim]struct{}
+}
+
+type declarationClaim struct {
+	open     abi.ChainEpoch
+	recovery bool
+}
+
+// claim returns false if the fault (or recovery) declarations of the deadline
+// opening at open were already claimed.
+func (c *declarationClaims) claim(open abi.ChainEpoch, recovery bool) bool {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if c.claimed == nil {
+		c.claimed = map[declarationClaim]struct{}{}
+	}
+
+	key := declarationClaim{open: open, recovery: recovery}
+	if _, ok := c.claimed[key]; ok {
+		return false
+	}
+
+	for k := range c.claimed {
+		if k.open < open-miner.WPoStProvingPeriod {
+			delete(c.claimed, k)
+		}
+	}
+	c.claimed[key] = struct{}{}
+	return true
+}
+
+// sweepDeadline checks the files of the live sectors of the deadline and
+// records their health. When enabled, the sectors found unhealthy are declared
+// faulty and the faulty sectors found healthy again are declared recovered,
+// as long as the fault cutoff of the deadline isn't reached and the window
+// PoSt cycle didn't declare them already.
+func (s *WindowPoStScheduler) sweepDeadline(ctx context.Context, dl *dline.Info, ts *types.TipSet) error {
+	partitions, err := s.api.StateMinerPartitions(ctx, s.actor, dl.Index, ts.Key())
+	if err != nil {
+		return xerrors.Errorf("getting partitions: %w", err)
+	}
+
+	var (
+		now         = time.Now().Unix()
+		healths     []*types2.SectorHealth
+		unhealthy   []abi.SectorNumber
+		newFaults   = map[abi.SectorNumber]*types2.SectorHealth{}
+		recoverable = 0
+		params      = &miner.DeclareFaultsParams{}
+	)
+
+	for partIdx, partition := range partitions {
+		refs, err := s.provableSectors(ctx, partition.LiveSectors, ts.Key())
+		if err != nil {
+			return xerrors.Errorf("getting sectors of partition %d: %w", partIdx, err)
+		}
+
+		bad, err := s.faultTracker.CheckProvable(ctx, s.proofType, refs, nil)
+		if err != nil {
+			return xerrors.Errorf("checking provable sectors: %w", err)
+		}
+
+		newFaulty := bitfield.New()
+		for _, ref := range refs {
+			sn := ref.ID.Number
+			faulty, err := partition.FaultySectors.IsSet(uint64(sn))
+			if err != nil {
+				return xerrors.Errorf("checking faulty sectors: %w", err)
+			}
+			recovering, err := partition.RecoveringSectors.IsSet(uint64(sn))
+			if err != nil {
+				return xerrors.Errorf("checking recovering sectors: %w", err)
+			}
+
+			h := &types2.SectorHealth{
+				SectorNumber:  sn,
+				Deadline:      dl.Index,
+				Partition:     uint64(partIdx),
+				Healthy:       true,
+				FaultDeclared: faulty,
+				DeadlineOpen:  dl.Open,
+				CheckedAt:     now,
+			}
+			healths = append(healths, h)
+
+			reason, ok := bad[ref.ID]
+			if !ok {
+				if faulty && !recovering {
+					recoverable++
+				}
+				continue
+			}
+
+			h.Healthy = false
+			h.Reason = reason
+			unhealthy = append(unhealthy, sn)
+			if !faulty {
+				newFaulty.Set(uint64(sn))
+				newFaults[sn] = h
+			}
+		}
+
+		c, err := newFaulty.Count()
+		if err != nil {
+			return xerrors.Errorf("counting faulty sectors: %w", err)
+		}
+		if c > 0 {
+			params.Faults = append(params.Faults, miner.FaultDeclaration{
+				Deadline:  dl.Index,
+				Partition: uint64(partIdx),
+				Sectors:   newFaulty,
+			})
+		}
+	}
+
+	log.Infow("health sweep", "deadline", dl.Index, "open", dl.Open, "checked", len(healths), "unhealthy", len(unhealthy))
+
+	if len(unhealthy) > 0 {
+		s.notifier.Notify(notify.TopicWdPoStUnhealthy, notify.WdPoStEvent{
+			Deadline: dl.Index,
+			Open:     dl.Open,
+			Height:   ts.Height(),
+			Sectors:  unhealthy,
+		})
+	}
+
+	canDeclare := ts.Height() < dl.FaultCutoff
+
+	declareFaults := s.provingCfg.DeclareFaults && canDeclare && len(params.Faults) > 0
+	if declareFaults && !s.declClaims.claim(dl.Open, false) {
+		log.Infow("health sweep: faults already declared by the window PoSt cycle", "deadline", dl.Index)
+		declareFaults = false
+	}
+	declareRecoveries := s.provingCfg.DeclareRecoveries && canDeclare && recoverable > 0
+	if declareRecoveries && !s.declClaims.claim(dl.Open, true) {
+		log.Infow("health sweep: recoveries already declared by the window PoSt cycle", "deadline", dl.Index)
+		declareRecoveries = false
+	}
+
+	if declareFaults {
+		log.Errorw("DETECTED FAULTY SECTORS, declaring faults", "deadline", dl.Index, "count", len(newFaults))
+
+		sm, err := s.pushDeclareFaults(ctx, params)
+		if err == nil {
+			for _, h := range newFaults {
+				h.FaultDeclared = true
+			}
+			s.recordFaultDeclarations(dl.Open, params.Faults, sm.ID)
+		} else {
+			log.Errorf("declaring faults of deadline %d: %v", dl.Index, err)
+		}
+
+		s.journal.RecordEvent(s.evtTypes[evtTypeWdPoStFaults], func() interface{} {
+			return WdPoStFaultsProcessedEvt{
+				evtCommon:    sweepEvtCommon(dl, ts, err),
+				Declarations: params.Faults,
+				MessageUID:   optionalUID(sm),
+			}
+		})
+	}
+
+	if declareRecoveries {
+		recoveries, sm, err := s.declareRecoveries(ctx, dl.Index, partitions, ts.Key())
+		if err != nil {
+			log.Errorf("declaring recoveries of deadline %d: %v", dl.Index, err)
+		} else if sm != nil {
+			s.recordRecoveryDeclarations(dl.Open, recoveries, sm.ID)
+		}
+
+		s.journal.RecordEvent(s.evtTypes[evtTypeWdPoStRecoveries], func() interface{} {
+			return WdPoStRecoveriesProcessedEvt{
+				evtCommon:    sweepEvtCommon(dl, ts, err),
+				Declarations: recoveries,
+				MessageUID:   optionalUID(sm),
+			}
+		})
+	}
+
+	return s.healthRepo.SaveDeadlineHealth(dl.Index, healths)
+}
+
+// SectorHealth returns the health recorded by the last sweep of every deadline.
+func (s *WindowPoStScheduler) SectorHealth(context.Context) ([]*types2.SectorHealth, error) {
+	if s.healthRepo == nil {
+		return nil, xerrors.Errorf("sector health sweeps are not available")
+	}
+
+	return s.healthRepo.ListSectorHealth()
+}
+
+func sweepEvtCommon(dl *dline.Info, ts *types.TipSet, err error) evtCommon {
+	return evtCommon{
+		Deadline: dl,
+		Height:   ts.Height(),
+		TipSet:   ts.Cids(),
+		Error:    err,
+	}
+}
+
+func optionalUID(sm *types3.MessageWithUID) string {
+	if sm == nil {
+		return ""
+	}
+	return sm.ID
+}
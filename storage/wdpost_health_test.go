@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+	"github.com/filecoin-project/specs-actors/v8/actors/builtin"
+	"github.com/filecoin-project/specs-storage/storage"
+
+	tutils "github.com/filecoin-project/specs-actors/v2/support/testing"
+	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/journal"
+	"github.com/filecoin-project/venus-sealer/notify"
+	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+
+	"github.com/filecoin-project/venus/venus-shared/actors/policy"
+	"github.com/filecoin-project/venus/venus-shared/types"
+)
+
+type badSectorsFaultTracker map[abi.SectorNumber]string
+
+func (m badSectorsFaultTracker) CheckProvable(ctx context.Context, pp abi.RegisteredPoStProof, sectors []storage.SectorRef, rg storiface.RGetter) (map[abi.SectorID]string, error) {
+	bad := map[abi.SectorID]string{}
+	for _, sector := range sectors {
+		if reason, ok := m[sector.ID.Number]; ok {
+			bad[sector.ID] = reason
+		}
+	}
+	return bad, nil
+}
+
+type memSectorHealthRepo struct {
+	healths map[uint64][]*types2.SectorHealth
+}
+
+func (m *memSectorHealthRepo) SaveDeadlineHealth(deadline uint64, healths []*types2.SectorHealth) error {
+	m.healths[deadline] = healths
+	return nil
+}
+
+func (m *memSectorHealthRepo) ListSectorHealth() ([]*types2.SectorHealth, error) {
+	var out []*types2.SectorHealth
+	for _, healths := range m.healths {
+		out = append(out, healths...)
+	}
+	return out, nil
+}
+
+func TestUpcomingDeadlines(t *testing.T) {
+	di := dline.NewInfo(0, miner5.WPoStPeriodDeadlines-1, miner5.WPoStProvingPeriod-10, miner5.WPoStPeriodDeadlines,
+		miner5.WPoStProvingPeriod, miner5.WPoStChallengeWindow, miner5.WPoStChallengeLookback, miner5.FaultDeclarationCutoff)
+
+	dls := upcomingDeadlines(di, 3*miner5.WPoStChallengeWindow)
+	require.Len(t, dls, 3)
+	for i, dl := range dls {
+		require.Equal(t, uint64(i), dl.Index)
+		require.Equal(t, miner5.WPoStProvingPeriod, dl.PeriodStart)
+		require.Equal(t, miner5.WPoStProvingPeriod+abi.ChainEpoch(i)*miner5.WPoStChallengeWindow, dl.Open)
+	}
+
+	require.Empty(t, upcomingDeadlines(di, 5))
+}
+
+func TestSweepDeadline(t *testing.T) {
+	ctx := context.Background()
+
+	// sectors 0 and 1 are live, 2 is faulty
+	live := bitfield.NewFromSet([]uint64{0, 1, 2})
+	mockStgMinerAPI := newMockStorageMinerAPI()
+	mockStgMinerAPI.pushedMessages = make(chan *types.Message, 2)
+	mockStgMinerAPI.setPartitions([]types.Partition{{
+		AllSectors:        live,
+		FaultySectors:     bitfield.NewFromSet([]uint64{2}),
+		RecoveringSectors: bitfield.New(),
+		LiveSectors:       live,
+		ActiveSectors:     bitfield.NewFromSet([]uint64{0, 1}),
+	}})
+
+	healthRepo := &memSectorHealthRepo{healths: map[uint64][]*types2.SectorHealth{}}
+	scheduler := &WindowPoStScheduler{
+		Messager: &mockMessagerAPI{pushedMessages: mockStgMinerAPI.pushedMessages},
+		api:      mockStgMinerAPI,
+		networkParams: &config.NetParamsConfig{
+			ForkLengthThreshold: policy.ChainFinality,
+			BlockDelaySecs:      30,
+		},
+		faultTracker: badSectorsFaultTracker{1: "file not found"},
+		proofType:    abi.RegisteredPoStProof_StackedDrgWindow2KiBV1,
+		provingCfg: config.ProvingConfig{
+			HealthSweepLeadEpochs: 180,
+			DeclareFaults:         true,
+			DeclareRecoveries:     true,
+		},
+		healthRepo: healthRepo,
+		actor:      tutils.NewIDAddr(t, 100),
+		journal:    journal.NilJournal(),
+		notifier:   notify.Nil,
+		addrSel:    &AddressSelector{},
+	}
+
+	ts := mockTipSet(t)
+	dl := dline.NewInfo(0, 3, ts.Height(), miner5.WPoStPeriodDeadlines, miner5.WPoStProvingPeriod,
+		miner5.WPoStChallengeWindow, miner5.WPoStChallengeLookback, miner5.FaultDeclarationCutoff)
+	require.NoError(t, scheduler.sweepDeadline(ctx, dl, ts))
+
+	// sector 1 is declared faulty and sector 2 recovered
+	msg := <-mockStgMinerAPI.pushedMessages
+	require.Equal(t, builtin.MethodsMiner.DeclareFaults, msg.Method)
+	var faults miner.DeclareFaultsParams
+	require.NoError(t, faults.UnmarshalCBOR(bytes.NewReader(msg.Params)))
+	require.Len(t, faults.Faults, 1)
+	require.Equal(t, uint64(3), faults.Faults[0].Deadline)
+	faulty, err := faults.Faults[0].Sectors.All(10)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1}, faulty)
+
+	msg = <-mockStgMinerAPI.pushedMessages
+	require.Equal(t, builtin.MethodsMiner.DeclareFaultsRecovered, msg.Method)
+	var recoveries miner.DeclareFaultsRecoveredParams
+	require.NoError(t, recoveries.UnmarshalCBOR(bytes.NewReader(msg.Params)))
+	require.Len(t, recoveries.Recoveries, 1)
+	recovered, err := recoveries.Recoveries[0].Sectors.All(10)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, recovered)
+
+	healths, err := scheduler.SectorHealth(ctx)
+	require.NoError(t, err)
+	require.Len(t, healths, 3)
+	for _, h := range healths {
+		require.Equal(t, uint64(3), h.Deadline)
+		require.Equal(t, dl.Open, h.DeadlineOpen)
+		switch h.SectorNumber {
+		case 0:
+			require.True(t, h.Healthy)
+			require.False(t, h.FaultDeclared)
+		case 1:
+			require.False(t, h.Healthy)
+			require.Equal(t, "file not found", h.Reason)
+			require.True(t, h.FaultDeclared)
+		case 2:
+			require.True(t, h.Healthy)
+			require.True(t, h.FaultDeclared)
+		}
+	}
+
+	// the declarations of a deadline are only made once
+	require.NoError(t, scheduler.sweepDeadline(ctx, dl, ts))
+	require.Len(t, mockStgMinerAPI.pushedMessages, 0)
+
+	// nor declared by the sweep once the window PoSt cycle declared them
+	next := dline.NewInfo(0, 4, ts.Height(), miner5.WPoStPeriodDeadlines, miner5.WPoStProvingPeriod,
+		miner5.WPoStChallengeWindow, miner5.WPoStChallengeLookback, miner5.FaultDeclarationCutoff)
+	require.True(t, scheduler.declClaims.claim(next.Open, false))
+	require.True(t, scheduler.declClaims.claim(next.Open, true))
+	require.NoError(t, scheduler.sweepDeadline(ctx, next, ts))
+	require.Len(t, mockStgMinerAPI.pushedMessages, 0)
+	require.Len(t, healthRepo.healths[4], 3)
+}
+
+func TestDeclarationClaims(t *testing.T) {
+	var claims declarationClaims
+
+	require.True(t, claims.claim(100, false))
+	require.False(t, claims.claim(100, false))
+	require.True(t, claims.claim(100, true))
+	require.True(t, claims.claim(160, false))
+
+	// claims of the previous proving periods are dropped
+	require.True(t, claims.claim(100+miner.WPoStProvingPeriod+1, false))
+	require.Len(t, claims.claimed, 2)
+	require.True(t, claims.claim(100, true))
+}
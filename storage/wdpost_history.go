@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/venus-sealer/api"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+
+	"github.com/filecoin-project/venus/venus-shared/types"
+)
+
+// updateProvingHistory applies update to the history of the partitions of a
+// deadline in the proving period where it opens at open. Failing to record the
+// history never fails the window PoSt, errors are only logged.
+func (s *WindowPoStScheduler) updateProvingHistory(deadline uint64, open abi.ChainEpoch, partitions []uint64, update func(*types2.PartitionPoSt)) {
+	if s.historyRepo == nil || len(partitions) == 0 {
+		return
+	}
+
+	s.historyLk.Lock()
+	defer s.historyLk.Unlock()
+
+	existing, err := s.historyRepo.GetDeadlinePoSt(deadline, open)
+	if err != nil {
+		log.Errorf("getting proving history of deadline %d: %v", deadline, err)
+		return
+	}
+	byPartition := make(map[uint64]*types2.PartitionPoSt, len(existing))
+	for _, record := range existing {
+		byPartition[record.Partition] = record
+	}
+
+	now := time.Now().Unix()
+	records := make([]*types2.PartitionPoSt, 0, len(partitions))
+	for _, partIdx := range partitions {
+		record, ok := byPartition[partIdx]
+		if !ok {
+			record = &types2.PartitionPoSt{
+				Deadline:           deadline,
+				Partition:          partIdx,
+				Open:               open,
+				Status:             types2.PoStPending,
+				Skipped:            bitfield.New(),
+				DeclaredFaults:     bitfield.New(),
+				DeclaredRecoveries: bitfield.New(),
+			}
+		}
+		update(record)
+		record.UpdatedAt = now
+		records = append(records, record)
+	}
+
+	if err := s.historyRepo.SavePartitionPoSt(records); err != nil {
+		log.Errorf("saving proving history of deadline %d: %v", deadline, err)
+	}
+}
+
+// recordGeneratedPoSt records the proofs generated for the partitions of the
+// deadline, report holds the partition batches of the run.
+func (s *WindowPoStScheduler) recordGeneratedPoSt(ctx context.Context, di *dline.Info, ts *types.TipSet, report *api.DeadlineSimulation, posts []miner.SubmitWindowedPoStParams, err error) {
+	if s.historyRepo == nil {
+		return
+	}
+
+	var partitions []uint64
+	if err != nil {
+		// every proof is dropped, the whole deadline failed
+		parts, perr := s.api.StateMinerPartitions(ctx, s.actor, di.Index, ts.Key())
+		if perr != nil {
+			log.Errorf("getting partitions for the proving history: %v", perr)
+			return
+		}
+		for partIdx := range parts {
+			partitions = append(partitions, uint64(partIdx))
+		}
+	}
+
+	took := map[uint64]time.Duration{}
+	for _, batch := range report.Batches {
+		for _, partIdx := range batch.Partitions {
+			took[partIdx] = batch.Took
+			if err == nil {
+				partitions = append(partitions, partIdx)
+			}
+		}
+	}
+
+	proven := map[uint64]miner.PoStPartition{}
+	for _, post := range posts {
+		for _, partition := range post.Partitions {
+			proven[partition.Index] = partition
+		}
+	}
+
+	s.updateProvingHistory(di.Index, di.Open, partitions, func(record *types2.PartitionPoSt) {
+		record.Height = ts.Height()
+		record.ProvingTime = took[record.Partition]
+		record.MessageUID = ""
+		record.Error = ""
+		record.Skipped = bitfield.New()
+
+		if err != nil {
+			record.Status = types2.PoStFailed
+			record.Error = err.Error()
+			return
+		}
+		partition, ok := proven[record.Partition]
+		if !ok {
+			record.Status = types2.PoStSkipped
+			return
+		}
+		record.Status = types2.PoStComputed
+		record.Skipped = partition.Skipped
+	})
+}
+
+// recordSubmittedPoSt records the message of a proof, or why it failed to be sent.
+func (s *WindowPoStScheduler) recordSubmittedPoSt(di *dline.Info, post *miner.SubmitWindowedPoStParams, uid string, err error) {
+	partitions := make([]uint64, len(post.Partitions))
+	for i, partition := range post.Partitions {
+		partitions[i] = partition.Index
+	}
+
+	s.updateProvingHistory(di.Index, di.Open, partitions, func(record *types2.PartitionPoSt) {
+		if err != nil {
+			record.Status = types2.PoStFailed
+			record.Error = err.Error()
+			return
+		}
+		record.Status = types2.PoStProven
+		record.MessageUID = uid
+	})
+}
+
+// recordFaultDeclarations records the faults declared ahead of a deadline
+// opening at open.
+func (s *WindowPoStScheduler) recordFaultDeclarations(open abi.ChainEpoch, faults []miner.FaultDeclaration, uid string) {
+	for _, decl := range faults {
+		sectors := decl.Sectors
+		s.updateProvingHistory(decl.Deadline, open, []uint64{decl.Partition}, func(record *types2.PartitionPoSt) {
+			merged, err := bitfield.MergeBitFields(record.DeclaredFaults, sectors)
+			if err != nil {
+				log.Errorf("merging declared faults: %v", err)
+				return
+			}
+			record.DeclaredFaults = merged
+			record.FaultsMessageUID = uid
+		})
+	}
+}
+
+// recordRecoveryDeclarations records the recoveries declared ahead of a
+// deadline opening at open.
+func (s *WindowPoStScheduler) recordRecoveryDeclarations(open abi.ChainEpoch, recoveries []miner.RecoveryDeclaration, uid string) {
+	for _, decl := range recoveries {
+		sectors := decl.Sectors
+		s.updateProvingHistory(decl.Deadline, open, []uint64{decl.Partition}, func(record *types2.PartitionPoSt) {
+			merged, err := bitfield.MergeBitFields(record.DeclaredRecoveries, sectors)
+			if err != nil {
+				log.Errorf("merging declared recoveries: %v", err)
+				return
+			}
+			record.DeclaredRecoveries = merged
+			record.RecoveriesMessageUID = uid
+		})
+	}
+}
+
+// ProvingHistory returns the proving history of the deadlines opening at or
+// after from, latest first.
+func (s *WindowPoStScheduler) ProvingHistory(_ context.Context, from abi.ChainEpoch) ([]*types2.PartitionPoSt, error) {
+	if s.historyRepo == nil {
+		return nil, xerrors.Errorf("proving history is not available")
+	}
+	return s.historyRepo.ListPartitionPoSt(from)
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	tutils "github.com/filecoin-project/specs-actors/v2/support/testing"
+	miner5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/miner"
+
+	"github.com/filecoin-project/venus-sealer/config"
+	"github.com/filecoin-project/venus-sealer/journal"
+	"github.com/filecoin-project/venus-sealer/notify"
+	types2 "github.com/filecoin-project/venus-sealer/types"
+
+	"github.com/filecoin-project/venus/venus-shared/actors/policy"
+	"github.com/filecoin-project/venus/venus-shared/types"
+)
+
+type partitionPoStKey struct {
+	deadline, partition uint64
+	open                abi.ChainEpoch
+}
+
+type memProvingHistoryRepo struct {
+	records map[partitionPoStKey]types2.PartitionPoSt
+}
+
+func (m *memProvingHistoryRepo) GetDeadlinePoSt(deadline uint64, open abi.ChainEpoch) ([]*types2.PartitionPoSt, error) {
+	var out []*types2.PartitionPoSt
+	for key, record := range m.records {
+		if key.deadline == deadline && key.open == open {
+			record := record
+			out = append(out, &record)
+		}
+	}
+	return out, nil
+}
+
+func (m *memProvingHistoryRepo) SavePartitionPoSt(records []*types2.PartitionPoSt) error {
+	for _, record := range records {
+		m.records[partitionPoStKey{record.Deadline, record.Partition, record.Open}] = *record
+	}
+	return nil
+}
+
+func (m *memProvingHistoryRepo) ListPartitionPoSt(from abi.ChainEpoch) ([]*types2.PartitionPoSt, error) {
+	var out []*types2.PartitionPoSt
+	for _, record := range m.records {
+		if record.Open >= from {
+			record := record
+			out = append(out, &record)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Open != out[j].Open {
+			return out[i].Open > out[j].Open
+		}
+		return out[i].Partition < out[j].Partition
+	})
+	return out, nil
+}
+
+func TestProvingHistory(t *testing.T) {
+	ctx := context.Background()
+
+	// partition 0 has sectors to prove, partition 1 is empty
+	sectors := bitfield.NewFromSet([]uint64{0, 1})
+	mockStgMinerAPI := newMockStorageMinerAPI()
+	mockStgMinerAPI.pushedMessages = make(chan *types.Message, 1)
+	mockStgMinerAPI.setPartitions([]types.Partition{{
+		AllSectors:        sectors,
+		FaultySectors:     bitfield.New(),
+		RecoveringSectors: bitfield.New(),
+		LiveSectors:       sectors,
+		ActiveSectors:     sectors,
+	}, {
+		AllSectors:        bitfield.New(),
+		FaultySectors:     bitfield.New(),
+		RecoveringSectors: bitfield.New(),
+		LiveSectors:       bitfield.New(),
+		ActiveSectors:     bitfield.New(),
+	}})
+
+	historyRepo := &memProvingHistoryRepo{records: map[partitionPoStKey]types2.PartitionPoSt{}}
+	scheduler := &WindowPoStScheduler{
+		Messager: &mockMessagerAPI{pushedMessages: mockStgMinerAPI.pushedMessages},
+		api:      mockStgMinerAPI,
+		networkParams: &config.NetParamsConfig{
+			ForkLengthThreshold: policy.ChainFinality,
+			BlockDelaySecs:      30,
+		},
+		prover:       &mockProver{},
+		verifier:     &mockVerif{},
+		faultTracker: &mockFaultTracker{},
+		proofType:    abi.RegisteredPoStProof_StackedDrgWindow2KiBV1,
+		historyRepo:  historyRepo,
+		actor:        tutils.NewIDAddr(t, 100),
+		journal:      journal.NilJournal(),
+		notifier:     notify.Nil,
+		addrSel:      &AddressSelector{},
+	}
+
+	ts := mockTipSet(t)
+	di := dline.NewInfo(0, 0, ts.Height(), miner5.WPoStPeriodDeadlines, miner5.WPoStProvingPeriod,
+		miner5.WPoStChallengeWindow, miner5.WPoStChallengeLookback, miner5.FaultDeclarationCutoff)
+
+	// faults declared ahead of the deadline are kept with its proof
+	scheduler.recordFaultDeclarations(di.Open, []miner.FaultDeclaration{{
+		Deadline:  di.Index,
+		Partition: 0,
+		Sectors:   bitfield.NewFromSet([]uint64{5}),
+	}}, "faults")
+
+	posts, err := scheduler.runGeneratePoST(ctx, ts, di)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+
+	records, err := scheduler.ProvingHistory(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, types2.PoStComputed, records[0].Status)
+	require.Equal(t, ts.Height(), records[0].Height)
+	require.Equal(t, types2.PoStSkipped, records[1].Status)
+
+	require.NoError(t, scheduler.runSubmitPoST(ctx, ts, di, posts))
+	<-mockStgMinerAPI.pushedMessages
+
+	records, err = scheduler.ProvingHistory(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, types2.PoStProven, records[0].Status)
+	require.NotEmpty(t, records[0].MessageUID)
+	require.Equal(t, "faults", records[0].FaultsMessageUID)
+	faults, err := records[0].DeclaredFaults.All(10)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5}, faults)
+	require.Equal(t, types2.PoStSkipped, records[1].Status)
+	require.Empty(t, records[1].MessageUID)
+}
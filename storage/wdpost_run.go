@@ -22,6 +22,7 @@ import (
 
 	"github.com/filecoin-project/venus-sealer/api"
 	"github.com/filecoin-project/venus-sealer/constants"
+	"github.com/filecoin-project/venus-sealer/notify"
 
 	types3 "github.com/filecoin-project/venus/venus-shared/types/messager"
 
@@ -48,6 +49,9 @@ func (s *WindowPoStScheduler) recordPoStFailure(err error, ts *types.TipSet, dea
 		}
 	})
 
+	recordPoStResult(deadline, "failed")
+	s.notifyPoStResult(notify.TopicWdPoStFailed, ts, deadline, err)
+
 	log.Errorf("Got err %+v - TODO handle errors", err)
 	/*s.failLk.Lock()
 	if eps > s.failed {
@@ -102,7 +106,10 @@ func (s *WindowPoStScheduler) runGeneratePoST(
 	ctx, span := trace.StartSpan(ctx, "WindowPoStScheduler.generatePoST")
 	defer span.End()
 
-	posts, err := s.runPoStCycle(ctx, false, *deadline, ts)
+	// the report holds the proving time of the partition batches for the history
+	var report api.DeadlineSimulation
+	posts, err := s.runPoStCycle(ctx, false, *deadline, ts, &report)
+	s.recordGeneratedPoSt(ctx, deadline, ts, &report, posts, err)
 	if err != nil {
 		log.Errorf("runPoStCycle failed: %+v", err)
 		return nil, err
@@ -136,6 +143,8 @@ func (s *WindowPoStScheduler) startSubmitPoST(
 					State:     SchedulerStateSucceeded,
 				}
 			})
+			recordPoStResult(deadline, "succeeded")
+			s.notifyPoStResult(notify.TopicWdPoStSucceeded, ts, deadline, nil)
 		}
 		completeSubmitPoST(err)
 	}()
@@ -171,6 +180,9 @@ func (s *WindowPoStScheduler) runSubmitPoST(
 	if err != nil {
 		err = xerrors.Errorf("failed to get chain randomness from tickets for windowPost (ts=%d; deadline=%d): %w", ts.Height(), commEpoch, err)
 		log.Errorf("submitPoStMessage failed: %+v", err)
+		for i := range posts {
+			s.recordSubmittedPoSt(deadline, &posts[i], "", err)
+		}
 
 		return err
 	}
@@ -184,6 +196,7 @@ func (s *WindowPoStScheduler) runSubmitPoST(
 
 		// Submit PoST
 		uid, err := s.submitPoStMessage(ctx, post)
+		s.recordSubmittedPoSt(deadline, post, uid, err)
 		if err != nil {
 			log.Errorf("submit window post failed: %+v", err)
 			submitErr = err
@@ -196,35 +209,14 @@ func (s *WindowPoStScheduler) runSubmitPoST(
 }
 
 func (s *WindowPoStScheduler) checkSectors(ctx context.Context, check bitfield.BitField, tsk types.TipSetKey) (bitfield.BitField, error) {
-	mid, err := address.IDFromAddress(s.actor)
-	if err != nil {
-		return bitfield.BitField{}, err
-	}
-
-	sectorInfos, err := s.api.StateMinerSectors(ctx, s.actor, &check, tsk)
+	tocheck, err := s.provableSectors(ctx, check, tsk)
 	if err != nil {
 		return bitfield.BitField{}, err
 	}
 
 	sectors := make(map[abi.SectorNumber]struct{})
-	var tocheck []storage.SectorRef
-	for _, info := range sectorInfos {
-		sectorPerCommitInfo, err := s.api.StateSectorPreCommitInfo(ctx, s.actor, info.SectorNumber, tsk)
-		if err != nil {
-			return bitfield.BitField{}, err
-		}
-		if sectorPerCommitInfo.Info.ReplaceCapacity == false {
-			delete(sectors, info.SectorNumber)
-		} else {
-			sectors[info.SectorNumber] = struct{}{}
-			tocheck = append(tocheck, storage.SectorRef{
-				ProofType: info.SealProof,
-				ID: abi.SectorID{
-					Miner:  abi.ActorID(mid),
-					Number: info.SectorNumber,
-				},
-			})
-		}
+	for _, ref := range tocheck {
+		sectors[ref.ID.Number] = struct{}{}
 	}
 
 	bad, err := s.faultTracker.CheckProvable(ctx, s.proofType, tocheck, nil)
@@ -245,6 +237,41 @@ func (s *WindowPoStScheduler) checkSectors(ctx context.Context, check bitfield.B
 	return sbf, nil
 }
 
+// provableSectors returns the sectors of check which have to be proven, the
+// sectors storing file increments (no ReplaceCapacity in their precommit) are
+// left out.
+func (s *WindowPoStScheduler) provableSectors(ctx context.Context, check bitfield.BitField, tsk types.TipSetKey) ([]storage.SectorRef, error) {
+	mid, err := address.IDFromAddress(s.actor)
+	if err != nil {
+		return nil, err
+	}
+
+	sectorInfos, err := s.api.StateMinerSectors(ctx, s.actor, &check, tsk)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []storage.SectorRef
+	for _, info := range sectorInfos {
+		sectorPerCommitInfo, err := s.api.StateSectorPreCommitInfo(ctx, s.actor, info.SectorNumber, tsk)
+		if err != nil {
+			return nil, err
+		}
+		if !sectorPerCommitInfo.Info.ReplaceCapacity {
+			continue
+		}
+		refs = append(refs, storage.SectorRef{
+			ProofType: info.SealProof,
+			ID: abi.SectorID{
+				Miner:  abi.ActorID(mid),
+				Number: info.SectorNumber,
+			},
+		})
+	}
+
+	return refs, nil
+}
+
 // declareRecoveries identifies sectors that were previously marked as faulty
 // for our miner, but are now recovered (i.e. are now provable again) and
 // still not reported as such.
@@ -421,9 +448,16 @@ func (s *WindowPoStScheduler) declareFaults(ctx context.Context, dlIdx uint64, p
 
 	log.Errorw("DETECTED FAULTY SECTORS, declaring faults", "count", bad)
 
+	sm, err := s.pushDeclareFaults(ctx, params)
+	return faults, sm, err
+}
+
+// pushDeclareFaults sends the `DeclareFaults` message and awaits for
+// build.MessageConfidence confirmations on chain.
+func (s *WindowPoStScheduler) pushDeclareFaults(ctx context.Context, params *miner.DeclareFaultsParams) (*types3.MessageWithUID, error) {
 	enc, aerr := actors.SerializeParams(params)
 	if aerr != nil {
-		return faults, nil, xerrors.Errorf("could not serialize declare faults parameters: %w", aerr)
+		return nil, xerrors.Errorf("could not serialize declare faults parameters: %w", aerr)
 	}
 
 	msg := &types.Message{
@@ -434,12 +468,12 @@ func (s *WindowPoStScheduler) declareFaults(ctx context.Context, dlIdx uint64, p
 	}
 	spec := &types.MessageSendSpec{MaxFee: abi.TokenAmount(s.feeCfg.MaxWindowPoStGasFee)}
 	if err := s.prepareMessage(ctx, msg, spec); err != nil {
-		return faults, nil, err
+		return nil, err
 	}
 
 	uid, err := s.Messager.PushMessage(ctx, msg, &types3.SendSpec{MaxFee: spec.MaxFee})
 	if err != nil {
-		return faults, nil, xerrors.Errorf("pushing message to mpool: %w", err)
+		return nil, xerrors.Errorf("pushing message to mpool: %w", err)
 	}
 	sm := &types3.MessageWithUID{
 		UnsignedMessage: *msg,
@@ -449,14 +483,14 @@ func (s *WindowPoStScheduler) declareFaults(ctx context.Context, dlIdx uint64, p
 
 	rec, err := s.Messager.WaitMessage(context.TODO(), sm.ID, constants.MessageConfidence)
 	if err != nil {
-		return faults, sm, xerrors.Errorf("declare faults wait error: %w", err)
+		return sm, xerrors.Errorf("declare faults wait error: %w", err)
 	}
 
 	if rec.Receipt.ExitCode != 0 {
-		return faults, sm, xerrors.Errorf("declare faults wait non-0 exit code: %d", rec.Receipt.ExitCode)
+		return sm, xerrors.Errorf("declare faults wait non-0 exit code: %d", rec.Receipt.ExitCode)
 	}
 
-	return faults, sm, nil
+	return sm, nil
 }
 
 func (s *WindowPoStScheduler) asyncFaultRecover(di dline.Info, ts *types.TipSet) {
@@ -464,6 +498,7 @@ func (s *WindowPoStScheduler) asyncFaultRecover(di dline.Info, ts *types.TipSet)
 		// check faults / recoveries for the *next* deadline. It's already too
 		// late to declare them for this deadline
 		declDeadline := (di.Index + 2) % di.WPoStPeriodDeadlines
+		declOpen := di.Open + 2*di.WPoStChallengeWindow
 
 		partitions, err := s.api.StateMinerPartitions(context.TODO(), s.actor, declDeadline, ts.Key())
 		if err != nil {
@@ -487,9 +522,13 @@ func (s *WindowPoStScheduler) asyncFaultRecover(di dline.Info, ts *types.TipSet)
 			}
 		)
 
-		if recoveries, uidMsg, err = s.declareRecoveries(context.TODO(), declDeadline, partitions, ts.Key()); err != nil {
+		if !s.declClaims.claim(declOpen, true) {
+			log.Infow("recoveries already declared by the health sweep", "deadline", declDeadline)
+		} else if recoveries, uidMsg, err = s.declareRecoveries(context.TODO(), declDeadline, partitions, ts.Key()); err != nil {
 			// TODO: This is potentially quite bad, but not even trying to post when this fails is objectively worse
 			log.Errorf("checking sector recoveries: %v", err)
+		} else if uidMsg != nil {
+			s.recordRecoveryDeclarations(declOpen, recoveries, uidMsg.ID)
 		}
 
 		s.journal.RecordEvent(s.evtTypes[evtTypeWdPoStRecoveries], func() interface{} {
@@ -506,9 +545,13 @@ func (s *WindowPoStScheduler) asyncFaultRecover(di dline.Info, ts *types.TipSet)
 			return // FORK: declaring faults after ignition upgrade makes no sense
 		}
 
-		if faults, uidMsg, err = s.declareFaults(context.TODO(), declDeadline, partitions, ts.Key()); err != nil {
+		if !s.declClaims.claim(declOpen, false) {
+			log.Infow("faults already declared by the health sweep", "deadline", declDeadline)
+		} else if faults, uidMsg, err = s.declareFaults(context.TODO(), declDeadline, partitions, ts.Key()); err != nil {
 			// TODO: This is also potentially really bad, but we try to post anyways
 			log.Errorf("checking sector faults: %v", err)
+		} else if uidMsg != nil {
+			s.recordFaultDeclarations(declOpen, faults, uidMsg.ID)
 		}
 
 		s.journal.RecordEvent(s.evtTypes[evtTypeWdPoStFaults], func() interface{} {
@@ -530,9 +573,12 @@ func (s *WindowPoStScheduler) asyncFaultRecover(di dline.Info, ts *types.TipSet)
 //
 // When `manual` is set, no messages (fault/recover) will be automatically sent
 //
+// When `report` is set, the sectors and the proving time of every partition
+// batch are recorded in it
+//
 // Now, the increment is recorded in the precommit onchain messages, so we now need to skip the
 // sectors which store the file increments. Also, the algorithm of the WindowPoSt need to be changed.
-func (s *WindowPoStScheduler) runPoStCycle(ctx context.Context, manual bool, di dline.Info, ts *types.TipSet) ([]miner.SubmitWindowedPoStParams, error) {
+func (s *WindowPoStScheduler) runPoStCycle(ctx context.Context, manual bool, di dline.Info, ts *types.TipSet, report *api.DeadlineSimulation) ([]miner.SubmitWindowedPoStParams, error) {
 	ctx, span := trace.StartSpan(ctx, "storage.runPoStCycle")
 	defer span.End()
 
@@ -598,6 +644,18 @@ func (s *WindowPoStScheduler) runPoStCycle(ctx context.Context, manual bool, di
 		postSkipped := bitfield.New()
 		somethingToProve := false
 
+		var batchReport *api.PoStBatchSimulation
+		if report != nil {
+			report.Batches = append(report.Batches, api.PoStBatchSimulation{})
+			batchReport = &report.Batches[len(report.Batches)-1]
+			for partIdx, partition := range batch {
+				batchReport.Partitions = append(batchReport.Partitions, uint64(batchPartitionStartIdx+partIdx))
+				if batchReport.Faulty, err = addCount(batchReport.Faulty, partition.FaultySectors); err != nil {
+					return nil, err
+				}
+			}
+		}
+
 		// Retry until we run out of sectors to prove.
 		// this is the large cycle to generate PoSt
 		for retries := 0; ; retries++ {
@@ -644,6 +702,12 @@ func (s *WindowPoStScheduler) runPoStCycle(ctx context.Context, manual bool, di
 
 				skipCount += sc
 
+				if batchReport != nil && retries == 0 {
+					if batchReport.Checked, err = addCount(batchReport.Checked, toProve); err != nil {
+						return nil, err
+					}
+				}
+
 				ssi, err := s.sectorsForProof(ctx, good, partition.AllSectors, ts)
 				if err != nil {
 					return nil, xerrors.Errorf("getting sorted sector info: %w", err)
@@ -660,6 +724,11 @@ func (s *WindowPoStScheduler) runPoStCycle(ctx context.Context, manual bool, di
 				})
 			}
 
+			if batchReport != nil {
+				batchReport.Skipped = skipCount
+				batchReport.Proven = uint64(len(xsinfos))
+			}
+
 			if len(xsinfos) == 0 {
 				// nothing to prove for this batch
 				break
@@ -682,6 +751,9 @@ func (s *WindowPoStScheduler) runPoStCycle(ctx context.Context, manual bool, di
 			postOut, ps, err := s.prover.GenerateWindowPoSt(ctx, abi.ActorID(mid), xsinfos, append(abi.PoStRandomness{}, rand...))
 			elapsed := time.Since(tsStart)
 			log.Infow("computing window post", "batch", batchIdx, "elapsed", elapsed)
+			if batchReport != nil {
+				batchReport.Took += elapsed
+			}
 			if err != nil {
 				log.Errorf("error generating window post: %s", err)
 			}
@@ -44,6 +44,7 @@ import (
 	"github.com/filecoin-project/venus-sealer/config"
 	"github.com/filecoin-project/venus-sealer/constants"
 	"github.com/filecoin-project/venus-sealer/journal"
+	"github.com/filecoin-project/venus-sealer/notify"
 	"github.com/filecoin-project/venus-sealer/sector-storage/storiface"
 )
 
@@ -249,6 +250,7 @@ func TestWDPostDoPost(t *testing.T) {
 		proofType:    proofType,
 		actor:        postAct,
 		journal:      journal.NilJournal(),
+		notifier:     notify.Nil,
 		addrSel:      &AddressSelector{},
 	}
 
@@ -281,6 +283,20 @@ func TestWDPostDoPost(t *testing.T) {
 			require.Len(t, params.Partitions, partitionsPerMsg)
 		}
 	}
+
+	// A compute-only run reports every partition batch
+	var report api.DeadlineSimulation
+	posts, err := scheduler.runPoStCycle(ctx, true, *di, ts, &report)
+	require.NoError(t, err)
+	require.Len(t, posts, expectedMsgCount)
+	require.Len(t, report.Batches, expectedMsgCount)
+	require.Len(t, report.Batches[0].Partitions, partitionsPerMsg)
+	require.Equal(t, []uint64{uint64(partitionCount - 1)}, report.Batches[expectedMsgCount-1].Partitions)
+	for _, batch := range report.Batches {
+		require.Equal(t, uint64(len(batch.Partitions))*sectorsPerPartition, batch.Checked)
+		require.Equal(t, batch.Checked, batch.Proven+batch.Skipped)
+		require.Zero(t, batch.Faulty)
+	}
 }
 
 func mockTipSet(t *testing.T) *types.TipSet {
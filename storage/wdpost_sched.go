@@ -2,8 +2,12 @@ package storage
 
 import (
 	"context"
+	"strconv"
+	"sync"
 	"time"
 
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-address"
@@ -14,6 +18,9 @@ import (
 	"github.com/filecoin-project/venus-sealer/api"
 	"github.com/filecoin-project/venus-sealer/config"
 	"github.com/filecoin-project/venus-sealer/journal"
+	"github.com/filecoin-project/venus-sealer/metrics"
+	"github.com/filecoin-project/venus-sealer/models/repo"
+	"github.com/filecoin-project/venus-sealer/notify"
 	sectorstorage "github.com/filecoin-project/venus-sealer/sector-storage"
 	"github.com/filecoin-project/venus-sealer/sector-storage/ffiwrapper"
 
@@ -40,10 +47,18 @@ type WindowPoStScheduler struct {
 	partitionSectors uint64
 	ch               *changeHandler
 
+	provingCfg config.ProvingConfig
+	healthRepo repo.SectorHealthRepo
+	declClaims declarationClaims
+
+	historyRepo repo.ProvingHistoryRepo
+	historyLk   sync.Mutex
+
 	actor address.Address
 
 	evtTypes [4]journal.EventType
 	journal  journal.Journal
+	notifier notify.Notifier
 
 	// failed abi.ChainEpoch // eps
 	// failLk sync.Mutex
@@ -58,6 +73,10 @@ func NewWindowedPoStScheduler(api fullNodeFilteredAPI,
 	verif ffiwrapper.Verifier,
 	ft sectorstorage.FaultTracker,
 	j journal.Journal,
+	notifier notify.Notifier,
+	provingCfg config.ProvingConfig,
+	healthRepo repo.SectorHealthRepo,
+	historyRepo repo.ProvingHistoryRepo,
 	actor address.Address,
 	networkParams *config.NetParamsConfig) (*WindowPoStScheduler, error) {
 	mi, err := api.StateMinerInfo(context.TODO(), actor, types.EmptyTSK)
@@ -77,6 +96,11 @@ func NewWindowedPoStScheduler(api fullNodeFilteredAPI,
 		proofType:        mi.WindowPoStProofType,
 		partitionSectors: mi.WindowPoStPartitionSectors,
 
+		provingCfg: provingCfg,
+		healthRepo: healthRepo,
+
+		historyRepo: historyRepo,
+
 		actor: actor,
 		evtTypes: [...]journal.EventType{
 			evtTypeWdPoStScheduler:  j.RegisterEventType("wdpost", "scheduler"),
@@ -84,7 +108,8 @@ func NewWindowedPoStScheduler(api fullNodeFilteredAPI,
 			evtTypeWdPoStRecoveries: j.RegisterEventType("wdpost", "recoveries_processed"),
 			evtTypeWdPoStFaults:     j.RegisterEventType("wdpost", "faults_processed"),
 		},
-		journal: j,
+		journal:  j,
+		notifier: notifier,
 	}, nil
 }
 
@@ -101,6 +126,8 @@ func (s *WindowPoStScheduler) Run(ctx context.Context) {
 	defer s.ch.shutdown()
 	s.ch.start()
 
+	go s.runHealthSweeps(ctx)
+
 	latest, err := s.api.ChainHead(ctx)
 	if err != nil {
 		log.Errorf("get chain head: %v", err)
@@ -186,6 +213,35 @@ func (s *WindowPoStScheduler) onAbort(ts *types.TipSet, deadline *dline.Info) {
 			State:     SchedulerStateAborted,
 		}
 	})
+	recordPoStResult(deadline, "aborted")
+}
+
+// notifyPoStResult sends the outcome of the window post of a deadline to the webhooks
+func (s *WindowPoStScheduler) notifyPoStResult(topic string, ts *types.TipSet, deadline *dline.Info, err error) {
+	evt := notify.WdPoStEvent{}
+	if deadline != nil {
+		evt.Deadline = deadline.Index
+		evt.Open = deadline.Open
+	}
+	if ts != nil {
+		evt.Height = ts.Height()
+	}
+	if err != nil {
+		evt.Error = err.Error()
+	}
+	s.notifier.Notify(topic, evt)
+}
+
+// recordPoStResult counts the outcome of the window post of a deadline
+func recordPoStResult(deadline *dline.Info, result string) {
+	dl := "unknown"
+	if deadline != nil {
+		dl = strconv.FormatUint(deadline.Index, 10)
+	}
+	_ = stats.RecordWithTags(context.TODO(), []tag.Mutator{
+		tag.Upsert(metrics.Deadline, dl),
+		tag.Upsert(metrics.Result, result),
+	}, metrics.WdPoStResults.M(1))
 }
 
 func (s *WindowPoStScheduler) getEvtCommon(err error) evtCommon {
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/dline"
+
+	"github.com/filecoin-project/venus-sealer/api"
+	"github.com/filecoin-project/venus-sealer/constants"
+)
+
+// Simulate runs the PoSt cycle of the deadlines, all of them when empty, in
+// compute-only mode: the proofs are generated but no fault, recovery or PoSt
+// message is sent. Deadlines whose challenge isn't reached yet in the current
+// proving period are proven with the challenge of the previous period.
+func (s *WindowPoStScheduler) Simulate(ctx context.Context, deadlines []uint64) (*api.WindowPoStSimulation, error) {
+	ts, err := s.api.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+
+	di, err := s.api.StateMinerProvingDeadline(ctx, s.actor, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting proving deadline: %w", err)
+	}
+
+	if len(deadlines) == 0 {
+		for idx := uint64(0); idx < di.WPoStPeriodDeadlines; idx++ {
+			deadlines = append(deadlines, idx)
+		}
+	}
+
+	out := &api.WindowPoStSimulation{
+		Epoch:           ts.Height(),
+		ChallengeWindow: time.Duration(di.WPoStChallengeWindow) * time.Duration(constants.BlockDelaySecs) * time.Second,
+	}
+
+	start := time.Now()
+	for _, idx := range deadlines {
+		if idx >= di.WPoStPeriodDeadlines {
+			return nil, xerrors.Errorf("invalid deadline %d, the proving period has %d", idx, di.WPoStPeriodDeadlines)
+		}
+
+		dl := dline.NewInfo(di.PeriodStart, idx, ts.Height(), di.WPoStPeriodDeadlines, di.WPoStProvingPeriod, di.WPoStChallengeWindow, di.WPoStChallengeLookback, di.FaultDeclarationCutoff)
+		if dl.Challenge > ts.Height() {
+			dl = dline.NewInfo(di.PeriodStart-di.WPoStProvingPeriod, idx, ts.Height(), di.WPoStPeriodDeadlines, di.WPoStProvingPeriod, di.WPoStChallengeWindow, di.WPoStChallengeLookback, di.FaultDeclarationCutoff)
+		}
+
+		report := api.DeadlineSimulation{
+			Index:     idx,
+			Challenge: dl.Challenge,
+		}
+
+		dlStart := time.Now()
+		if _, err := s.runPoStCycle(ctx, true, *dl, ts, &report); err != nil {
+			log.Warnw("window post simulation failed", "deadline", idx, "error", err)
+			report.Error = err.Error()
+		}
+		report.Took = time.Since(dlStart)
+		for _, batch := range report.Batches {
+			report.Partitions += len(batch.Partitions)
+		}
+
+		var proving time.Duration
+		for _, batch := range report.Batches {
+			proving += batch.Took
+		}
+		if proving > out.ChallengeWindow {
+			log.Warnw("window post simulation exceeds the challenge window", "deadline", idx, "proving", proving, "window", out.ChallengeWindow)
+		}
+
+		out.Deadlines = append(out.Deadlines, report)
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	out.Took = time.Since(start)
+
+	return out, nil
+}
+
+func addCount(n uint64, bf bitfield.BitField) (uint64, error) {
+	c, err := bf.Count()
+	if err != nil {
+		return 0, xerrors.Errorf("counting sectors: %w", err)
+	}
+	return n + c, nil
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/go-state-types/network"
+
+	"github.com/filecoin-project/venus/venus-shared/actors/builtin"
+	"github.com/filecoin-project/venus/venus-shared/types"
+
+	"github.com/filecoin-project/venus-sealer/api"
+)
+
+type winningPoStDryRunAPI interface {
+	ChainHead(context.Context) (*types.TipSet, error)
+	ChainGetTipSetByHeight(context.Context, abi.ChainEpoch, types.TipSetKey) (*types.TipSet, error)
+	StateMinerActiveSectors(context.Context, address.Address, types.TipSetKey) ([]*miner.SectorOnChainInfo, error)
+	StateGetRandomnessFromBeacon(ctx context.Context, personalization crypto.DomainSeparationTag, randEpoch abi.ChainEpoch, entropy []byte, tsk types.TipSetKey) (abi.Randomness, error)
+	StateNetworkVersion(context.Context, types.TipSetKey) (network.Version, error)
+}
+
+// WinningPoStDryRun computes a winning PoSt of the miner for the epoch, 0 uses
+// the chain head, like the block producer would when the miner wins an
+// election, so that the readiness of the sectors and the proving time can be
+// checked. The challenge randomness is drawn from the beacon at the epoch, the
+// challenged sectors may differ from the ones of a real election.
+func WinningPoStDryRun(ctx context.Context, full winningPoStDryRunAPI, prover WinningPoStProver, maddr address.Address, epoch abi.ChainEpoch) (*api.WinningPoStDryRun, error) {
+	head, err := full.ChainHead(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("getting chain head: %w", err)
+	}
+	if epoch == 0 {
+		epoch = head.Height()
+	}
+	if epoch > head.Height() {
+		return nil, xerrors.Errorf("epoch %d is after the chain head %d", epoch, head.Height())
+	}
+
+	ts, err := full.ChainGetTipSetByHeight(ctx, epoch, head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting tipset at %d: %w", epoch, err)
+	}
+
+	sectors, err := full.StateMinerActiveSectors(ctx, maddr, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting active sectors: %w", err)
+	}
+	if len(sectors) == 0 {
+		return nil, xerrors.Errorf("miner %s has no active sectors at %d", maddr, epoch)
+	}
+
+	nv, err := full.StateNetworkVersion(ctx, ts.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting network version: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := maddr.MarshalCBOR(buf); err != nil {
+		return nil, err
+	}
+	rand, err := full.StateGetRandomnessFromBeacon(ctx, crypto.DomainSeparationTag_WinningPoStChallengeSeed, epoch, buf.Bytes(), head.Key())
+	if err != nil {
+		return nil, xerrors.Errorf("getting winning post randomness: %w", err)
+	}
+
+	out := &api.WinningPoStDryRun{
+		Epoch:           epoch,
+		EligibleSectors: uint64(len(sectors)),
+	}
+
+	start := time.Now()
+	candidates, err := prover.GenerateCandidates(ctx, abi.PoStRandomness(rand), uint64(len(sectors)))
+	if err != nil {
+		return nil, xerrors.Errorf("generating candidates: %w", err)
+	}
+	out.CandidatesTook = time.Since(start)
+
+	challenged := make([]builtin.ExtendedSectorInfo, 0, len(candidates))
+	for _, idx := range candidates {
+		if idx >= uint64(len(sectors)) {
+			return nil, xerrors.Errorf("candidate index %d out of range, %d sectors", idx, len(sectors))
+		}
+		s := sectors[idx]
+		challenged = append(challenged, builtin.ExtendedSectorInfo{
+			SealProof:    s.SealProof,
+			SectorNumber: s.SectorNumber,
+			SectorKey:    s.SectorKeyCID,
+			SealedCID:    s.SealedCID,
+		})
+		out.Challenged = append(out.Challenged, s.SectorNumber)
+	}
+
+	start = time.Now()
+	proofs, err := prover.ComputeProof(ctx, challenged, abi.PoStRandomness(rand), epoch, nv)
+	if err != nil {
+		return nil, xerrors.Errorf("computing proof: %w", err)
+	}
+	out.ProofTook = time.Since(start)
+	for _, p := range proofs {
+		out.ProofSize += len(p.ProofBytes)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin/v8/miner"
+	"github.com/filecoin-project/go-state-types/crypto"
+	"github.com/filecoin-project/go-state-types/network"
+	tutils "github.com/filecoin-project/specs-actors/v2/support/testing"
+
+	"github.com/filecoin-project/venus/venus-shared/actors/builtin"
+	"github.com/filecoin-project/venus/venus-shared/types"
+)
+
+type dryRunAPI struct {
+	ts      *types.TipSet
+	sectors []*miner.SectorOnChainInfo
+}
+
+func (a *dryRunAPI) ChainHead(context.Context) (*types.TipSet, error) {
+	return a.ts, nil
+}
+
+func (a *dryRunAPI) ChainGetTipSetByHeight(context.Context, abi.ChainEpoch, types.TipSetKey) (*types.TipSet, error) {
+	return a.ts, nil
+}
+
+func (a *dryRunAPI) StateMinerActiveSectors(context.Context, address.Address, types.TipSetKey) ([]*miner.SectorOnChainInfo, error) {
+	return a.sectors, nil
+}
+
+func (a *dryRunAPI) StateGetRandomnessFromBeacon(context.Context, crypto.DomainSeparationTag, abi.ChainEpoch, []byte, types.TipSetKey) (abi.Randomness, error) {
+	return make([]byte, abi.RandomnessLength), nil
+}
+
+func (a *dryRunAPI) StateNetworkVersion(context.Context, types.TipSetKey) (network.Version, error) {
+	return network.Version16, nil
+}
+
+// dryRunProver challenges the last sector and records the proven sectors
+type dryRunProver struct {
+	proven []builtin.ExtendedSectorInfo
+}
+
+func (p *dryRunProver) GenerateCandidates(_ context.Context, _ abi.PoStRandomness, eligible uint64) ([]uint64, error) {
+	return []uint64{eligible - 1}, nil
+}
+
+func (p *dryRunProver) ComputeProof(_ context.Context, ssi []builtin.ExtendedSectorInfo, _ abi.PoStRandomness, _ abi.ChainEpoch, _ network.Version) ([]builtin.PoStProof, error) {
+	p.proven = ssi
+	return []builtin.PoStProof{{ProofBytes: []byte("valid proof")}}, nil
+}
+
+func TestWinningPoStDryRun(t *testing.T) {
+	ctx := context.Background()
+	maddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	full := &dryRunAPI{ts: mockTipSet(t)}
+	prover := &dryRunProver{}
+
+	_, err = WinningPoStDryRun(ctx, full, prover, maddr, 0)
+	require.Error(t, err, "no active sectors")
+
+	_, err = WinningPoStDryRun(ctx, full, prover, maddr, 10)
+	require.Error(t, err, "epoch after the head")
+
+	for i := 0; i < 3; i++ {
+		full.sectors = append(full.sectors, &miner.SectorOnChainInfo{
+			SectorNumber: abi.SectorNumber(i),
+			SealProof:    abi.RegisteredSealProof_StackedDrg32GiBV1_1,
+			SealedCID:    tutils.MakeCID("sealed", nil),
+		})
+	}
+
+	res, err := WinningPoStDryRun(ctx, full, prover, maddr, 0)
+	require.NoError(t, err)
+	require.Equal(t, full.ts.Height(), res.Epoch)
+	require.Equal(t, uint64(3), res.EligibleSectors)
+	require.Equal(t, []abi.SectorNumber{2}, res.Challenged)
+	require.Equal(t, len("valid proof"), res.ProofSize)
+	require.Len(t, prover.proven, 1)
+	require.Equal(t, abi.SectorNumber(2), prover.proven[0].SectorNumber)
+}
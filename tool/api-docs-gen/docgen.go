@@ -1,6 +1,7 @@
 package docgen
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -99,6 +100,9 @@ func init() {
 	addExample(abi.UnpaddedPieceSize(1024).Padded())
 	addExample(abi.DealID(5432))
 	addExample(abi.SectorNumber(9))
+	sectorNumber := abi.SectorNumber(9)
+	addExample(&sectorNumber)
+	addExample(json.RawMessage(`{"SectorNumber":9}`))
 	addExample(types.UnKnown)
 	addExample(abi.SectorSize(32 * 1024 * 1024 * 1024))
 	addExample(network.Connected)
@@ -152,11 +156,15 @@ func init() {
 		123: "can't acquire read lock",
 	})
 	addExample([]abi.SectorNumber{123, 124})
+	addExample(map[abi.SectorNumber]cid.Cid{123: c})
 	addExample(map[string]interface{}{"abc": 123})
 
 	addExample(storiface.FTUnsealed)
 	addExample(storiface.PathSealing)
+	addExample(storiface.RedoPreCommit1)
 	addExample(stype.TTAddPiece)
+	addExample(stype.PoStProven)
+	addExample(stype.DealUnreported)
 	addExample(map[string][]stype.SealedRef{"10": {ExampleValue("init", reflect.TypeOf(stype.SealedRef{}), nil).(stype.SealedRef)}})
 	addExample(map[api.SectorState]int{
 		ExampleValue("init", reflect.TypeOf(api.SectorState("")), nil).(api.SectorState): 0})
@@ -171,6 +179,8 @@ func init() {
 		ExampleValue("init", reflect.TypeOf(stype.TTAddPiece), nil).(stype.TaskType): {
 			ExampleValue("init", reflect.TypeOf(abi.RegisteredSealProof_StackedDrg2KiBV1), nil).(abi.RegisteredSealProof): ExampleValue("init", reflect.TypeOf(storiface.Resources{}), nil).(storiface.Resources),
 		}})
+	addExample(map[stype.TaskType]int64{
+		ExampleValue("init", reflect.TypeOf(stype.TTPreCommit1), nil).(stype.TaskType): 2})
 	addExample(map[uuid.UUID]storiface.WorkerStats{
 		ExampleValue("init", reflect.TypeOf(uuid.UUID{}), nil).(uuid.UUID): ExampleValue("init", reflect.TypeOf(storiface.WorkerStats{}), nil).(storiface.WorkerStats)})
 }
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+)
+
+// DealAssignment is a deal added to a sector which isn't reported to
+// venus-market yet. It's recorded before UpdateDealOnPacking is called and
+// dropped once venus-market and the sector pieces agree.
+type DealAssignment struct {
+	DealID       abi.DealID
+	SectorNumber abi.SectorNumber
+	Offset       abi.PaddedPieceSize
+	PieceCID     cid.Cid
+	Size         abi.PaddedPieceSize
+
+	Attempts  int    // failed UpdateDealOnPacking calls
+	LastError string // error of the last attempt
+
+	CreatedAt int64 // unix seconds
+	UpdatedAt int64
+}
+
+func (a *DealAssignment) DealAssign() DealAssign {
+	return DealAssign{
+		DealId:   a.DealID,
+		SectorId: a.SectorNumber,
+		PieceCid: a.PieceCID,
+		Offset:   a.Offset,
+		Size:     a.Size,
+	}
+}
+
+type DealMismatchKind string
+
+const (
+	// the sector holds the deal, venus-market doesn't know it's packed
+	DealUnreported DealMismatchKind = "unreported"
+	// venus-market and the sector pieces disagree on where the deal is
+	DealMisplaced DealMismatchKind = "misplaced"
+	// venus-market has the deal packed in a sector which doesn't hold it
+	DealMissing DealMismatchKind = "missing"
+)
+
+// DealMismatch is a deal on which venus-market and the sector pieces disagree
+type DealMismatch struct {
+	DealID abi.DealID
+	Kind   DealMismatchKind
+
+	SectorNumber abi.SectorNumber // sector holding the deal, if any
+	Offset       abi.PaddedPieceSize
+
+	MarketStatus string
+	MarketSector abi.SectorNumber
+	MarketOffset abi.PaddedPieceSize
+
+	Fixed bool
+	Error string // why the fix failed
+}
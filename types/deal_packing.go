@@ -0,0 +1,44 @@
+package types
+
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+)
+
+// DealPackingPlan is the assignment of the unpacked deals to sectors proposed
+// by the deal packing policy
+type DealPackingPlan struct {
+	SectorSize abi.SectorSize
+	Head       abi.ChainEpoch // chain head the plan was computed at
+
+	Sectors  []*PlannedSector
+	Deferred []*DeferredDeal // deals left for a later run
+}
+
+// PlannedSector is a sector to be filled with deals, a new sector is created
+// for it when the deals are packed
+type PlannedSector struct {
+	Group       string // client and verified grouping key of the deals
+	Deals       []*PlannedDeal
+	Used        abi.PaddedPieceSize // including the padding between pieces
+	Utilisation float64
+
+	StartEpoch abi.ChainEpoch // earliest start of the deals
+	EndEpoch   abi.ChainEpoch // latest end of the deals
+}
+
+type PlannedDeal struct {
+	DealID     abi.DealID
+	PieceCID   cid.Cid
+	Size       abi.PaddedPieceSize
+	Client     address.Address
+	Verified   bool
+	StartEpoch abi.ChainEpoch
+	EndEpoch   abi.ChainEpoch
+}
+
+type DeferredDeal struct {
+	PlannedDeal
+	Reason string
+}
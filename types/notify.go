@@ -0,0 +1,14 @@
+package types
+
+// NotifyMessage is an event waiting in the outbox to be delivered to a webhook
+type NotifyMessage struct {
+	ID      int64
+	Sink    string // name of the webhook
+	Topic   string
+	Payload string // json encoded event
+
+	Attempts    int
+	NextAttempt int64 // unix seconds
+	LastErr     string
+	CreatedAt   int64 // unix seconds
+}
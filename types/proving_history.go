@@ -0,0 +1,47 @@
+package types
+
+import (
+	"time"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/go-state-types/abi"
+)
+
+// PoStStatus is the outcome of the window PoSt of a partition
+type PoStStatus string
+
+const (
+	// PoStPending is a partition with declarations only, its deadline didn't run yet
+	PoStPending PoStStatus = "pending"
+	// PoStComputed is a partition whose proof is generated but not submitted yet
+	PoStComputed PoStStatus = "computed"
+	// PoStProven is a partition whose proof message was sent
+	PoStProven PoStStatus = "proven"
+	// PoStSkipped is a partition without any sector to prove, it isn't part of any proof
+	PoStSkipped PoStStatus = "skipped"
+	// PoStFailed is a partition whose proof failed to be generated or submitted
+	PoStFailed PoStStatus = "failed"
+)
+
+// PartitionPoSt is the proving history of a partition in a proving period
+type PartitionPoSt struct {
+	Deadline  uint64
+	Partition uint64
+	Open      abi.ChainEpoch // open epoch of the deadline, identifies the proving period
+
+	Status      PoStStatus
+	Height      abi.ChainEpoch    // epoch the proof was computed at
+	MessageUID  string            // uid of the SubmitWindowedPoSt message
+	Skipped     bitfield.BitField // sectors skipped by the proof
+	ProvingTime time.Duration     // proving time of the partition batch
+	Error       string
+
+	// DeclaredFaults and DeclaredRecoveries are the declarations sent ahead of
+	// the deadline
+	DeclaredFaults       bitfield.BitField
+	FaultsMessageUID     string
+	DeclaredRecoveries   bitfield.BitField
+	RecoveriesMessageUID string
+
+	UpdatedAt int64 // unix seconds
+}
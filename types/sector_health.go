@@ -0,0 +1,20 @@
+package types
+
+import "github.com/filecoin-project/go-state-types/abi"
+
+// SectorHealth is the result of the last health sweep of a sector, which
+// checks the sector files ahead of the window PoSt of its deadline
+type SectorHealth struct {
+	SectorNumber abi.SectorNumber
+	Deadline     uint64
+	Partition    uint64
+
+	Healthy bool
+	Reason  string // why the sector can't be proven, empty when healthy
+
+	// FaultDeclared is set when the sweep declared the sector faulty
+	FaultDeclared bool
+
+	DeadlineOpen abi.ChainEpoch // open epoch of the deadline the sector was checked for
+	CheckedAt    int64          // unix seconds
+}
@@ -2,6 +2,8 @@ package types
 
 import (
 	"context"
+
+	"github.com/filecoin-project/go-state-types/abi"
 )
 
 type schedPrioCtxKey int
@@ -21,3 +23,17 @@ func GetPriority(ctx context.Context) int {
 func WithPriority(ctx context.Context, priority int) context.Context {
 	return context.WithValue(ctx, SchedPriorityKey, priority)
 }
+
+type sectorDeadlineCtxKey struct{}
+
+// WithSectorDeadline records how many epochs are left before the sector misses
+// its next deadline, e.g. its ticket expiring before the precommit lands.
+func WithSectorDeadline(ctx context.Context, left abi.ChainEpoch) context.Context {
+	return context.WithValue(ctx, sectorDeadlineCtxKey{}, left)
+}
+
+// GetSectorDeadline returns the epochs left recorded by WithSectorDeadline
+func GetSectorDeadline(ctx context.Context) (abi.ChainEpoch, bool) {
+	left, ok := ctx.Value(sectorDeadlineCtxKey{}).(abi.ChainEpoch)
+	return left, ok
+}
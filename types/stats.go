@@ -72,6 +72,18 @@ func (ss *SectorStats) curStagingLocked() uint64 {
 	return ss.Totals[SstStaging]
 }
 
+// StateCounts returns a copy of the number of sectors in each state
+func (ss *SectorStats) StateCounts() map[SectorState]int64 {
+	ss.lk.Lock()
+	defer ss.lk.Unlock()
+
+	out := make(map[SectorState]int64, len(ss.ByState))
+	for st, n := range ss.ByState {
+		out[st] = n
+	}
+	return out
+}
+
 // return the number of sectors currently in the sealing pipeline
 func (ss *SectorStats) CurSealing() uint64 {
 	ss.lk.Lock()
@@ -0,0 +1,32 @@
+package types
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+
+	"github.com/filecoin-project/venus-sealer/sector-storage/fsutil"
+)
+
+// StoragePath is a storage path attached to the sector index, as persisted in the db
+type StoragePath struct {
+	ID         string
+	URLs       []string
+	Weight     uint64
+	MaxStorage uint64
+
+	CanSeal  bool
+	CanStore bool
+
+	Groups  []string
+	AllowTo []string
+
+	// filesystem stat reported when the path was last attached
+	Stat fsutil.FsStat
+}
+
+// SectorDecl records that a sector file of FileType (storiface.SectorFileType) is stored in a storage path
+type SectorDecl struct {
+	StorageID string
+	Sector    abi.SectorID
+	FileType  int
+	Primary   bool
+}
@@ -20,6 +20,8 @@ const (
 	TTProveReplicaUpdate2   TaskType = "seal/v0/provereplicaupdate/2"
 	TTRegenSectorKey        TaskType = "seal/v0/regensectorkey"
 	TTFinalizeReplicaUpdate TaskType = "seal/v0/finalize/replicaupdate"
+
+	TTGenerateWindowPoSt TaskType = "post/v0/windowproof"
 )
 
 var order = map[TaskType]int{
@@ -35,8 +37,9 @@ var order = map[TaskType]int{
 	TTCommit1:             2,
 	TTUnseal:              1,
 
-	TTFetch:    -1,
-	TTFinalize: -2, // most priority
+	TTFetch:              -1,
+	TTFinalize:           -2,
+	TTGenerateWindowPoSt: -3, // most priority
 }
 
 var shortNames = map[TaskType]string{
@@ -58,6 +61,8 @@ var shortNames = map[TaskType]string{
 	TTProveReplicaUpdate2:   "PR2",
 	TTRegenSectorKey:        "GSK",
 	TTFinalizeReplicaUpdate: "FRU",
+
+	TTGenerateWindowPoSt: "WDP",
 }
 
 func (a TaskType) MuchLess(b TaskType) (bool, bool) {